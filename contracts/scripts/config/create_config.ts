@@ -1,19 +1,21 @@
 import fs from "fs";
+import path from "node:path";
 import { ethers } from "ethers";
-import hre from "hardhat";
 import { parseFlag } from "./utils";
 
-type RawLog = {
-  topics: string[],
-  data: string
-}
+const CONTRACTS_DIR = path.join(__dirname, "/../../");
+require("dotenv").config({ path: path.join(CONTRACTS_DIR, ".genesis.env") });
 
 async function main() {
   const baseConfigPath = parseFlag("--in");
   const configPath = parseFlag("--out");
   const genesisPath = parseFlag("--genesis");
+  const genesisHashPath = parseFlag(
+    "--genesis-hash",
+    path.join(CONTRACTS_DIR, process.env.GENESIS_EXPORTED_HASH_PATH || "")
+  );
   const deploymentsPath = parseFlag("--deployments", "./deployments/localhost");
-  await generateConfigFile(baseConfigPath, configPath, genesisPath, deploymentsPath);
+  await generateConfigFile(baseConfigPath, configPath, genesisPath, genesisHashPath, deploymentsPath);
 }
 
 /**
@@ -24,6 +26,7 @@ export async function generateConfigFile(
   baseConfigPath: string,
   configPath: string,
   genesisPath: string,
+  genesisHashPath: string,
   deploymentsPath: string
 ) {
   // check the deployments dir - error out if it is not there
@@ -34,15 +37,10 @@ export async function generateConfigFile(
   const l1Number = deployment.receipt.blockNumber;
   const l1Hash= deployment.receipt.blockHash;
 
-  // parse receipt logs to get L2 vm hash
-  const txLogs: RawLog[] = deployment.receipt.logs;
-  const artifacts = await hre.artifacts.readArtifact("Rollup")
-  const iface = new ethers.utils.Interface(artifacts.abi);
-  const l2Hash = txLogs
-    .map(l => iface.parseLog(l))
-    .filter(l => l.name === 'AssertionCreated')
-    .map(l => l.args["vmHash"])
-    .pop()
+  // the genesis assertion's VM hash commits to more than the L2 block hash,
+  // so read the L2 genesis block hash from the exported genesis hash file
+  const l2Hash = JSON.parse(fs.readFileSync(genesisHashPath, "utf-8")).hash;
+  if (!l2Hash) throw Error(`hash not found in ${genesisHashPath}`);
 
   // Write out new file
   // TODO: use on-chain data-only or genesis-only
@@ -50,6 +48,8 @@ export async function generateConfigFile(
   baseConfig.genesis.l1.hash = l1Hash;
   baseConfig.genesis.l1.number = l1Number;
   baseConfig.genesis.l2.hash = l2Hash;
+  // the genesis tool exports a V1 genesis VM hash, so V1 must be asserted from genesis on
+  baseConfig.vm_hash_version_activations = { ...baseConfig.vm_hash_version_activations, 1: 0 };
   const genesis = JSON.parse(fs.readFileSync(genesisPath, "utf-8"));
   baseConfig.genesis.l2_time = ethers.BigNumber.from(genesis.timestamp).toNumber() || 0;
 
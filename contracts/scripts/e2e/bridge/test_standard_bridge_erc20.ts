@@ -143,6 +143,8 @@ async function main() {
   }
 
   const withdrawalProof = await getWithdrawalProof(
+    rollup,
+    assertionId,
     l2Portal.address,
     withdrawalEvent.args.withdrawalHash
   );
@@ -150,7 +152,7 @@ async function main() {
   const finalizeTx = await l1Portal.finalizeWithdrawalTransaction(
     crossDomainMessage,
     assertionId,
-    withdrawalProof.accountProof,
+    withdrawalProof.vmState,
     withdrawalProof.storageProof
   );
   await finalizeTx.wait();
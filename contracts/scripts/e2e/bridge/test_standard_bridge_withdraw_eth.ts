@@ -81,7 +81,9 @@ async function main() {
     await delay(500);
   }
 
-  const { accountProof, storageProof } = await getWithdrawalProof(
+  const { vmState, storageProof } = await getWithdrawalProof(
+    rollup,
+    assertionId,
     l2Portal.address,
     initEvent.args.withdrawalHash
   );
@@ -89,7 +91,7 @@ async function main() {
   const finalizeTx = await l1Portal.finalizeWithdrawalTransaction(
     crossDomainMessage,
     assertionId,
-    accountProof,
+    vmState,
     storageProof
   );
   await finalizeTx.wait();
@@ -107,15 +107,32 @@ export async function getDepositProof(portalAddress, depositHash) {
   };
 }
 
-export async function getWithdrawalProof(portalAddress, withdrawalHash) {
+// Returns the proof of a withdrawal at the L2 block asserted by `assertionId`,
+// along with the VM state committed to by the assertion.
+export async function getWithdrawalProof(
+  rollup,
+  assertionId,
+  portalAddress,
+  withdrawalHash
+) {
+  const assertion = await rollup.getAssertion(assertionId);
+  const blockTag = ethers.utils.hexValue(assertion.blockNum);
   const proof = await l2Provider.send("eth_getProof", [
     portalAddress,
     [getStorageKey(withdrawalHash)],
-    "latest",
+    blockTag,
+  ]);
+  const block = await l2Provider.send("eth_getBlockByNumber", [
+    blockTag,
+    false,
   ]);
 
   return {
-    accountProof: proof.accountProof,
+    vmState: {
+      stateRoot: block.stateRoot,
+      blockHash: block.hash,
+      withdrawalStorageRoot: proof.storageHash,
+    },
     storageProof: proof.storageProof[0].proof,
   };
 }
@@ -179,6 +179,17 @@ interface IRollup {
      */
     function confirmedBlockNum() external view returns (uint256);
 
+    /**
+     * @return The minimum number of L1 blocks between the proposals of an assertion and its parent.
+     */
+    function minimumAssertionPeriod() external view returns (uint256);
+
+    /**
+     * @param addr Address to query.
+     * @return Funds withdrawable by the address (won or left over from challenges).
+     */
+    function withdrawableFunds(address addr) external view returns (uint256);
+
     /**
      * @notice Requires that the first unresolved assertion is confirmable. Otherwise, reverts.
      * This is exposed as a utility function to validators.
@@ -50,7 +50,7 @@ abstract contract RollupBase is
     // Config parameters
     uint256 public confirmationPeriod; // number of L1 blocks
     uint256 public challengePeriod; // number of L1 blocks
-    uint256 public minimumAssertionPeriod; // number of L1 blocks
+    uint256 public override minimumAssertionPeriod; // number of L1 blocks
     uint256 public baseStakeAmount; // number of stake tokens
 
     address public vault;
@@ -97,7 +97,7 @@ contract Rollup is RollupBase {
     // Staking state
     uint256 public numStakers; // current total number of stakers
     mapping(address => Staker) public stakers; // mapping from staker addresses to corresponding stakers
-    mapping(address => uint256) public withdrawableFunds; // mapping from addresses to withdrawable funds (won in challenge)
+    mapping(address => uint256) public override withdrawableFunds; // mapping from addresses to withdrawable funds (won in challenge)
     Zombie[] public zombies; // stores stakers that lost a challenge
 
     function initialize(
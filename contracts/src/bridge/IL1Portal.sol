@@ -47,14 +47,13 @@ interface IL1Portal {
      *
      * @param withdrawalTx           Withdrawal transaction to finalize.
      * @param assertionID            ID of the assertion that can be used to finalize the withdrawal.
-     * @param withdrawalAccountProof Inclusion proof of the L2Portal contract's storage root.
+     * @param vmState                L2 state committed to by the assertion's VM hash.
      * @param withdrawalProof        Inclusion proof of the withdrawal in L2Portal contract.
      */
     function finalizeWithdrawalTransaction(
         Types.CrossDomainMessage memory withdrawalTx,
         uint256 assertionID,
-        // bytes calldata encodedBlockHeader,
-        bytes[] calldata withdrawalAccountProof,
+        Types.VMState calldata vmState,
         bytes[] calldata withdrawalProof
     ) external;
 
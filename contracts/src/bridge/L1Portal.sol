@@ -9,8 +9,6 @@ import {PausableUpgradeable} from "@openzeppelin/contracts-upgradeable/security/
 import {SafeCall} from "../libraries/SafeCall.sol";
 import {Types} from "../libraries/Types.sol";
 import {Hashing} from "../libraries/Hashing.sol";
-import {Encoding} from "../libraries/Hashing.sol";
-import {Predeploys} from "../libraries/Predeploys.sol";
 import {MerkleTrie} from "../libraries/trie/MerkleTrie.sol";
 import {SecureMerkleTrie} from "../libraries/trie/SecureMerkleTrie.sol";
 import {AddressAliasHelper} from "../vendor/AddressAliasHelper.sol";
@@ -130,8 +128,7 @@ contract L1Portal is
     function finalizeWithdrawalTransaction(
         Types.CrossDomainMessage memory withdrawalTx,
         uint256 assertionID,
-        // bytes calldata encodedBlockHeader,
-        bytes[] calldata withdrawalAccountProof,
+        Types.VMState calldata vmState,
         bytes[] calldata withdrawalProof
     ) external override onlyProxy whenNotPaused {
         // Prevent nested withdrawals within withdrawals.
@@ -156,25 +153,17 @@ contract L1Portal is
         // Check that this withdrawal has not already been finalized, this is replay protection.
         require(finalizedWithdrawals[withdrawalHash] == false, "L1Portal: withdrawal has already been finalized");
 
-        // Avoid stack too deep
-        {
-            // (bytes32 blockHash, bytes32 stateRoot) = Encoding.decodeStateRootFromEncodedBlockHeader(encodedBlockHeader);
+        // Verify that the L2 state is the one committed to by the assertion's VM hash,
+        // which includes the L2Portal contract's storage root.
+        require(Hashing.hashVMStateV1(vmState) == assertion.stateHash, "L1Portal: invalid VM state");
 
-            // Verify that the block hash is the assertion's stateHash.
-            // require(blockHash == assertion.stateHash, "L1Portal: invalid block");
-
-            // Verify the account proof.
-            bytes32 storageRoot =
-                _verifyAccountInclusion(Predeploys.L2_PORTAL, assertion.stateHash, withdrawalAccountProof);
-
-            // Verify that the hash of this withdrawal was stored in the L2Portal contract on L2.
-            // If this is true, then we know that this withdrawal was actually triggered on L2
-            // and can therefore be relayed on L1.
-            require(
-                _verifyWithdrawalInclusion(withdrawalHash, storageRoot, withdrawalProof),
-                "L1Portal: invalid withdrawal inclusion proof"
-            );
-        }
+        // Verify that the hash of this withdrawal was stored in the L2Portal contract on L2.
+        // If this is true, then we know that this withdrawal was actually triggered on L2
+        // and can therefore be relayed on L1.
+        require(
+            _verifyWithdrawalInclusion(withdrawalHash, vmState.withdrawalStorageRoot, withdrawalProof),
+            "L1Portal: invalid withdrawal inclusion proof"
+        );
 
         // Mark the withdrawal as finalized so it can't be replayed.
         finalizedWithdrawals[withdrawalHash] = true;
@@ -264,25 +253,6 @@ contract L1Portal is
         return true;
     }
 
-    /**
-     * @notice Verifies a Merkle Trie inclusion proof that an account is present in
-     *         the world state and extract its storage root.
-     *
-     * @param account      Account address to verify.
-     * @param stateRoot    Root of the world state root of L2.
-     * @param proof        Inclusion proof of the account in the storage root.
-     */
-    function _verifyAccountInclusion(address account, bytes32 stateRoot, bytes[] memory proof)
-        internal
-        pure
-        returns (bytes32)
-    {
-        (bool exists, bytes memory encodedAccount) = SecureMerkleTrie.get(abi.encodePacked(account), proof, stateRoot);
-        require(exists, "L1Portal: invalid account proof");
-
-        return Encoding.decodeStorageRootFromEncodedAccount(encodedAccount);
-    }
-
     /**
      * @notice Verifies a Merkle Trie inclusion proof that a given withdrawal hash is present in
      *         the storage of the L2ToL1MessagePasser contract.
@@ -9,6 +9,11 @@ import {Encoding} from "./Encoding.sol";
  * @notice Hashing handles Specular's various different hashing schemes.
  */
 library Hashing {
+    /**
+     * @notice Version byte of VM hashes computed by `hashVMStateV1`.
+     */
+    uint8 internal constant VM_HASH_V1 = 0x01;
+
     /**
      * @notice Derives the withdrawal hash according to the encoding in the L2 Withdrawer contract
      *
@@ -19,4 +24,17 @@ library Hashing {
     function hashCrossDomainMessage(Types.CrossDomainMessage memory _tx) internal pure returns (bytes32) {
         return keccak256(abi.encode(_tx.nonce, _tx.sender, _tx.target, _tx.value, _tx.gasLimit, _tx.data));
     }
+
+    /**
+     * @notice Derives the V1 VM hash committing to an L2 state, as asserted by validators:
+     *         version || keccak256(stateRoot || blockHash || withdrawalStorageRoot)[1:]
+     *
+     * @param _state L2 state to hash.
+     *
+     * @return VM hash of the state.
+     */
+    function hashVMStateV1(Types.VMState memory _state) internal pure returns (bytes32) {
+        bytes32 hash = keccak256(abi.encodePacked(_state.stateRoot, _state.blockHash, _state.withdrawalStorageRoot));
+        return (hash & bytes32(type(uint256).max >> 8)) | bytes32(uint256(VM_HASH_V1) << 248);
+    }
 }
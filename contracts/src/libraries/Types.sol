@@ -18,4 +18,13 @@ library Types {
         uint256 gasLimit;
         bytes data;
     }
+
+    /**
+     * @notice Struct representing the L2 state committed to by an assertion's VM hash.
+     */
+    struct VMState {
+        bytes32 stateRoot;
+        bytes32 blockHash;
+        bytes32 withdrawalStorageRoot;
+    }
 }
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: MIT
+pragma solidity 0.8.15;
+
+import {Test} from "forge-std/Test.sol";
+
+import {Hashing} from "../../src/libraries/Hashing.sol";
+import {Types} from "../../src/libraries/Types.sol";
+
+contract HashingTest is Test {
+    // Vectors shared with the sidecar and genesis tool (see `vmhash_test.go`).
+    function test_hashVMStateV1_vectors() public {
+        assertEq(
+            Hashing.hashVMStateV1(
+                Types.VMState({
+                    stateRoot: bytes32(0x1111111111111111111111111111111111111111111111111111111111111111),
+                    blockHash: bytes32(0x2222222222222222222222222222222222222222222222222222222222222222),
+                    withdrawalStorageRoot: bytes32(0x3333333333333333333333333333333333333333333333333333333333333333)
+                })
+            ),
+            bytes32(0x01524791bda53e6da2158f10c15e3672835515d6135111d11c7e9880cfcbe573)
+        );
+        assertEq(
+            Hashing.hashVMStateV1(
+                Types.VMState({
+                    stateRoot: bytes32(0),
+                    blockHash: bytes32(0),
+                    withdrawalStorageRoot: bytes32(0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421)
+                })
+            ),
+            bytes32(0x016c2d8928d7347bf610c96837d30be488ca892200b00a97a7c86cf9ebfe591b)
+        );
+    }
+
+    function testFuzz_hashVMStateV1_versionByte(bytes32 stateRoot, bytes32 blockHash, bytes32 withdrawalStorageRoot)
+        public
+    {
+        bytes32 hash = Hashing.hashVMStateV1(Types.VMState(stateRoot, blockHash, withdrawalStorageRoot));
+        assertEq(uint8(hash[0]), Hashing.VM_HASH_V1);
+        assertEq(hash << 8, keccak256(abi.encodePacked(stateRoot, blockHash, withdrawalStorageRoot)) << 8);
+    }
+}
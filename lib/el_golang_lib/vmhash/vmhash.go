@@ -0,0 +1,93 @@
+// Package vmhash implements the VM hashes asserted by validators, i.e. the commitments to L2 state
+// checked on L1 (see `Hashing.hashVMStateV1` in the contracts).
+// It's shared by the sidecar (validator and prover) and the genesis tool, so they can't diverge.
+package vmhash
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type Version = uint8
+
+const (
+	// Legacy VM hashes are bare L2 block hashes, so their first byte isn't a version.
+	// Version 0 is reserved for them, so that no versioned VM hash has a version byte of 0.
+	Legacy Version = 0x00
+	// V1: version || keccak256(stateRoot || blockHash || withdrawalStorageRoot)[1:]
+	V1 Version = 0x01
+)
+
+// Default address of the `L2Portal` predeploy (see `Predeploys.sol`), whose storage holds initiated withdrawals.
+var DefaultL2PortalAddr = common.HexToAddress("0x2A00000000000000000000000000000000000011")
+
+// The L2 state committed to by a VM hash (see `Types.VMState` in the contracts).
+type State struct {
+	StateRoot             common.Hash
+	BlockHash             common.Hash
+	WithdrawalStorageRoot common.Hash // Storage root of the `L2Portal` contract.
+}
+
+// Returns the VM hash committing to `state` under the given version.
+func Compute(version Version, state State) (common.Hash, error) {
+	switch version {
+	case Legacy:
+		return state.BlockHash, nil
+	case V1:
+		hash := crypto.Keccak256Hash(state.StateRoot[:], state.BlockHash[:], state.WithdrawalStorageRoot[:])
+		hash[0] = version
+		return hash, nil
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported VM hash version: %d", version)
+	}
+}
+
+// Returns the version byte of a versioned VM hash.
+// Since legacy VM hashes have none, this is only meaningful for VM hashes known to be versioned (see `Activations`).
+func GetVersion(vmHash common.Hash) Version { return vmHash[0] }
+
+// Returns the storage root of the `L2Portal` contract at `l2PortalAddr` in `db`
+// (the empty root if it doesn't exist).
+func WithdrawalStorageRoot(db *state.StateDB, l2PortalAddr common.Address) (common.Hash, error) {
+	tr, err := db.StorageTrie(l2PortalAddr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if tr == nil {
+		return types.EmptyRootHash, nil
+	}
+	return tr.Hash(), nil
+}
+
+// L2 block numbers from which each VM hash version is asserted, keyed by version.
+// Legacy is asserted from genesis, unless another version is configured to activate then,
+// so existing chains keep their (legacy) genesis assertion.
+type Activations map[Version]uint64
+
+// Returns an error if any configured version is unsupported.
+func (a Activations) Validate() error {
+	for version := range a {
+		if version != Legacy && version != V1 {
+			return fmt.Errorf("unsupported VM hash version: %d", version)
+		}
+	}
+	return nil
+}
+
+// Returns the latest version activated at or before the given L2 block.
+func (a Activations) VersionAt(l2BlockNum uint64) Version {
+	var (
+		latest    = Legacy
+		latestNum uint64
+	)
+	for version, num := range a {
+		if num <= l2BlockNum && (num > latestNum || (num == latestNum && version > latest)) {
+			latest, latestNum = version, num
+		}
+	}
+	return latest
+}
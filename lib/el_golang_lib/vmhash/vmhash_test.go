@@ -0,0 +1,105 @@
+package vmhash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Vectors shared with `Hashing.t.sol` in the contracts.
+func TestCompute(t *testing.T) {
+	var tests = []struct {
+		version Version
+		state   State
+		want    common.Hash
+	}{
+		{
+			V1,
+			State{
+				common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111"),
+				common.HexToHash("0x2222222222222222222222222222222222222222222222222222222222222222"),
+				common.HexToHash("0x3333333333333333333333333333333333333333333333333333333333333333"),
+			},
+			common.HexToHash("0x01524791bda53e6da2158f10c15e3672835515d6135111d11c7e9880cfcbe573"),
+		},
+		{
+			V1,
+			State{WithdrawalStorageRoot: types.EmptyRootHash},
+			common.HexToHash("0x016c2d8928d7347bf610c96837d30be488ca892200b00a97a7c86cf9ebfe591b"),
+		},
+		{
+			Legacy,
+			State{StateRoot: common.Hash{1}, BlockHash: common.Hash{2}, WithdrawalStorageRoot: common.Hash{3}},
+			common.Hash{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("v%d,%s", tt.version, tt.want), func(t *testing.T) {
+			got, err := Compute(tt.version, tt.state)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, err := Compute(2, State{}); err == nil {
+		t.Error("computed VM hash of unsupported version")
+	}
+}
+
+func TestWithdrawalStorageRoot(t *testing.T) {
+	db, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	root, err := WithdrawalStorageRoot(db, DefaultL2PortalAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if root != types.EmptyRootHash {
+		t.Errorf("got %s for missing account, want the empty root", root)
+	}
+	db.SetState(DefaultL2PortalAddr, common.BigToHash(common.Big1), common.BigToHash(common.Big1))
+	root, err = WithdrawalStorageRoot(db, DefaultL2PortalAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Root of the single-leaf secure trie mapping slot 1 to 1.
+	if want := common.HexToHash("0xf38f9f63c760d088d7dd04f743619b6291f63beebd8bdf530628f90e9cfa52d7"); root != want {
+		t.Errorf("got %s, want %s", root, want)
+	}
+}
+
+func TestActivationsVersionAt(t *testing.T) {
+	var tests = []struct {
+		activations Activations
+		l2BlockNum  uint64
+		want        Version
+	}{
+		{nil, 0, Legacy},
+		{nil, 100, Legacy},
+		{Activations{V1: 0}, 0, V1},
+		{Activations{V1: 100}, 0, Legacy},
+		{Activations{V1: 100}, 99, Legacy},
+		{Activations{V1: 100}, 100, V1},
+		{Activations{Legacy: 0, V1: 100}, 101, V1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v,%d", tt.activations, tt.l2BlockNum), func(t *testing.T) {
+			if got := tt.activations.VersionAt(tt.l2BlockNum); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if err := (Activations{2: 0}).Validate(); err == nil {
+		t.Error("validated unsupported version")
+	}
+}
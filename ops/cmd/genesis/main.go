@@ -48,7 +48,7 @@ var Flags = []cli.Flag{
 	},
 	&cli.StringFlag{
 		Name:  "export-hash",
-		Usage: "Genesis hash output file",
+		Usage: "Genesis block and VM hash output file",
 	},
 	&cli.Uint64Flag{
 		Name:  "l1-block",
@@ -57,7 +57,8 @@ var Flags = []cli.Flag{
 }
 
 type exportedHash struct {
-	Hash common.Hash `json:"hash"`
+	Hash   common.Hash `json:"hash"`   // L2 genesis block hash.
+	VMHash common.Hash `json:"vmHash"` // VM hash of the genesis assertion.
 }
 
 func GenerateSpecularGenesis(ctx *cli.Context) error {
@@ -96,7 +97,11 @@ func GenerateSpecularGenesis(ctx *cli.Context) error {
 
 	if ctx.IsSet("export-hash") {
 		hash := l2Genesis.ToBlock().Hash()
-		if err := writeGenesisFile(ctx.String("export-hash"), exportedHash{hash}); err != nil {
+		vmHash, err := genesis.ComputeGenesisVMHash(l2Genesis)
+		if err != nil {
+			return err
+		}
+		if err := writeGenesisFile(ctx.String("export-hash"), exportedHash{hash, vmHash}); err != nil {
 			return err
 		}
 	}
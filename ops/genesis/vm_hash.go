@@ -0,0 +1,41 @@
+package genesis
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/lib/el_golang_lib/vmhash"
+	"github.com/specularL2/specular/ops/predeploys"
+)
+
+// Returns the VM hash committed to by the genesis assertion.
+// New chains assert V1 VM hashes from genesis on (see `vmhash.Activations`).
+func ComputeGenesisVMHash(genesis *core.Genesis) (common.Hash, error) {
+	block := genesis.ToBlock()
+	withdrawalStorageRoot, err := storageRoot(genesis, predeploys.L2PortalAddr)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to compute L2Portal storage root: %w", err)
+	}
+	state := vmhash.State{
+		StateRoot:             block.Root(),
+		BlockHash:             block.Hash(),
+		WithdrawalStorageRoot: withdrawalStorageRoot,
+	}
+	return vmhash.Compute(vmhash.V1, state)
+}
+
+// Returns the storage root of `addr` in the genesis state.
+func storageRoot(genesis *core.Genesis, addr common.Address) (common.Hash, error) {
+	db, err := gethstate.New(types.EmptyRootHash, gethstate.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for key, value := range genesis.Alloc[addr].Storage {
+		db.SetState(addr, key, value)
+	}
+	return vmhash.WithdrawalStorageRoot(db, addr)
+}
@@ -4,8 +4,11 @@ go 1.19
 
 replace github.com/ethereum/go-ethereum => ../services/el_clients/go-ethereum
 
+replace github.com/specularL2/specular/lib/el_golang_lib => ../lib/el_golang_lib
+
 require (
 	github.com/ethereum/go-ethereum v1.10.26
+	github.com/specularL2/specular/lib/el_golang_lib v0.0.0
 	github.com/urfave/cli/v2 v2.25.7
 )
 
@@ -41,6 +41,7 @@ if [ "$VALIDATOR" = true ] ; then
     FLAGS+=(
         "--validator"
         "--validator.private-key $VALIDATOR_PRIV_KEY"
+        "--validator.check-derivation"
     )
 fi
 
@@ -13,13 +13,16 @@ import (
 	bind "github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 
 	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
 	"github.com/specularL2/specular/services/sidecar/rollup/services"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/admin"
 	"github.com/specularL2/specular/services/sidecar/rollup/services/disseminator"
 	"github.com/specularL2/specular/services/sidecar/rollup/services/validator"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
@@ -38,6 +41,13 @@ func main() {
 		Name:   "sidecar",
 		Usage:  "launch a validator and/or disseminator",
 		Action: startServices,
+		Commands: []*cli.Command{
+			{
+				Name:   "exit",
+				Usage:  "remove the validator's stake and withdraw its funds (e.g. before rotating keys)",
+				Action: exitValidator,
+			},
+		},
 	}
 	app.Flags = services.CLIFlags()
 	if err := app.Run(os.Args); err != nil {
@@ -48,10 +58,7 @@ func main() {
 
 // Starts the CLI-specified services (blocking).
 func startServices(cliCtx *cli.Context) error {
-	// Configure logger.
-	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
-	glogger.Verbosity(log.Lvl(cliCtx.Int(services.VerbosityFlag.Name)))
-	log.Root().SetHandler(glogger)
+	configureLogger(cliCtx)
 	log.Info("Parsing configuration")
 	cfg, err := services.ParseSystemConfig(cliCtx)
 	if err != nil {
@@ -70,7 +77,12 @@ func startServices(cliCtx *cli.Context) error {
 	}
 	if cfg.Disseminator().GetIsEnabled() {
 		log.Info("Starting disseminator...")
-		disseminator, err = createDisseminator(context.Background(), cfg, l1State)
+		db, err := openDisseminatorDB(cfg.Disseminator())
+		if err != nil {
+			return fmt.Errorf("failed to open disseminator database: %w", err)
+		}
+		defer db.Close()
+		disseminator, err = createDisseminator(context.Background(), cfg, l1State, db)
 		if err != nil {
 			return fmt.Errorf("failed to create disseminator: %w", err)
 		}
@@ -80,13 +92,46 @@ func startServices(cliCtx *cli.Context) error {
 	}
 	if cfg.Validator().GetIsEnabled() {
 		log.Info("Starting validator...")
-		validator, err = createValidator(context.Background(), cfg, l1State)
+		l1BridgeClient, err := createBridgeClient(context.Background(), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create l1 bridge client: %w", err)
+		}
+		rollupWatcher, err := bridge.NewRollupWatcher(l1BridgeClient, l1State, cfg.Protocol())
+		if err != nil {
+			return fmt.Errorf("failed to create rollup watcher: %w", err)
+		}
+		validator, err = createValidator(context.Background(), cfg, l1State, l1BridgeClient, rollupWatcher)
 		if err != nil {
 			return fmt.Errorf("failed to create validator: %w", err)
 		}
+		// Start the validator first, so it's subscribed to the watcher's events.
 		if err := validator.Start(ctx, eg); err != nil {
 			return fmt.Errorf("failed to start validator: %w", err)
 		}
+		if err := rollupWatcher.Start(ctx, eg); err != nil {
+			return fmt.Errorf("failed to start rollup watcher: %w", err)
+		}
+	}
+	if cfg.Admin().GetRPCAddr() != "" {
+		log.Info("Starting admin RPC server...")
+		// Avoid wrapping nil pointers of disabled services in non-nil interfaces.
+		var (
+			adminDisseminator admin.Disseminator
+			adminValidator    admin.Validator
+		)
+		if disseminator != nil {
+			adminDisseminator = disseminator
+		}
+		if validator != nil {
+			adminValidator = validator
+		}
+		server, err := admin.NewServer(cfg.Admin(), l1State, adminDisseminator, adminValidator)
+		if err != nil {
+			return fmt.Errorf("failed to create admin RPC server: %w", err)
+		}
+		if err := server.Start(ctx, eg); err != nil {
+			return fmt.Errorf("failed to start admin RPC server: %w", err)
+		}
 	}
 	log.Info("Services running.")
 	if err := eg.Wait(); err != nil {
@@ -96,42 +141,175 @@ func startServices(cliCtx *cli.Context) error {
 	return nil
 }
 
+// Removes the configured validator's stake and withdraws its funds.
+func exitValidator(cliCtx *cli.Context) error {
+	configureLogger(cliCtx)
+	cfg, err := services.ParseSystemConfig(cliCtx)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if !cfg.Validator().GetIsEnabled() || cfg.Validator().GetMode() == validator.WatchMode {
+		return fmt.Errorf("exit requires a staking validator to be configured")
+	}
+	ctx := context.Background()
+	l1BridgeClient, err := createBridgeClient(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create l1 bridge client: %w", err)
+	}
+	l1TxMgr, err := createTxManager(ctx, "validator", cfg.L1().Endpoint, cfg.Protocol(), cfg.Validator())
+	if err != nil {
+		return fmt.Errorf("failed to initialize l1 tx manager: %w", err)
+	}
+	stakeMgr := validator.NewStakeManager(cfg.Validator().GetAccountAddr(), l1TxMgr, l1BridgeClient)
+	if err := stakeMgr.Exit(ctx); err != nil {
+		return fmt.Errorf("failed to exit: %w", err)
+	}
+	log.Info("Validator exited.", "addr", cfg.Validator().GetAccountAddr())
+	return nil
+}
+
+func configureLogger(cliCtx *cli.Context) {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(cliCtx.Int(services.VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+}
+
 func createDisseminator(
 	ctx context.Context,
 	cfg *services.SystemConfig,
 	l1State *eth.EthState,
+	db ethdb.KeyValueStore,
 ) (*disseminator.BatchDisseminator, error) {
-	l1TxMgr, err := createTxManager(ctx, "disseminator", cfg.L1().Endpoint, cfg.Protocol(), cfg.Disseminator())
+	// Dry runs send no txs, so need no key.
+	var l1TxMgr disseminator.TxManager
+	if !cfg.Disseminator().GetDryRun() {
+		txMgr, err := createTxManager(ctx, "disseminator", cfg.L1().Endpoint, cfg.Protocol(), cfg.Disseminator())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize l1 tx manager: %w", err)
+		}
+		l1TxMgr = txMgr
+	}
+	registry, err := derivation.NewBatchVersionRegistry(cfg.Protocol().GetBatchVersionActivations())
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize l1 tx manager: %w", err)
+		return nil, fmt.Errorf("failed to initialize batch version registry: %w", err)
 	}
-	var (
-		encoder      = derivation.NewBatchV0Encoder(cfg)
-		batchBuilder = derivation.NewBatchBuilder(cfg, encoder)
-		l2Client     = eth.NewLazilyDialedEthClient(cfg.L2().GetEndpoint())
+	batchBuilder, err := derivation.NewBatchBuilder(cfg, registry, derivation.NewBatchBuilderStore(db))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize batch builder: %w", err)
+	}
+	l1Client, err := eth.DialWithRetry(ctx, cfg.L1().GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize l1 client: %w", err)
+	}
+	da, err := derivation.NewDAProvider(cfg.Disseminator())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize DA provider: %w", err)
+	}
+	inboxScanner, err := derivation.NewInboxScanner(
+		cfg, l1Client, createBlobFetcher(cfg), registry, da, cfg.Disseminator().GetAccountAddr(),
 	)
-	return disseminator.NewBatchDisseminator(cfg.Disseminator(), batchBuilder, l1TxMgr, l1State, l2Client), nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inbox scanner: %w", err)
+	}
+	l2Client := eth.NewLazilyDialedEthClient(cfg.L2().GetEndpoint())
+	var l2HeadSub disseminator.L2HeadSubscriber
+	if cfg.L2().GetWSEndpoint() != "" {
+		l2HeadSub = eth.NewLazilyDialedEthClient(cfg.L2().GetWSEndpoint())
+	}
+	return disseminator.NewBatchDisseminator(
+		cfg.Disseminator(), batchBuilder, l1TxMgr, l1State, l1Client, l2Client, l2HeadSub, inboxScanner, da, db,
+	), nil
+}
+
+// Opens the database in which disseminator state is persisted (in-memory if no datadir is configured).
+// Dry runs must not affect persisted state, so always use an in-memory database.
+func openDisseminatorDB(cfg services.DisseminatorConfig) (ethdb.Database, error) {
+	if cfg.GetDryRun() {
+		log.Info("Dry run; disseminator state is kept in memory only")
+		return rawdb.NewMemoryDatabase(), nil
+	}
+	if cfg.GetDataDir() == "" {
+		log.Warn("No disseminator datadir configured; batch builder state will not survive restarts")
+		return rawdb.NewMemoryDatabase(), nil
+	}
+	return rawdb.Open(rawdb.OpenOptions{
+		Type:      cfg.GetDBEngine(),
+		Directory: cfg.GetDataDir(),
+		Namespace: "sidecar/disseminator/",
+		Cache:     16,
+		Handles:   16,
+	})
 }
 
 func createValidator(
 	ctx context.Context,
 	cfg *services.SystemConfig,
 	l1State *eth.EthState,
+	l1BridgeClient *bridge.BridgeClient,
+	rollupWatcher *bridge.RollupWatcher,
 ) (*validator.Validator, error) {
-	l1TxMgr, err := createTxManager(ctx, "validator", cfg.L1().Endpoint, cfg.Protocol(), cfg.Validator())
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize l1 tx manager: %w", err)
+	// Watch-only validators send no txs, so need no key.
+	var l1TxMgr validator.TxManager
+	if cfg.Validator().GetMode() != validator.WatchMode {
+		txMgr, err := createTxManager(ctx, "validator", cfg.L1().Endpoint, cfg.Protocol(), cfg.Validator())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize l1 tx manager: %w", err)
+		}
+		l1TxMgr = txMgr
 	}
 	l1Client, err := eth.DialWithRetry(ctx, cfg.L1().GetEndpoint())
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize l1 client: %w", err)
 	}
-	l1BridgeClient, err := bridge.NewBridgeClient(l1Client, cfg.Protocol())
+	l2Client := eth.NewLazilyDialedEthClient(cfg.L2().GetEndpoint())
+	var derivationChecker *validator.DerivationChecker
+	if cfg.Validator().GetCheckDerivation() {
+		derivationChecker, err = createDerivationChecker(cfg, l1Client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize derivation checker: %w", err)
+		}
+	}
+	return validator.NewValidator(
+		cfg.Validator(), cfg.Protocol(), l1TxMgr, l1BridgeClient, l1State, l1Client, l2Client, rollupWatcher.Broker,
+		derivationChecker,
+	), nil
+}
+
+// Creates a checker that derives the L2 chain from rollup genesis on.
+func createDerivationChecker(cfg *services.SystemConfig, l1Client derivation.L1Client) (*validator.DerivationChecker, error) {
+	registry, err := derivation.NewBatchVersionRegistry(cfg.Protocol().GetBatchVersionActivations())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize batch version registry: %w", err)
+	}
+	// Batches are resolved from the same DA provider they're disseminated to.
+	da, err := derivation.NewDAProvider(cfg.Disseminator())
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize l1 bridge client: %w", err)
+		return nil, fmt.Errorf("failed to initialize DA provider: %w", err)
 	}
-	l2Client := eth.NewLazilyDialedEthClient(cfg.L2().GetEndpoint())
-	return validator.NewValidator(cfg.Validator(), l1TxMgr, l1BridgeClient, l1State, l2Client), nil
+	genesis := cfg.Protocol().GetRollup().Genesis
+	pipeline, err := derivation.NewDerivationPipeline(
+		cfg.Protocol(), registry, l1Client, createBlobFetcher(cfg), da, genesis.L2.GetNumber(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize derivation pipeline: %w", err)
+	}
+	return validator.NewDerivationChecker(pipeline, genesis.L1.GetNumber()), nil
+}
+
+// Returns a fetcher for blobs from the L1 beacon node, or nil if no beacon endpoint is configured.
+func createBlobFetcher(cfg *services.SystemConfig) derivation.BlobFetcher {
+	if cfg.L1().GetBeaconEndpoint() == "" {
+		return nil
+	}
+	return derivation.NewBeaconBlobFetcher(cfg.L1().GetBeaconEndpoint())
+}
+
+func createBridgeClient(ctx context.Context, cfg *services.SystemConfig) (*bridge.BridgeClient, error) {
+	l1Client, err := eth.DialWithRetry(ctx, cfg.L1().GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize l1 client: %w", err)
+	}
+	return bridge.NewBridgeClient(l1Client, cfg.Protocol())
 }
 
 func createTxManager(
@@ -4,9 +4,14 @@ go 1.19
 
 replace github.com/ethereum/go-ethereum => ../el_clients/go-ethereum
 
+replace github.com/specularL2/specular/lib/el_golang_lib => ../../lib/el_golang_lib
+
 require (
 	github.com/avast/retry-go/v4 v4.3.3
 	github.com/ethereum/go-ethereum v1.12.2
+	github.com/golang-jwt/jwt/v4 v4.3.0
+	github.com/holiman/uint256 v1.2.3
+	github.com/specularL2/specular/lib/el_golang_lib v0.0.0
 	github.com/urfave/cli/v2 v2.25.7
 	golang.org/x/sync v0.3.0
 )
@@ -41,7 +46,6 @@ require (
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/gofrs/flock v0.8.1 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
-	github.com/golang-jwt/jwt/v4 v4.3.0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/golang/snappy v0.0.5-0.20220116011046-fa5810519dcb // indirect
 	github.com/google/uuid v1.3.0 // indirect
@@ -50,7 +54,6 @@ require (
 	github.com/hashicorp/go-bexpr v0.1.10 // indirect
 	github.com/holiman/billy v0.0.0-20230718173358-1c7e68d277a7 // indirect
 	github.com/holiman/bloomfilter/v2 v2.0.3 // indirect
-	github.com/holiman/uint256 v1.2.3 // indirect
 	github.com/huin/goupnp v1.3.0 // indirect
 	github.com/influxdata/influxdb-client-go/v2 v2.4.0 // indirect
 	github.com/influxdata/influxdb1-client v0.0.0-20220302092344-a9ab5670611c // indirect
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 )
 
 // Backend interface provides the common API services (that are provided by
@@ -91,6 +92,66 @@ func (api *ProverAPI) ProveTransaction(ctx context.Context, hash common.Hash, ta
 	return hexutil.Bytes{}, nil
 }
 
+// Returns the hashes of the execution states across blocks [startNum, endNum) (see `GenerateStates`).
+func (api *ProverAPI) GenerateStateHashes(
+	ctx context.Context,
+	startNum hexutil.Uint64,
+	endNum hexutil.Uint64,
+	config *ProverConfig,
+) ([]common.Hash, error) {
+	states, err := GenerateStates(api.backend, ctx, uint64(startNum), uint64(endNum), config)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]common.Hash, len(states))
+	for i, state := range states {
+		hashes[i] = state.Hash()
+	}
+	return hashes, nil
+}
+
+// OneStepProofResult is a one-step proof along with the context needed to verify it on L1.
+type OneStepProofResult struct {
+	Proof          hexutil.Bytes  `json:"proof"`
+	EncodedTx      hexutil.Bytes  `json:"encodedTx"`
+	Coinbase       common.Address `json:"coinbase"`
+	BlockNumber    hexutil.Uint64 `json:"blockNumber"`
+	BlockTimestamp hexutil.Uint64 `json:"blockTimestamp"`
+}
+
+// Generates a one-step proof from the `step`-th execution state across blocks [startNum, endNum).
+func (api *ProverAPI) GenerateOneStepProof(
+	ctx context.Context,
+	startNum hexutil.Uint64,
+	endNum hexutil.Uint64,
+	step hexutil.Uint64,
+	config *ProverConfig,
+) (*OneStepProofResult, error) {
+	states, err := GenerateStates(api.backend, ctx, uint64(startNum), uint64(endNum), config)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(step) >= uint64(len(states)-1) {
+		return nil, fmt.Errorf("step %d out of range (%d states)", step, len(states))
+	}
+	state := states[step]
+	osp, err := GenerateProof(api.backend, ctx, state, config)
+	if err != nil {
+		return nil, err
+	}
+	encodedTx, err := state.Block.Transactions()[state.TransactionIdx].MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &OneStepProofResult{
+		Proof:          osp.Encode(),
+		EncodedTx:      encodedTx,
+		Coinbase:       state.Block.Coinbase(),
+		BlockNumber:    hexutil.Uint64(state.Block.NumberU64()),
+		BlockTimestamp: hexutil.Uint64(state.Block.Time()),
+	}, nil
+}
+
 // APIs return the collection of RPC services the tracer package offers.
 func APIs(backend Backend) []rpc.API {
 	// Append all the local APIs and return
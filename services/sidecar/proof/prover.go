@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/specularL2/specular/lib/el_golang_lib/vmhash"
 	"github.com/specularL2/specular/services/sidecar/proof/proof"
 	"github.com/specularL2/specular/services/sidecar/proof/prover"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
@@ -38,6 +39,10 @@ const (
 
 type ProverConfig struct {
 	Reexec *uint64
+	// Version of the VM hash committed to by the final execution state (legacy if unset).
+	VMHashVersion *vmhash.Version
+	// Address of the L2Portal contract, whose storage root versioned VM hashes commit to (the predeploy if unset).
+	L2PortalAddr *common.Address
 }
 
 type ExecutionState struct {
@@ -146,8 +151,13 @@ func GenerateStates(
 			}
 		}
 	}
+	// The final state is committed to as asserted, i.e. by the VM hash of the last block.
+	vmHash, err := computeVMHash(backend, ctx, block, reexec, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute VM hash of block #%d: %w", block.NumberU64(), err)
+	}
 	states = append(states, &ExecutionState{
-		VMHash:         block.Root(),
+		VMHash:         vmHash,
 		Block:          block,
 		TransactionIdx: uint64(len(block.Transactions())),
 		StepIdx:        0,
@@ -155,6 +165,37 @@ func GenerateStates(
 	return states, nil
 }
 
+// Returns the VM hash of the state after `block`, under the version configured in `config`.
+func computeVMHash(
+	backend Backend,
+	ctx context.Context,
+	block *types.Block,
+	reexec uint64,
+	config *ProverConfig,
+) (common.Hash, error) {
+	var (
+		version      = vmhash.Legacy
+		l2PortalAddr = vmhash.DefaultL2PortalAddr
+		state        = vmhash.State{StateRoot: block.Root(), BlockHash: block.Hash()}
+	)
+	if config != nil && config.VMHashVersion != nil {
+		version = *config.VMHashVersion
+	}
+	if config != nil && config.L2PortalAddr != nil {
+		l2PortalAddr = *config.L2PortalAddr
+	}
+	if version != vmhash.Legacy {
+		statedb, _, err := backend.StateAtBlock(ctx, block, reexec, nil, true, false)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if state.WithdrawalStorageRoot, err = vmhash.WithdrawalStorageRoot(statedb, l2PortalAddr); err != nil {
+			return common.Hash{}, fmt.Errorf("failed to get withdrawal storage root: %w", err)
+		}
+	}
+	return vmhash.Compute(version, state)
+}
+
 func GenerateProof(backend Backend, ctx context.Context, startState *ExecutionState, config *ProverConfig) (*proof.OneStepProof, error) {
 	if startState.Block == nil {
 		return nil, fmt.Errorf("bad start state")
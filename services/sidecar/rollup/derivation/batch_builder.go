@@ -3,6 +3,7 @@ package derivation
 import (
 	"errors"
 	"io"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
@@ -20,16 +21,25 @@ type Config interface {
 	GetL1OracleAddr() common.Address
 	GetSeqWindowSize() uint64
 	GetSubSafetyMargin() uint64
+	GetTargetBatchSize() uint64
+	GetTargetBatchGas() uint64
+	// Max batch version to encode with; the latest version active at the L1 head (up to this) is used.
+	GetBatchVersion() BatchEncoderVersion
+	// Max age of the oldest block in a batch before it's flushed, regardless of size (0 disables).
+	GetMaxBatchAge() time.Duration
+	// Max # of blocks in a batch before it's flushed, regardless of size (0 disables).
+	GetMaxBatchBlocks() uint64
 }
 
 type VersionedDataEncoder interface {
 	// Returns an encoded batch if one is ready (or if forced).
 	// If not forced, an error is returned if one cannot yet be built.
 	GetBatch(force bool) ([]byte, error)
-	// Processes a block, adding its data to the current batch.
+	// Processes a block (with the given L1 origin), adding its data to the current batch.
+	// The L1 origin is empty if not yet known (i.e. no oracle tx seen since start-up).
 	// Returns an `errBatchFull` if the block would cause the batch to exceed the target size.
 	// Returns an error if the block could not be processed.
-	ProcessBlock(block *ethTypes.Block, isNewEpoch bool) error
+	ProcessBlock(block *ethTypes.Block, l1Origin types.BlockID, isNewEpoch bool) error
 	// Resets the encoder, discarding all buffered data.
 	Reset()
 }
@@ -40,20 +50,104 @@ func (e InvalidBlockError) Error() string { return e.Msg }
 
 type batchBuilder struct {
 	cfg           Config
-	encoder       VersionedDataEncoder
+	registry      *BatchVersionRegistry
+	policy        SizingPolicy
+	store         *BatchBuilderStore
+	encoder       VersionedDataEncoder // nil until a batch is started
 	pendingBlocks []*ethTypes.Block
 	lastEnqueued  types.BlockID
-	lastBuilt     []byte
+	built         []*builtBatch // built batches not yet advanced past, in order
+	firstPending  uint64        // number of the first block not yet handed off via `Advance`
+	lastProcessed uint64        // number of the last block processed into the current batch
+	numProcessed  uint64        // # of blocks processed into the current batch
+	firstSeen     time.Time     // local time at which the first block was processed into the current batch
+	now           func() time.Time
 
-	timeout uint64
+	l1Origin types.BlockID // last L1 epoch seen
+	timeout  uint64
+	flush    bool // whether to force-build the current batch, regardless of its size
 }
 
-func NewBatchBuilder(cfg Config, encoder VersionedDataEncoder) *batchBuilder {
-	return &batchBuilder{cfg, encoder, nil, types.BlockID{}, nil, 0}
+type builtBatch struct {
+	data         []byte
+	lastBlockNum uint64        // number of the last block in the batch
+	deadline     uint64        // L1 block # by which the batch must be posted (0 if unknown)
+	l1Origin     types.BlockID // last L1 epoch seen when the batch was built
+}
+
+// Creates a batch builder, resuming from the state persisted in `store` (if any).
+// If a batch was in progress, it's resumed with the version it was started with, by re-processing its blocks
+// (i.e. those enqueued but not yet included in a built batch) into the encoder.
+func NewBatchBuilder(cfg Config, registry *BatchVersionRegistry, store *BatchBuilderStore) (*batchBuilder, error) {
+	state, err := store.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load batch builder state: %w", err)
+	}
+	if state.lastEnqueued != types.EmptyBlockID {
+		log.Info(
+			"Resuming batch builder",
+			"last_enqueued", state.lastEnqueued, "#unbatched", len(state.unbatchedBlocks), "has_built", state.lastBuilt != nil,
+		)
+	}
+	firstPending := state.lastEnqueued.GetNumber() + 1
+	if len(state.unbatchedBlocks) > 0 {
+		firstPending = state.unbatchedBlocks[0].NumberU64()
+	}
+	// Only the first built batch is persisted; the blocks of any later ones are re-encoded.
+	var built []*builtBatch
+	if state.lastBuilt != nil {
+		decoded, err := DecodeBatch(state.lastBuilt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode persisted batch: %w", err)
+		}
+		batch := &builtBatch{data: state.lastBuilt, deadline: state.deadline, l1Origin: state.l1Origin}
+		if first, last, ok := l2BlockRange(decoded); ok {
+			firstPending, batch.lastBlockNum = first, last
+		}
+		built = append(built, batch)
+	}
+	b := &batchBuilder{
+		cfg:           cfg,
+		registry:      registry,
+		policy:        NewSizingPolicy(cfg),
+		store:         store,
+		pendingBlocks: state.unbatchedBlocks,
+		lastEnqueued:  state.lastEnqueued,
+		built:         built,
+		firstPending:  firstPending,
+		l1Origin:      state.l1Origin,
+		now:           time.Now,
+	}
+	if state.batchStarted {
+		if err := b.resumeBatch(state.batchVersion); err != nil {
+			return nil, fmt.Errorf("failed to resume batch: %w", err)
+		}
+	}
+	return b, nil
 }
 
 func (b *batchBuilder) LastEnqueued() types.BlockID { return b.lastEnqueued }
 
+// Returns the number of the first block not yet handed off via `Advance` (i.e. in a built batch, or pending).
+func (b *batchBuilder) FirstPending() uint64 { return b.firstPending }
+
+// Returns the L1 block number by which the most recently built batch (not yet advanced past) must be posted,
+// per the sequencing window (less the safety margin).
+// Returns false if it's unknown (e.g. no L1 epoch was seen when it was built), or if there's no such batch.
+func (b *batchBuilder) Deadline() (uint64, bool) {
+	if len(b.built) == 0 {
+		return 0, false
+	}
+	deadline := b.built[len(b.built)-1].deadline
+	return deadline, deadline != 0
+}
+
+// Returns the L1 block number at which the current batch is force-built (0 if not yet set).
+func (b *batchBuilder) Timeout() uint64 { return b.timeout }
+
+// Forces the current batch to be built on the next call to `Build`, regardless of its size.
+func (b *batchBuilder) Flush() { b.flush = true }
+
 // Enqueues a block, to be processed and batched.
 // Returns a `InvalidBlockError` if the block is not a child of the last enqueued block.
 func (b *batchBuilder) Enqueue(block *ethTypes.Block) error {
@@ -61,55 +155,158 @@ func (b *batchBuilder) Enqueue(block *ethTypes.Block) error {
 	if (b.lastEnqueued.GetHash() != common.Hash{}) && (block.ParentHash() != b.lastEnqueued.GetHash()) {
 		return InvalidBlockError{Msg: "Appended block is not a child of the last appended block"}
 	}
+	if err := b.store.writeEnqueued(block); err != nil {
+		return fmt.Errorf("failed to persist block: %w", err)
+	}
 	b.pendingBlocks = append(b.pendingBlocks, block)
 	b.lastEnqueued = types.NewBlockID(block.NumberU64(), block.Hash())
 	return nil
 }
 
 // Resets the builder, discarding all pending blocks.
-func (b *batchBuilder) Reset(lastEnqueued types.BlockID) {
-	b.encoder.Reset()
+func (b *batchBuilder) Reset(lastEnqueued types.BlockID) error {
+	if err := b.store.reset(lastEnqueued); err != nil {
+		return fmt.Errorf("failed to reset persisted state: %w", err)
+	}
+	b.encoder = nil
+	b.timeout = 0
+	b.numProcessed = 0
 	b.pendingBlocks = []*ethTypes.Block{}
 	b.lastEnqueued = lastEnqueued
-	b.lastBuilt = nil
+	b.built = nil
+	b.flush = false
+	b.firstPending = lastEnqueued.GetNumber() + 1
+	b.l1Origin = types.EmptyBlockID
+	return nil
 }
 
 // This short-circuits the build process if a batch is
 // already built and `Advance` hasn't been called.
 // An l1Head must be provided to allow the encoder to determine if the batch is ready.
 func (b *batchBuilder) Build(l1Head types.BlockID) ([]byte, error) {
-	if b.lastBuilt != nil {
-		return b.lastBuilt, nil
+	return b.BuildAhead(l1Head, 0)
+}
+
+// Returns the batch following the first `n` built batches not yet advanced past, building it if necessary.
+// This allows building further batches before earlier ones are posted (i.e. advanced past).
+// Returns an `io.EOF` error if the batch can't be built yet.
+func (b *batchBuilder) BuildAhead(l1Head types.BlockID, n int) ([]byte, error) {
+	if n < len(b.built) {
+		return b.built[n].data, nil
+	}
+	if n > len(b.built) {
+		return nil, fmt.Errorf("cannot build ahead of unbuilt batches (n=%d, #built=%d)", n, len(b.built))
 	}
-	if err := b.encodePending(); err != nil {
+	if b.encoder == nil {
+		if err := b.startBatch(l1Head); err != nil {
+			return nil, fmt.Errorf("failed to start new batch: %w", err)
+		}
+	}
+	// No pending blocks is fine, the current batch may still need to be flushed.
+	if err := b.encodePending(); err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("failed to encode pending blocks into a new batch: %w", err)
 	}
 	return b.getBatch(l1Head)
 }
 
-// Advances the builder, clearing the last built batch.
+// Advances the builder past the first built batch (i.e. once it's posted).
 func (b *batchBuilder) Advance() {
-	b.lastBuilt = nil
+	if len(b.built) == 0 {
+		return
+	}
+	b.firstPending = b.built[0].lastBlockNum + 1
+	b.built = b.built[1:]
+	// Failing to persist this only results in the batch being re-sent after a restart.
+	var err error
+	if len(b.built) == 0 {
+		err = b.store.deleteLastBuilt()
+	} else {
+		next := b.built[0]
+		err = b.store.writeBuilt(next.data, next.lastBlockNum, next.deadline, next.l1Origin)
+	}
+	if err != nil {
+		log.Error("Failed to persist advanced batch", "err", err)
+	}
 }
 
 // Tries to get the current batch.
 func (b *batchBuilder) getBatch(l1Head types.BlockID) ([]byte, error) {
-	// Force-build batch if necessary (timeout exceeded).
-	force := b.timeout != 0 && l1Head.GetNumber() >= b.timeout
+	// Force-build batch if necessary (flushed, timeout or max age exceeded).
+	force := b.flush || (b.timeout != 0 && l1Head.GetNumber() >= b.timeout) || b.exceedsMaxAge()
 	batch, err := b.encoder.GetBatch(force)
 	if err != nil {
 		if errors.Is(err, errBatchTooSmall) {
+			// Nothing to flush.
+			b.flush = false
 			log.Warn("Batch too small, waiting for more blocks")
 			return nil, io.EOF
 		}
 		return nil, fmt.Errorf("failed to get batch: %w", err)
 	}
-	// Cache last built batch.
-	b.lastBuilt = batch
+	// Cache built batch and start afresh.
+	built := &builtBatch{data: batch, lastBlockNum: b.lastProcessed, deadline: b.timeout, l1Origin: b.l1Origin}
+	b.built = append(b.built, built)
+	b.flush = false
+	b.encoder = nil
+	b.timeout = 0
+	b.numProcessed = 0
+	// Failing to persist this only results in the batch being re-encoded after a restart.
+	// Later batches are persisted once they're first (see `Advance`); until then, their blocks are kept.
+	if len(b.built) == 1 {
+		if err := b.store.writeBuilt(batch, built.lastBlockNum, built.deadline, built.l1Origin); err != nil {
+			log.Error("Failed to persist built batch", "err", err)
+		}
+	}
+	if err := b.store.deleteBatchStarted(); err != nil {
+		log.Error("Failed to delete persisted batch version", "err", err)
+	}
 	return batch, nil
 }
 
-// Encodes pending blocks into a new batch, constrained by `maxBatchSize`.
+// Starts a new batch, encoded with the latest version active at the L1 head.
+func (b *batchBuilder) startBatch(l1Head types.BlockID) error {
+	version, err := b.registry.LatestActiveVersion(b.cfg.GetBatchVersion(), l1Head.GetNumber())
+	if err != nil {
+		return err
+	}
+	log.Info("Starting new batch", "version", version)
+	if err := b.newEncoder(version); err != nil {
+		return err
+	}
+	// Failing to persist this only results in the batch being restarted with the latest version after a restart.
+	if err := b.store.writeBatchStarted(version); err != nil {
+		log.Error("Failed to persist batch version", "err", err)
+	}
+	return nil
+}
+
+// Resumes the batch in progress before a restart, re-processing its blocks into an encoder of the given version.
+func (b *batchBuilder) resumeBatch(version BatchEncoderVersion) error {
+	log.Info("Resuming batch", "version", version, "#blocks", len(b.pendingBlocks))
+	if err := b.newEncoder(version); err != nil {
+		return err
+	}
+	if err := b.encodePending(); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to re-encode blocks: %w", err)
+	}
+	return nil
+}
+
+// Sets a new encoder of the given version for the current batch.
+func (b *batchBuilder) newEncoder(version BatchEncoderVersion) error {
+	encoder, err := b.registry.NewEncoder(version, b.policy)
+	if err != nil {
+		return err
+	}
+	b.encoder = encoder
+	// Blocks without an oracle tx belong to the last seen epoch.
+	if b.l1Origin != types.EmptyBlockID {
+		b.updateTimeout(b.l1Origin.GetNumber())
+	}
+	return nil
+}
+
+// Encodes pending blocks into a new batch, constrained by `maxBatchSize` and the max # of blocks per batch.
 // Returns an `io.EOF` error if there are no pending blocks.
 func (b *batchBuilder) encodePending() error {
 	if len(b.pendingBlocks) == 0 {
@@ -118,6 +315,10 @@ func (b *batchBuilder) encodePending() error {
 	// Process all pending blocks (until the batch is full).
 	numProcessed := 0
 	for _, block := range b.pendingBlocks {
+		if maxBlocks := b.cfg.GetMaxBatchBlocks(); maxBlocks != 0 && b.numProcessed >= maxBlocks {
+			log.Info("Batch has max # of blocks, stopping processing")
+			break
+		}
 		if err := b.processBlock(block); err != nil {
 			if errors.Is(err, errBatchFull) {
 				log.Info("Batch is full, stopping processing")
@@ -126,6 +327,11 @@ func (b *batchBuilder) encodePending() error {
 			return fmt.Errorf("failed to process block: %w", err)
 		}
 		numProcessed += 1
+		if b.numProcessed == 0 {
+			b.firstSeen = b.now()
+		}
+		b.numProcessed += 1
+		b.lastProcessed = block.NumberU64()
 	}
 	log.Info("Encoded l2 blocks", "num_processed", numProcessed)
 	// Advance queue.
@@ -135,26 +341,45 @@ func (b *batchBuilder) encodePending() error {
 }
 
 // Processes a block, adding its data to the current batch.
-func (b *batchBuilder) processBlock(block *ethTypes.Block) (err error) {
-	var epoch uint64
-	// Process oracle tx, if it exists (to update timeout).
+func (b *batchBuilder) processBlock(block *ethTypes.Block) error {
+	var isNewEpoch bool
+	// Process oracle tx, if it exists (to update timeout and L1 origin).
 	if block.Transactions().Len() > 0 {
 		var firstTx = block.Transactions()[0]
 		if *firstTx.To() == b.cfg.GetL1OracleAddr() {
-			epoch, _, _, _, _, err = bridge.UnpackL1OracleInput(firstTx)
+			epoch, _, _, hash, _, err := bridge.UnpackL1OracleInput(firstTx)
 			if err != nil {
 				return fmt.Errorf("could not unpack oracle tx: %w", err)
 			}
+			isNewEpoch = true
+			b.l1Origin = types.NewBlockID(epoch, hash)
 			b.updateTimeout(epoch)
 		} else {
 			log.Trace("No oracle tx in block", "block#", block.NumberU64())
 		}
 	}
 	// Process block.
-	if err := b.encoder.ProcessBlock(block, epoch != 0); err != nil {
-		return err
+	return b.encoder.ProcessBlock(block, b.l1Origin, isNewEpoch)
+}
+
+// Returns true if the current batch has the max # of blocks, or its first block was processed too long ago.
+// The age is measured locally, so it's unaffected by any skew between the L2 block timestamps and the local clock.
+func (b *batchBuilder) exceedsMaxAge() bool {
+	if b.numProcessed == 0 {
+		return false
+	}
+	if maxBlocks := b.cfg.GetMaxBatchBlocks(); maxBlocks != 0 && b.numProcessed >= maxBlocks {
+		log.Info("Batch has max # of blocks", "#blocks", b.numProcessed)
+		return true
+	}
+	if maxAge := b.cfg.GetMaxBatchAge(); maxAge != 0 {
+		age := b.now().Sub(b.firstSeen)
+		if age >= maxAge {
+			log.Info("Batch exceeds max age", "age", age)
+			return true
+		}
 	}
-	return err
+	return false
 }
 
 // Updates the batch timeout if the given L1 epoch is earlier than the current timeout.
@@ -173,16 +398,53 @@ func (e *DecodeTxBatchError) Error() string {
 	return fmt.Sprintf("failed to decode batch: %s", e.msg)
 }
 
-// TODO: this is not currently called anywhere but will be useful for testing.
+// Decodes versioned batch data (as appended to the sequencer inbox).
 func DecodeBatch(data []byte) (interface{}, error) {
 	if len(data) == 0 {
 		return nil, &DecodeTxBatchError{"empty batch data"}
 	}
-	// TODO: use map.
-	switch data[0] {
-	case V0:
-		return decodeV0(data[1:])
-	default:
+	format, ok := batchFormats[data[0]]
+	if !ok {
 		return nil, &DecodeTxBatchError{fmt.Sprintf("invalid batch version: %d", data[0])}
 	}
+	return format.decode(data[1:])
+}
+
+// Decodes a batch and returns the numbers of the first and last L2 blocks encoded in it (see `l2BlockRange`).
+func DecodeL2BlockRange(data []byte) (first, last uint64, ok bool, err error) {
+	decoded, err := DecodeBatch(data)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	first, last, ok = l2BlockRange(decoded)
+	return first, last, ok, nil
+}
+
+// Returns the numbers of the first and last L2 blocks encoded in a decoded batch.
+// Note: for V0/V1 batches, leading and trailing empty blocks are not encoded (so not accounted for).
+// Returns false if the batch encodes no blocks.
+func l2BlockRange(decoded interface{}) (first, last uint64, ok bool) {
+	switch subBatches := decoded.(type) {
+	case []subBatch:
+		for _, sb := range subBatches {
+			if len(sb.TxBlocks) == 0 {
+				continue
+			}
+			if !ok {
+				first, ok = sb.FirstL2BlockNum, true
+			}
+			last = sb.FirstL2BlockNum + uint64(len(sb.TxBlocks)) - 1
+		}
+	case []subBatchV2:
+		for _, sb := range subBatches {
+			if len(sb.Blocks) == 0 {
+				continue
+			}
+			if !ok {
+				first, ok = sb.FirstL2BlockNum, true
+			}
+			last = sb.FirstL2BlockNum + uint64(len(sb.Blocks)) - 1
+		}
+	}
+	return first, last, ok
 }
@@ -0,0 +1,196 @@
+package derivation
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+var (
+	lastEnqueuedKey = []byte("BatchBuilderLastEnqueued")
+	lastBuiltKey    = []byte("BatchBuilderLastBuilt")
+	deadlineKey     = []byte("BatchBuilderDeadline")
+	l1OriginKey     = []byte("BatchBuilderL1Origin")
+	batchVersionKey = []byte("BatchBuilderBatchVersion")
+	// Unbatched blocks are keyed by prefix || number (uint64 big endian), so they're iterated in order.
+	blockKeyPrefix = []byte("BatchBuilderBlock")
+)
+
+// Persists batch builder state to a key-value store, so that it survives restarts.
+// Enqueued blocks are kept until they're included in a built batch.
+type BatchBuilderStore struct {
+	db ethdb.KeyValueStore
+}
+
+// Batch builder state, as restored from a store.
+type builderState struct {
+	unbatchedBlocks []*ethTypes.Block
+	lastEnqueued    types.BlockID
+	lastBuilt       []byte
+	deadline        uint64 // deadline of the last built batch (0 if unknown)
+	l1Origin        types.BlockID
+	batchStarted    bool                // whether the current batch was started (i.e. has an encoder)
+	batchVersion    BatchEncoderVersion // version of the current batch, if started
+}
+
+func NewBatchBuilderStore(db ethdb.KeyValueStore) *BatchBuilderStore {
+	return &BatchBuilderStore{db: db}
+}
+
+// Loads the persisted state (empty if nothing was persisted).
+func (s *BatchBuilderStore) load() (*builderState, error) {
+	var state builderState
+	if err := s.readRLP(lastEnqueuedKey, &state.lastEnqueued); err != nil {
+		return nil, fmt.Errorf("failed to read last enqueued block: %w", err)
+	}
+	if err := s.readRLP(l1OriginKey, &state.l1Origin); err != nil {
+		return nil, fmt.Errorf("failed to read L1 origin: %w", err)
+	}
+	if ok, _ := s.db.Has(lastBuiltKey); ok {
+		lastBuilt, err := s.db.Get(lastBuiltKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read last built batch: %w", err)
+		}
+		state.lastBuilt = lastBuilt
+	}
+	if err := s.readRLP(deadlineKey, &state.deadline); err != nil {
+		return nil, fmt.Errorf("failed to read deadline: %w", err)
+	}
+	if ok, _ := s.db.Has(batchVersionKey); ok {
+		version, err := s.db.Get(batchVersionKey)
+		if err != nil || len(version) != 1 {
+			return nil, fmt.Errorf("failed to read batch version: %x (err=%v)", version, err)
+		}
+		state.batchStarted, state.batchVersion = true, version[0]
+	}
+	it := s.db.NewIterator(blockKeyPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		var block ethTypes.Block
+		if err := rlp.DecodeBytes(it.Value(), &block); err != nil {
+			return nil, fmt.Errorf("failed to decode block (key=%x): %w", it.Key(), err)
+		}
+		state.unbatchedBlocks = append(state.unbatchedBlocks, &block)
+	}
+	if err := it.Error(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over blocks: %w", err)
+	}
+	return &state, nil
+}
+
+// Persists an enqueued block.
+func (s *BatchBuilderStore) writeEnqueued(block *ethTypes.Block) error {
+	batch := s.db.NewBatch()
+	if err := writeRLP(batch, blockKey(block.NumberU64()), block); err != nil {
+		return err
+	}
+	if err := writeRLP(batch, lastEnqueuedKey, types.NewBlockID(block.NumberU64(), block.Hash())); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Persists the version of a newly-started batch, so that it's resumed with the same version after a restart.
+func (s *BatchBuilderStore) writeBatchStarted(version BatchEncoderVersion) error {
+	return s.db.Put(batchVersionKey, []byte{version})
+}
+
+// Persists the first built batch not yet advanced past, which includes all blocks up to `lastBlockNum`,
+// its deadline (0 if unknown), and the L1 origin after building it.
+func (s *BatchBuilderStore) writeBuilt(data []byte, lastBlockNum uint64, deadline uint64, l1Origin types.BlockID) error {
+	batch := s.db.NewBatch()
+	if err := s.deleteBlocks(batch, lastBlockNum); err != nil {
+		return err
+	}
+	if err := batch.Put(lastBuiltKey, data); err != nil {
+		return err
+	}
+	if err := writeRLP(batch, deadlineKey, deadline); err != nil {
+		return err
+	}
+	if err := writeRLP(batch, l1OriginKey, l1Origin); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Deletes the version of the current batch, once it's built.
+func (s *BatchBuilderStore) deleteBatchStarted() error {
+	return s.db.Delete(batchVersionKey)
+}
+
+// Deletes the last built batch.
+func (s *BatchBuilderStore) deleteLastBuilt() error {
+	batch := s.db.NewBatch()
+	if err := batch.Delete(lastBuiltKey); err != nil {
+		return err
+	}
+	if err := batch.Delete(deadlineKey); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Deletes all state, except for the last enqueued block.
+func (s *BatchBuilderStore) reset(lastEnqueued types.BlockID) error {
+	batch := s.db.NewBatch()
+	if err := s.deleteBlocks(batch, ^uint64(0)); err != nil {
+		return err
+	}
+	for _, key := range [][]byte{lastBuiltKey, deadlineKey, l1OriginKey, batchVersionKey} {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := writeRLP(batch, lastEnqueuedKey, lastEnqueued); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Deletes all blocks up to (and including) `lastBlockNum`.
+func (s *BatchBuilderStore) deleteBlocks(batch ethdb.Batch, lastBlockNum uint64) error {
+	it := s.db.NewIterator(blockKeyPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if bytes.Compare(it.Key(), blockKey(lastBlockNum)) > 0 {
+			break
+		}
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// Decodes the RLP value at `key` into `val`, if it exists.
+func (s *BatchBuilderStore) readRLP(key []byte, val interface{}) error {
+	if ok, _ := s.db.Has(key); !ok {
+		return nil
+	}
+	data, err := s.db.Get(key)
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(data, val)
+}
+
+func writeRLP(w ethdb.KeyValueWriter, key []byte, val interface{}) error {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return fmt.Errorf("failed to encode value (key=%s): %w", key, err)
+	}
+	return w.Put(key, data)
+}
+
+func blockKey(number uint64) []byte {
+	key := make([]byte, len(blockKeyPrefix)+8)
+	copy(key, blockKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(blockKeyPrefix):], number)
+	return key
+}
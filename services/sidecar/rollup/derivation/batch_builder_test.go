@@ -0,0 +1,287 @@
+package derivation
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+type testBuilderConfig struct {
+	version   BatchEncoderVersion
+	maxAge    time.Duration
+	maxBlocks uint64
+}
+
+func (testBuilderConfig) GetL1OracleAddr() common.Address        { return testOracleAddr }
+func (testBuilderConfig) GetSeqWindowSize() uint64               { return 10 }
+func (testBuilderConfig) GetSubSafetyMargin() uint64             { return 2 }
+func (testBuilderConfig) GetTargetBatchSize() uint64             { return 10_000 }
+func (testBuilderConfig) GetTargetBatchGas() uint64              { return 0 }
+func (c testBuilderConfig) GetBatchVersion() BatchEncoderVersion { return c.version }
+func (c testBuilderConfig) GetMaxBatchAge() time.Duration        { return c.maxAge }
+func (c testBuilderConfig) GetMaxBatchBlocks() uint64            { return c.maxBlocks }
+
+// Returns a chain of `n` L2 blocks (one tx each) following `parent`.
+func testL2Chain(t *testing.T, parent *ethTypes.Block, n int) []*ethTypes.Block {
+	t.Helper()
+	key, _ := crypto.GenerateKey()
+	txs := testL2Txs(t, key, 0, n)
+	var blocks []*ethTypes.Block
+	for i := 0; i < n; i++ {
+		header := &ethTypes.Header{
+			Number:     new(big.Int).Add(parent.Number(), big.NewInt(1)),
+			ParentHash: parent.Hash(),
+			Time:       parent.Time() + 1,
+		}
+		parent = ethTypes.NewBlockWithHeader(header).WithBody(txs[i:i+1], nil)
+		blocks = append(blocks, parent)
+	}
+	return blocks
+}
+
+func newTestBuilder(t *testing.T, cfg testBuilderConfig, store *BatchBuilderStore) *batchBuilder {
+	t.Helper()
+	registry, err := NewBatchVersionRegistry(map[BatchEncoderVersion]uint64{V0: 0, V1: 0})
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	b, err := NewBatchBuilder(cfg, registry, store)
+	if err != nil {
+		t.Fatalf("failed to create builder: %v", err)
+	}
+	return b
+}
+
+func TestBatchBuilderResume(t *testing.T) {
+	var (
+		store   = NewBatchBuilderStore(rawdb.NewMemoryDatabase())
+		genesis = ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: big.NewInt(0)})
+		blocks  = testL2Chain(t, genesis, 3)
+		l1Head  = types.NewBlockID(1, common.Hash{})
+	)
+	b := newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	if err := b.Reset(types.NewBlockID(0, genesis.Hash())); err != nil {
+		t.Fatalf("failed to reset builder: %v", err)
+	}
+	for _, block := range blocks[:2] {
+		if err := b.Enqueue(block); err != nil {
+			t.Fatalf("failed to enqueue block: %v", err)
+		}
+	}
+	// Starts a V0 batch, which is too small to build.
+	if _, err := b.Build(l1Head); !errors.Is(err, io.EOF) {
+		t.Fatalf("got error %v, want %v", err, io.EOF)
+	}
+
+	// Restart, configured for a later version.
+	b = newTestBuilder(t, testBuilderConfig{version: V1}, store)
+	if b.LastEnqueued().GetHash() != blocks[1].Hash() {
+		t.Fatalf("last enqueued %s, want %s", b.LastEnqueued(), blocks[1].Hash())
+	}
+	if err := b.Enqueue(blocks[2]); err != nil {
+		t.Fatalf("failed to enqueue block: %v", err)
+	}
+	b.Flush()
+	batch, err := b.Build(l1Head)
+	if err != nil {
+		t.Fatalf("failed to build batch: %v", err)
+	}
+	// The batch in progress is resumed with its version, incl. the blocks enqueued before the restart.
+	if batch[0] != V0 {
+		t.Errorf("built batch version %d, want %d", batch[0], V0)
+	}
+	if first, last, _, err := DecodeL2BlockRange(batch); err != nil || first != 1 || last != 3 {
+		t.Errorf("built blocks [%d, %d] (err=%v), want [1, 3]", first, last, err)
+	}
+	b.Advance()
+
+	// The next batch is started with the configured version.
+	b = newTestBuilder(t, testBuilderConfig{version: V1}, store)
+	if b.FirstPending() != 4 {
+		t.Errorf("first pending %d, want 4", b.FirstPending())
+	}
+	for _, block := range testL2Chain(t, blocks[2], 1) {
+		if err := b.Enqueue(block); err != nil {
+			t.Fatalf("failed to enqueue block: %v", err)
+		}
+	}
+	b.Flush()
+	if batch, err = b.Build(l1Head); err != nil {
+		t.Fatalf("failed to build batch: %v", err)
+	}
+	if batch[0] != V1 {
+		t.Errorf("built batch version %d, want %d", batch[0], V1)
+	}
+}
+
+func TestBatchBuilderRestoresLastBuilt(t *testing.T) {
+	var (
+		store   = NewBatchBuilderStore(rawdb.NewMemoryDatabase())
+		genesis = ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: big.NewInt(0)})
+		blocks  = testL2Chain(t, genesis, 2)
+	)
+	b := newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	if err := b.Reset(types.NewBlockID(0, genesis.Hash())); err != nil {
+		t.Fatalf("failed to reset builder: %v", err)
+	}
+	for _, block := range blocks {
+		if err := b.Enqueue(block); err != nil {
+			t.Fatalf("failed to enqueue block: %v", err)
+		}
+	}
+	b.Flush()
+	built, err := b.Build(types.NewBlockID(1, common.Hash{}))
+	if err != nil {
+		t.Fatalf("failed to build batch: %v", err)
+	}
+	b = newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	restored, err := b.Build(types.NewBlockID(1, common.Hash{}))
+	if err != nil {
+		t.Fatalf("failed to build batch: %v", err)
+	}
+	if string(restored) != string(built) {
+		t.Errorf("restored batch %x, want %x", restored, built)
+	}
+	if b.FirstPending() != 1 {
+		t.Errorf("first pending %d, want 1", b.FirstPending())
+	}
+}
+
+func TestBatchBuilderBuildAhead(t *testing.T) {
+	var (
+		store   = NewBatchBuilderStore(rawdb.NewMemoryDatabase())
+		genesis = ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: big.NewInt(0)})
+		blocks  = testL2Chain(t, genesis, 4)
+		l1Head  = types.NewBlockID(1, common.Hash{})
+	)
+	b := newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	if err := b.Reset(types.NewBlockID(0, genesis.Hash())); err != nil {
+		t.Fatalf("failed to reset builder: %v", err)
+	}
+	// Builds two batches: [1, 2] and [3, 4].
+	var batches [][]byte
+	for i := 0; i < 2; i++ {
+		for _, block := range blocks[2*i : 2*i+2] {
+			if err := b.Enqueue(block); err != nil {
+				t.Fatalf("failed to enqueue block: %v", err)
+			}
+		}
+		b.Flush()
+		batch, err := b.BuildAhead(l1Head, i)
+		if err != nil {
+			t.Fatalf("failed to build batch %d: %v", i, err)
+		}
+		batches = append(batches, batch)
+	}
+	if _, err := b.BuildAhead(l1Head, 3); err == nil {
+		t.Fatalf("built ahead of unbuilt batch")
+	}
+	if batch, _ := b.Build(l1Head); string(batch) != string(batches[0]) {
+		t.Fatalf("got batch %x, want first batch %x", batch, batches[0])
+	}
+
+	// Only the first batch is persisted; the blocks of the second are re-encoded after a restart.
+	restarted := newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	if batch, _ := restarted.Build(l1Head); string(batch) != string(batches[0]) {
+		t.Errorf("restored batch %x, want first batch %x", batch, batches[0])
+	}
+	restarted.Flush()
+	batch, err := restarted.BuildAhead(l1Head, 1)
+	if err != nil {
+		t.Fatalf("failed to rebuild second batch: %v", err)
+	}
+	if first, last, _, _ := DecodeL2BlockRange(batch); first != 3 || last != 4 {
+		t.Errorf("rebuilt blocks [%d, %d], want [3, 4]", first, last)
+	}
+
+	// Advancing past the first batch persists the second.
+	b.Advance()
+	if b.FirstPending() != 3 {
+		t.Errorf("first pending %d, want 3", b.FirstPending())
+	}
+	restarted = newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	if batch, _ := restarted.Build(l1Head); string(batch) != string(batches[1]) {
+		t.Errorf("restored batch %x, want second batch %x", batch, batches[1])
+	}
+	b.Advance()
+	restarted = newTestBuilder(t, testBuilderConfig{version: V0}, store)
+	if restarted.FirstPending() != 5 {
+		t.Errorf("first pending %d after restart, want 5", restarted.FirstPending())
+	}
+}
+
+// Returns a builder reset to `genesis`, with the given blocks enqueued.
+func newTestBuilderWithBlocks(
+	t *testing.T,
+	cfg testBuilderConfig,
+	genesis *ethTypes.Block,
+	blocks []*ethTypes.Block,
+) *batchBuilder {
+	t.Helper()
+	b := newTestBuilder(t, cfg, NewBatchBuilderStore(rawdb.NewMemoryDatabase()))
+	if err := b.Reset(types.NewBlockID(0, genesis.Hash())); err != nil {
+		t.Fatalf("failed to reset builder: %v", err)
+	}
+	for _, block := range blocks {
+		if err := b.Enqueue(block); err != nil {
+			t.Fatalf("failed to enqueue block: %v", err)
+		}
+	}
+	return b
+}
+
+func TestBatchBuilderMaxBlocks(t *testing.T) {
+	var (
+		genesis = ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: big.NewInt(0)})
+		l1Head  = types.NewBlockID(1, common.Hash{})
+		b       = newTestBuilderWithBlocks(t, testBuilderConfig{version: V0, maxBlocks: 2}, genesis, testL2Chain(t, genesis, 5))
+	)
+	// Batches are built once they have the max # of blocks (well below the target size), and capped at it.
+	for _, want := range [][2]uint64{{1, 2}, {3, 4}} {
+		batch, err := b.Build(l1Head)
+		if err != nil {
+			t.Fatalf("failed to build batch: %v", err)
+		}
+		if first, last, _, err := DecodeL2BlockRange(batch); err != nil || first != want[0] || last != want[1] {
+			t.Errorf("built blocks [%d, %d] (err=%v), want %v", first, last, err, want)
+		}
+		b.Advance()
+	}
+	if _, err := b.Build(l1Head); !errors.Is(err, io.EOF) {
+		t.Errorf("got error %v, want %v", err, io.EOF)
+	}
+}
+
+func TestBatchBuilderMaxAge(t *testing.T) {
+	var (
+		// L2 block timestamps are far in the past; only the local time the batch was started at counts.
+		genesis = ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: big.NewInt(0)})
+		l1Head  = types.NewBlockID(1, common.Hash{})
+		now     = time.Unix(1_700_000_000, 0)
+		b       = newTestBuilderWithBlocks(t, testBuilderConfig{version: V0, maxAge: time.Minute}, genesis, testL2Chain(t, genesis, 2))
+	)
+	b.now = func() time.Time { return now }
+	if _, err := b.Build(l1Head); !errors.Is(err, io.EOF) {
+		t.Fatalf("got error %v, want %v", err, io.EOF)
+	}
+	now = now.Add(time.Minute - time.Second)
+	if _, err := b.Build(l1Head); !errors.Is(err, io.EOF) {
+		t.Fatalf("got error %v before max age, want %v", err, io.EOF)
+	}
+	now = now.Add(time.Second)
+	batch, err := b.Build(l1Head)
+	if err != nil {
+		t.Fatalf("failed to build batch at max age: %v", err)
+	}
+	if first, last, _, err := DecodeL2BlockRange(batch); err != nil || first != 1 || last != 2 {
+		t.Errorf("built blocks [%d, %d] (err=%v), want [1, 2]", first, last, err)
+	}
+}
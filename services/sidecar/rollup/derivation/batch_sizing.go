@@ -0,0 +1,42 @@
+package derivation
+
+import "github.com/ethereum/go-ethereum/rlp"
+
+type SizingConfig interface {
+	GetTargetBatchSize() uint64
+	GetTargetBatchGas() uint64
+}
+
+// Measures (estimated) batches against a target, to determine when they're full.
+type SizingPolicy interface {
+	// Returns the measure of a batch of `numBytes` bytes, `numZeroBytes` of which are zero.
+	Measure(numBytes, numZeroBytes uint64) uint64
+	// Returns the target measure of a batch.
+	Target() uint64
+}
+
+// Returns a gas-based policy if a target gas is configured, or a byte-based one otherwise.
+func NewSizingPolicy(cfg SizingConfig) SizingPolicy {
+	if cfg.GetTargetBatchGas() != 0 {
+		return gasSizingPolicy{cfg.GetTargetBatchGas()}
+	}
+	return byteSizingPolicy{cfg.GetTargetBatchSize()}
+}
+
+// Returns the min target of a gas-based policy: the estimated gas of a batch tx holding an empty sub-batch.
+// At or below it, no block fits a batch, so every block would be rejected as overflowing it.
+func MinTargetBatchGas() uint64 { return AppendTxBatchGas(rlp.ListSize(0), 0) }
+
+// Measures batches by their size (# of bytes).
+type byteSizingPolicy struct{ target uint64 }
+
+func (p byteSizingPolicy) Measure(numBytes, _ uint64) uint64 { return numBytes }
+func (p byteSizingPolicy) Target() uint64                    { return p.target }
+
+// Measures batches by the estimated L1 gas of the `appendTxBatch` tx that posts them as calldata.
+type gasSizingPolicy struct{ target uint64 }
+
+func (p gasSizingPolicy) Measure(numBytes, numZeroBytes uint64) uint64 {
+	return AppendTxBatchGas(numBytes, numZeroBytes)
+}
+func (p gasSizingPolicy) Target() uint64 { return p.target }
@@ -0,0 +1,34 @@
+package derivation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+func TestMinTargetBatchGas(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	block := testL2Block(1, testL2Txs(t, key, 0, 1))
+	tests := []struct {
+		name    string
+		target  uint64
+		wantErr error
+	}{
+		// No block would ever fit, so batches could never be built.
+		{"at min target", MinTargetBatchGas(), errBatchFull},
+		{"above min target", MinTargetBatchGas() + 1, nil},
+	}
+	for version, format := range batchFormats {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("V%d %s", version, tt.name), func(t *testing.T) {
+				e := format.newEncoder(gasSizingPolicy{tt.target})
+				if err := e.ProcessBlock(block, types.EmptyBlockID, false); !errors.Is(err, tt.wantErr) {
+					t.Errorf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package derivation
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+// Max size of a batch served by a DA server.
+const maxStoredBatchSize = 16 * 1024 * 1024
+
+// Stores batches as files in a local directory (e.g. as a stand-in for a DA server in tests).
+type FileBatchStore struct {
+	dir string
+}
+
+func NewFileBatchStore(dir string) (*FileBatchStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create batch store directory: %w", err)
+	}
+	return &FileBatchStore{dir: dir}, nil
+}
+
+func (s *FileBatchStore) Put(_ context.Context, key common.Hash, batch []byte) error {
+	// Write atomically, so that a partially-written batch is never served.
+	tmp, err := os.CreateTemp(s.dir, key.Hex()+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(batch); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+func (s *FileBatchStore) Get(_ context.Context, key common.Hash) ([]byte, error) {
+	return os.ReadFile(s.path(key))
+}
+
+func (s *FileBatchStore) path(key common.Hash) string { return filepath.Join(s.dir, key.Hex()) }
+
+// Stores batches on a DA server over HTTP: batches are PUT to and fetched by GET from `<url>/<key>`.
+type HTTPBatchStore struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPBatchStore(url string) *HTTPBatchStore {
+	return &HTTPBatchStore{url: strings.TrimSuffix(url, "/"), client: &http.Client{}}
+}
+
+func (s *HTTPBatchStore) Put(ctx context.Context, key common.Hash, batch []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.keyURL(key), bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *HTTPBatchStore) Get(ctx context.Context, key common.Hash) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.keyURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxStoredBatchSize))
+}
+
+func (s *HTTPBatchStore) keyURL(key common.Hash) string { return s.url + "/" + key.Hex() }
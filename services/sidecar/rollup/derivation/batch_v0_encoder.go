@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
+	spTypes "github.com/specularL2/specular/services/sidecar/rollup/types"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 	"github.com/specularL2/specular/services/sidecar/utils/log"
 )
@@ -14,30 +15,35 @@ import (
 var errBatchFull = errors.New("batch full")
 var errBatchTooSmall = errors.New("batch too small")
 
-type V0Config interface {
-	GetTargetBatchSize() uint64
-}
-
 type BatchV0Encoder struct {
-	cfg        V0Config
-	subBatches []*subBatch
-	runningLen uint64
+	policy       SizingPolicy
+	subBatches   []*subBatch
+	runningLen   uint64
+	runningZeros uint64 // # of zero bytes in closed sub-batches
 }
 
-func NewBatchV0Encoder(cfg V0Config) *BatchV0Encoder {
-	return &BatchV0Encoder{cfg, []*subBatch{newSubBatch()}, 0}
+func NewBatchV0Encoder(policy SizingPolicy) *BatchV0Encoder {
+	return &BatchV0Encoder{policy, []*subBatch{newSubBatch()}, 0, 0}
 }
 
+// Returns the current batch (if ready, or forced) and starts a new one.
+// All processed blocks are included: the open sub-batch is closed first (`ProcessBlock` keeps it within the
+// target, softly). Returns `errBatchTooSmall` rather than an empty batch if no blocks were processed.
+// The batch is encoded in the V0 format: the version byte, followed by the RLP-encoded list of sub-batches.
 func (e *BatchV0Encoder) GetBatch(force bool) ([]byte, error) {
 	// Return error if the batch is too small and the timeout hasn't been reached.
 	// If the timeout has been reached, the batch will be closed regardless of its current size.
-	totalLen := e.runningLen + e.subBatches[len(e.subBatches)-1].contentSize
-	if !force && totalLen < e.cfg.GetTargetBatchSize() {
+	var (
+		currSubBatch = e.subBatches[len(e.subBatches)-1]
+		totalSize    = e.policy.Measure(e.runningLen+currSubBatch.contentSize, e.runningZeros+currSubBatch.zeroBytes)
+	)
+	if !force && totalSize < e.policy.Target() {
 		return nil, errBatchTooSmall
 	}
-	// Close the sub-batch if the batch can fit it.
-	if e.shouldCloseBatch() {
-		e.closeSubBatch()
+	// Close the current sub-batch; `ProcessBlock` guarantees it fits (softly).
+	e.closeSubBatch()
+	if len(e.subBatches) == 1 {
+		return nil, errBatchTooSmall
 	}
 	// Encode version.
 	buf := bytes.NewBuffer(nil)
@@ -48,12 +54,14 @@ func (e *BatchV0Encoder) GetBatch(force bool) ([]byte, error) {
 	if err := rlp.Encode(buf, e.subBatches[:len(e.subBatches)-1]); err != nil {
 		return nil, fmt.Errorf("failed to encode batch: %w", err)
 	}
+	// Start a new batch.
+	e.Reset()
 	return buf.Bytes(), nil
 }
 
 // Processes a block. If the block is non-empty and fits, add it to the current sub-batch.
 // If the block belongs to a new epoch, close the current sub-batch and start a new one.
-func (e *BatchV0Encoder) ProcessBlock(block *types.Block, isNewEpoch bool) error {
+func (e *BatchV0Encoder) ProcessBlock(block *types.Block, _ spTypes.BlockID, isNewEpoch bool) error {
 	var (
 		// Block is empty
 		shouldSkipBlock = len(block.Transactions()) == 0
@@ -86,13 +94,14 @@ func (e *BatchV0Encoder) ProcessBlock(block *types.Block, isNewEpoch bool) error
 func (e *BatchV0Encoder) Reset() {
 	e.subBatches = []*subBatch{newSubBatch()}
 	e.runningLen = 0
+	e.runningZeros = 0
 }
 
 // Returns the data format version (v0).
 func (e *BatchV0Encoder) getVersion() BatchEncoderVersion { return V0 }
 func (e *BatchV0Encoder) shouldCloseBatch() bool {
 	var currSubBatch = e.subBatches[len(e.subBatches)-1]
-	return e.runningLen+currSubBatch.size() > e.cfg.GetTargetBatchSize()
+	return e.policy.Measure(e.runningLen+currSubBatch.size(), e.runningZeros+currSubBatch.zeroBytes) > e.policy.Target()
 }
 
 // Closes the current sub-batch.
@@ -104,24 +113,35 @@ func (e *BatchV0Encoder) closeSubBatch() {
 	}
 	log.Info("Closing sub-batch...")
 	e.runningLen += currSubBatch.size()
+	e.runningZeros += currSubBatch.zeroBytes
 	e.subBatches = append(e.subBatches, newSubBatch())
 }
 
+// Note: fields must be exported to be RLP-encoded.
 type subBatch struct {
-	firstL2BlockNum uint64
-	txBlocks        []rawTxBlock
-	contentSize     uint64 `rlp:"-"` // size of sub-batch content (# of bytes)
+	FirstL2BlockNum uint64
+	TxBlocks        []rawTxBlock
+	contentSize     uint64 // size of sub-batch content (# of bytes)
+	zeroBytes       uint64 // # of zero bytes in sub-batch content
 }
 
 type rawTxBlock []hexutil.Bytes
 
+func (b rawTxBlock) countZeroBytes() uint64 {
+	var n uint64
+	for _, rawTx := range b {
+		n += countZeroBytes(rawTx)
+	}
+	return n
+}
+
 func newSubBatch() *subBatch     { return &subBatch{contentSize: 0} }
 func (s *subBatch) size() uint64 { return rlp.ListSize(s.contentSize) }
 
 func (s *subBatch) appendTxBlock(blockNum uint64, txs types.Transactions) error {
 	// Set the first L2 block number if it hasn't been set yet.
-	if len(s.txBlocks) == 0 {
-		s.firstL2BlockNum = blockNum
+	if len(s.TxBlocks) == 0 {
+		s.FirstL2BlockNum = blockNum
 		s.contentSize += uint64(rlp.IntSize(blockNum))
 	}
 	// Append the block of txs to the sub-batch.
@@ -129,8 +149,9 @@ func (s *subBatch) appendTxBlock(blockNum uint64, txs types.Transactions) error
 	if err != nil {
 		return fmt.Errorf("could not marshall txs: %w", err)
 	}
-	s.txBlocks = append(s.txBlocks, marshalled)
+	s.TxBlocks = append(s.TxBlocks, marshalled)
 	s.contentSize += uint64(numBytes)
+	s.zeroBytes += marshalled.countZeroBytes()
 	return nil
 }
 
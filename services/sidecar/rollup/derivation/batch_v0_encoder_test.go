@@ -0,0 +1,144 @@
+package derivation
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+// An L2 block to process, and whether it starts a new epoch.
+type testEncoderBlock struct {
+	block      *ethTypes.Block
+	isNewEpoch bool
+}
+
+func testL2Block(num uint64, txs ethTypes.Transactions) *ethTypes.Block {
+	return ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: new(big.Int).SetUint64(num)}).WithBody(txs, nil)
+}
+
+// Returns the first block number and # of blocks of each sub-batch in a V0 batch.
+func decodeV0Summary(t *testing.T, batch []byte) [][2]uint64 {
+	t.Helper()
+	if batch[0] != V0 {
+		t.Fatalf("got version %d, want %d", batch[0], V0)
+	}
+	subBatches, err := decodeV0(batch[1:])
+	if err != nil {
+		t.Fatalf("failed to decode batch: %v", err)
+	}
+	var summary [][2]uint64
+	for _, sb := range subBatches {
+		summary = append(summary, [2]uint64{sb.FirstL2BlockNum, uint64(len(sb.TxBlocks))})
+	}
+	return summary
+}
+
+func TestBatchV0EncoderGetBatch(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	txs := testL2Txs(t, key, 0, 4)
+	var (
+		b1 = testEncoderBlock{block: testL2Block(1, txs[:1])}
+		b2 = testEncoderBlock{block: testL2Block(2, txs[1:2])}
+		b3 = testEncoderBlock{block: testL2Block(3, txs[2:3])}
+		b4 = testEncoderBlock{block: testL2Block(4, txs[3:])}
+	)
+	tests := []struct {
+		name   string
+		target uint64
+		blocks []testEncoderBlock
+		// Whether the last block doesn't fit the batch.
+		wantFull bool
+		force    bool
+		wantErr  error
+		// First block number and # of blocks of each sub-batch.
+		want [][2]uint64
+	}{
+		{name: "waits for target", target: 10_000, blocks: []testEncoderBlock{b1}, wantErr: errBatchTooSmall},
+		{name: "no empty batch when forced", target: 10_000, force: true, wantErr: errBatchTooSmall},
+		{
+			// Previously, the open sub-batch was dropped from forced batches below target.
+			name:   "forced batch includes open sub-batch",
+			target: 10_000,
+			blocks: []testEncoderBlock{b1, b2},
+			force:  true,
+			want:   [][2]uint64{{1, 2}},
+		},
+		{
+			name:   "empty blocks and new epochs split sub-batches",
+			target: 10_000,
+			blocks: []testEncoderBlock{
+				b1,
+				{block: testL2Block(2, nil)},
+				{block: b3.block, isNewEpoch: true},
+				b4,
+			},
+			force: true,
+			want:  [][2]uint64{{1, 1}, {3, 2}},
+		},
+		{
+			name:     "full batch excludes overflowing block",
+			target:   150, // Fits two single-tx blocks (softly), but not three.
+			blocks:   []testEncoderBlock{b1, b2, b3},
+			wantFull: true,
+			want:     [][2]uint64{{1, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewBatchV0Encoder(byteSizingPolicy{tt.target})
+			for i, b := range tt.blocks {
+				err := e.ProcessBlock(b.block, types.EmptyBlockID, b.isNewEpoch)
+				if tt.wantFull && i == len(tt.blocks)-1 {
+					if !errors.Is(err, errBatchFull) {
+						t.Fatalf("block %d: got error %v, want %v", b.block.NumberU64(), err, errBatchFull)
+					}
+				} else if err != nil {
+					t.Fatalf("block %d: failed to process: %v", b.block.NumberU64(), err)
+				}
+			}
+			batch, err := e.GetBatch(tt.force)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := decodeV0Summary(t, batch)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got sub-batches %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got sub-batches %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchV0EncoderStartsNewBatch(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	txs := testL2Txs(t, key, 0, 2)
+	e := NewBatchV0Encoder(byteSizingPolicy{10_000})
+	if err := e.ProcessBlock(testL2Block(1, txs[:1]), types.EmptyBlockID, false); err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	if _, err := e.GetBatch(true); err != nil {
+		t.Fatalf("failed to get batch: %v", err)
+	}
+	// Previously, the caller had to reset the encoder, or the next batch would repeat block 1.
+	if err := e.ProcessBlock(testL2Block(2, txs[1:]), types.EmptyBlockID, false); err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	batch, err := e.GetBatch(true)
+	if err != nil {
+		t.Fatalf("failed to get batch: %v", err)
+	}
+	if got := decodeV0Summary(t, batch); len(got) != 1 || got[0] != [2]uint64{2, 1} {
+		t.Errorf("got sub-batches %v, want [[2 1]]", got)
+	}
+}
@@ -0,0 +1,167 @@
+package derivation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	spTypes "github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+const V1 BatchEncoderVersion = 0x1
+
+// Upper bound on the decompressed size of a V1 batch (protects decoders against zip bombs).
+const maxDecompressedBatchSize = 16 * 1024 * 1024
+
+var errBatchTooLarge = errors.New("decompressed batch exceeds max size")
+
+// Encodes batches as: version || zlib(rlp(subBatch_0) || ... || rlp(subBatch_n)).
+// The sizing target is enforced (softly) on the compressed batch.
+type BatchV1Encoder struct {
+	policy        SizingPolicy
+	currSubBatch  *subBatch
+	numSubBatches uint64 // number of closed sub-batches in the current batch
+	buf           *bytes.Buffer
+	bufZeroBytes  uint64 // # of zero bytes in buf
+	compressor    *zlib.Writer
+}
+
+func NewBatchV1Encoder(policy SizingPolicy) *BatchV1Encoder {
+	e := &BatchV1Encoder{policy: policy}
+	e.Reset()
+	return e
+}
+
+func (e *BatchV1Encoder) GetBatch(force bool) ([]byte, error) {
+	// Return error if the batch is too small and the timeout hasn't been reached.
+	// If the timeout has been reached, the batch will be closed regardless of its current size.
+	if !force && e.estimatedSize() < e.policy.Target() {
+		return nil, errBatchTooSmall
+	}
+	// Close the current sub-batch; `ProcessBlock` guarantees it fits (softly).
+	if err := e.closeSubBatch(); err != nil {
+		return nil, fmt.Errorf("failed to close sub-batch: %w", err)
+	}
+	if e.numSubBatches == 0 {
+		return nil, errBatchTooSmall
+	}
+	if err := e.compressor.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress batch: %w", err)
+	}
+	// Encode version.
+	batch := make([]byte, 0, 1+e.buf.Len())
+	batch = append(batch, e.getVersion())
+	batch = append(batch, e.buf.Bytes()...)
+	log.Info("Encoded V1 batch", "num_sub_batches", e.numSubBatches, "compressed_size", len(batch))
+	// Start a new batch.
+	e.Reset()
+	return batch, nil
+}
+
+// Processes a block. If the block is non-empty and fits, add it to the current sub-batch.
+// If the block belongs to a new epoch, close the current sub-batch and start a new one.
+func (e *BatchV1Encoder) ProcessBlock(block *types.Block, _ spTypes.BlockID, isNewEpoch bool) error {
+	var (
+		// Block is empty
+		shouldSkipBlock = len(block.Transactions()) == 0
+		// Batch would exceed the target size with the current sub-batch.
+		shouldCloseBatch = e.shouldCloseBatch()
+		// Should close sub-batch if we're closing the batch entirely, OR...
+		// the block is empty, OR... the block belongs to a new epoch.
+		shouldCloseSubBatch = shouldCloseBatch || shouldSkipBlock || isNewEpoch
+	)
+	if shouldCloseSubBatch {
+		if err := e.closeSubBatch(); err != nil {
+			return fmt.Errorf("failed to close sub-batch: %w", err)
+		}
+	}
+	// Enforce soft cap on batch size.
+	if shouldCloseBatch {
+		return errBatchFull
+	}
+	// Skip intrinsically-derivable blocks.
+	if shouldSkipBlock {
+		log.Info("Skipping intrinsically-derivable block", "block#", block.NumberU64())
+		return nil
+	}
+	// Append a block's txs to the current sub-batch.
+	if err := e.currSubBatch.appendTxBlock(block.NumberU64(), block.Transactions()); err != nil {
+		return fmt.Errorf("could not append block of txs: %w", err)
+	}
+	return nil
+}
+
+func (e *BatchV1Encoder) Reset() {
+	e.currSubBatch = newSubBatch()
+	e.numSubBatches = 0
+	e.buf = bytes.NewBuffer(nil)
+	e.bufZeroBytes = 0
+	e.compressor = zlib.NewWriter(e.buf)
+}
+
+// Returns the data format version (v1).
+func (e *BatchV1Encoder) getVersion() BatchEncoderVersion { return V1 }
+
+// Returns the measure (per the sizing policy) of all closed sub-batches compressed, plus the current one uncompressed.
+// This over-estimates the final batch size, since the current sub-batch will be compressed when closed.
+func (e *BatchV1Encoder) estimatedSize() uint64 {
+	return e.policy.Measure(uint64(e.buf.Len())+e.currSubBatch.size(), e.bufZeroBytes+e.currSubBatch.zeroBytes)
+}
+
+func (e *BatchV1Encoder) shouldCloseBatch() bool {
+	return e.estimatedSize() > e.policy.Target()
+}
+
+// Closes the current sub-batch, compressing it into the batch buffer.
+func (e *BatchV1Encoder) closeSubBatch() error {
+	// No need to close if it's empty.
+	if e.currSubBatch.size() == 0 {
+		return nil
+	}
+	log.Info("Closing sub-batch...")
+	prevLen := e.buf.Len()
+	if err := rlp.Encode(e.compressor, e.currSubBatch); err != nil {
+		return fmt.Errorf("failed to encode sub-batch: %w", err)
+	}
+	// Flush so that the buffer reflects the compressed size of all closed sub-batches.
+	if err := e.compressor.Flush(); err != nil {
+		return fmt.Errorf("failed to flush compressor: %w", err)
+	}
+	e.bufZeroBytes += countZeroBytes(e.buf.Bytes()[prevLen:])
+	e.numSubBatches += 1
+	e.currSubBatch = newSubBatch()
+	return nil
+}
+
+func decodeV1(data []byte) ([]subBatch, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// Allow reading one byte past the max size, to tell a batch cut off at the limit from one that ends there.
+	var (
+		limited = &io.LimitedReader{R: r, N: maxDecompressedBatchSize + 1}
+		stream  = rlp.NewStream(limited, 0)
+		decoded []subBatch
+	)
+	for {
+		var sb subBatch
+		if err := stream.Decode(&sb); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		decoded = append(decoded, sb)
+	}
+	if limited.N == 0 {
+		return nil, errBatchTooLarge
+	}
+	return decoded, nil
+}
@@ -0,0 +1,82 @@
+package derivation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Returns a sub-batch whose RLP encoding is exactly `size` bytes.
+func subBatchOfSize(t *testing.T, firstL2BlockNum uint64, size int) subBatch {
+	t.Helper()
+	// The encoding overhead is constant for payloads of similar size, so measure it once and adjust.
+	sb := subBatch{FirstL2BlockNum: firstL2BlockNum, TxBlocks: []rawTxBlock{{make(hexutil.Bytes, size)}}}
+	encoded, err := rlp.EncodeToBytes(sb)
+	if err != nil {
+		t.Fatalf("failed to encode sub-batch: %v", err)
+	}
+	sb.TxBlocks[0][0] = make(hexutil.Bytes, 2*size-len(encoded))
+	if encoded, _ = rlp.EncodeToBytes(sb); len(encoded) != size {
+		t.Fatalf("sub-batch size %d, want %d", len(encoded), size)
+	}
+	return sb
+}
+
+// Compresses the RLP encodings of the given sub-batches, as a V1 batch (without version).
+func compressSubBatches(t *testing.T, subBatches []subBatch) []byte {
+	t.Helper()
+	var (
+		buf = bytes.NewBuffer(nil)
+		w   = zlib.NewWriter(buf)
+	)
+	for _, sb := range subBatches {
+		if err := rlp.Encode(w, sb); err != nil {
+			t.Fatalf("failed to encode sub-batch: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeV1Limit(t *testing.T) {
+	const subBatchSize = 1024 * 1024
+	tests := []struct {
+		name          string
+		numSubBatches int
+		wantErr       error
+	}{
+		{"below max size", 15, nil},
+		{"at max size", maxDecompressedBatchSize / subBatchSize, nil},
+		// The limit falls exactly on a sub-batch boundary, so decoding would otherwise succeed.
+		{"one sub-batch past max size", maxDecompressedBatchSize/subBatchSize + 1, errBatchTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subBatches := make([]subBatch, tt.numSubBatches)
+			for i := range subBatches {
+				subBatches[i] = subBatchOfSize(t, uint64(i+1), subBatchSize)
+			}
+			decoded, err := decodeV1(compressSubBatches(t, subBatches))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(decoded) != tt.numSubBatches {
+				t.Errorf("decoded %d sub-batches, want %d", len(decoded), tt.numSubBatches)
+			}
+		})
+	}
+}
+
+func TestDecodeV1PartialSubBatch(t *testing.T) {
+	// A batch cut off mid-sub-batch by the limit must not be accepted either.
+	subBatches := []subBatch{subBatchOfSize(t, 1, maxDecompressedBatchSize-10), subBatchOfSize(t, 2, 100)}
+	if _, err := decodeV1(compressSubBatches(t, subBatches)); err == nil {
+		t.Fatal("decoded batch exceeding max size")
+	}
+}
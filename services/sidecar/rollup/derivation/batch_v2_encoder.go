@@ -0,0 +1,204 @@
+package derivation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	spTypes "github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+const V2 BatchEncoderVersion = 0x2
+
+// Encodes batches as: version || zlib(rlp(subBatchV2_0) || ... || rlp(subBatchV2_n)).
+// Unlike V0/V1, each sub-batch carries its L1 origin and the timestamp of each L2 block,
+// and empty blocks are encoded (as empty tx lists) rather than skipped,
+// so derivers can check epoch consistency and rebuild empty blocks without the L2 node.
+// The sizing target is enforced (softly) on the compressed batch.
+type BatchV2Encoder struct {
+	policy        SizingPolicy
+	currSubBatch  *subBatchV2
+	numSubBatches uint64 // number of closed sub-batches in the current batch
+	buf           *bytes.Buffer
+	bufZeroBytes  uint64 // # of zero bytes in buf
+	compressor    *zlib.Writer
+}
+
+func NewBatchV2Encoder(policy SizingPolicy) *BatchV2Encoder {
+	e := &BatchV2Encoder{policy: policy}
+	e.Reset()
+	return e
+}
+
+func (e *BatchV2Encoder) GetBatch(force bool) ([]byte, error) {
+	// Return error if the batch is too small and the timeout hasn't been reached.
+	// If the timeout has been reached, the batch will be closed regardless of its current size.
+	if !force && e.estimatedSize() < e.policy.Target() {
+		return nil, errBatchTooSmall
+	}
+	// Close the current sub-batch; `ProcessBlock` guarantees it fits (softly).
+	if err := e.closeSubBatch(); err != nil {
+		return nil, fmt.Errorf("failed to close sub-batch: %w", err)
+	}
+	if e.numSubBatches == 0 {
+		return nil, errBatchTooSmall
+	}
+	if err := e.compressor.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress batch: %w", err)
+	}
+	// Encode version.
+	batch := make([]byte, 0, 1+e.buf.Len())
+	batch = append(batch, e.getVersion())
+	batch = append(batch, e.buf.Bytes()...)
+	log.Info("Encoded V2 batch", "num_sub_batches", e.numSubBatches, "compressed_size", len(batch))
+	// Start a new batch.
+	e.Reset()
+	return batch, nil
+}
+
+// Processes a block, adding it to the current sub-batch.
+// If the block belongs to a new epoch (or its L1 origin otherwise differs), close the current sub-batch and start a new one.
+func (e *BatchV2Encoder) ProcessBlock(block *types.Block, l1Origin spTypes.BlockID, isNewEpoch bool) error {
+	var (
+		// Batch would exceed the target size with the current sub-batch.
+		shouldCloseBatch = e.shouldCloseBatch()
+		// Should close sub-batch if we're closing the batch entirely, OR...
+		// the block belongs to a new epoch.
+		shouldCloseSubBatch = shouldCloseBatch || isNewEpoch || !e.currSubBatch.hasL1Origin(l1Origin)
+	)
+	if shouldCloseSubBatch {
+		if err := e.closeSubBatch(); err != nil {
+			return fmt.Errorf("failed to close sub-batch: %w", err)
+		}
+	}
+	// Enforce soft cap on batch size.
+	if shouldCloseBatch {
+		return errBatchFull
+	}
+	// Append the block to the current sub-batch.
+	if err := e.currSubBatch.appendBlock(block, l1Origin); err != nil {
+		return fmt.Errorf("could not append block: %w", err)
+	}
+	return nil
+}
+
+func (e *BatchV2Encoder) Reset() {
+	e.currSubBatch = &subBatchV2{}
+	e.numSubBatches = 0
+	e.buf = bytes.NewBuffer(nil)
+	e.bufZeroBytes = 0
+	e.compressor = zlib.NewWriter(e.buf)
+}
+
+// Returns the data format version (v2).
+func (e *BatchV2Encoder) getVersion() BatchEncoderVersion { return V2 }
+
+// Returns the measure (per the sizing policy) of all closed sub-batches compressed, plus the current one uncompressed.
+func (e *BatchV2Encoder) estimatedSize() uint64 {
+	return e.policy.Measure(uint64(e.buf.Len())+e.currSubBatch.size(), e.bufZeroBytes+e.currSubBatch.zeroBytes)
+}
+
+func (e *BatchV2Encoder) shouldCloseBatch() bool {
+	return e.estimatedSize() > e.policy.Target()
+}
+
+// Closes the current sub-batch, compressing it into the batch buffer.
+func (e *BatchV2Encoder) closeSubBatch() error {
+	// No need to close if it's empty.
+	if len(e.currSubBatch.Blocks) == 0 {
+		return nil
+	}
+	log.Info("Closing sub-batch...")
+	prevLen := e.buf.Len()
+	if err := rlp.Encode(e.compressor, e.currSubBatch); err != nil {
+		return fmt.Errorf("failed to encode sub-batch: %w", err)
+	}
+	// Flush so that the buffer reflects the compressed size of all closed sub-batches.
+	if err := e.compressor.Flush(); err != nil {
+		return fmt.Errorf("failed to flush compressor: %w", err)
+	}
+	e.bufZeroBytes += countZeroBytes(e.buf.Bytes()[prevLen:])
+	e.numSubBatches += 1
+	e.currSubBatch = &subBatchV2{}
+	return nil
+}
+
+// A run of consecutive L2 blocks sharing the same L1 origin.
+// The L1 origin is empty if it was unknown to the encoder (i.e. the epoch continues from the previous sub-batch).
+// Note: fields must be exported to be RLP-encoded.
+type subBatchV2 struct {
+	L1OriginNum     uint64
+	L1OriginHash    common.Hash
+	FirstL2BlockNum uint64
+	Blocks          []txBlockV2
+	contentSize     uint64 // size of sub-batch content (# of bytes)
+	zeroBytes       uint64 // # of zero bytes in sub-batch content
+}
+
+type txBlockV2 struct {
+	Timestamp uint64
+	Txs       rawTxBlock
+}
+
+func (s *subBatchV2) size() uint64 { return rlp.ListSize(s.contentSize) }
+
+func (s *subBatchV2) l1Origin() spTypes.BlockID {
+	return spTypes.NewBlockID(s.L1OriginNum, s.L1OriginHash)
+}
+
+// Returns true if the sub-batch is empty or has the given L1 origin.
+func (s *subBatchV2) hasL1Origin(l1Origin spTypes.BlockID) bool {
+	return len(s.Blocks) == 0 || s.l1Origin() == l1Origin
+}
+
+func (s *subBatchV2) appendBlock(block *types.Block, l1Origin spTypes.BlockID) error {
+	// Set the sub-batch header if it hasn't been set yet.
+	if len(s.Blocks) == 0 {
+		s.L1OriginNum = l1Origin.GetNumber()
+		s.L1OriginHash = l1Origin.GetHash()
+		s.FirstL2BlockNum = block.NumberU64()
+		s.contentSize += uint64(rlp.IntSize(s.L1OriginNum) + 1 + common.HashLength + rlp.IntSize(s.FirstL2BlockNum))
+	}
+	marshalled, numBytes, err := marshallTxs(block.Transactions())
+	if err != nil {
+		return fmt.Errorf("could not marshall txs: %w", err)
+	}
+	s.Blocks = append(s.Blocks, txBlockV2{Timestamp: block.Time(), Txs: marshalled})
+	s.contentSize += uint64(rlp.IntSize(block.Time()) + numBytes)
+	s.zeroBytes += marshalled.countZeroBytes()
+	return nil
+}
+
+func decodeV2(data []byte) ([]subBatchV2, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// Allow reading one byte past the max size, to tell a batch cut off at the limit from one that ends there.
+	var (
+		limited = &io.LimitedReader{R: r, N: maxDecompressedBatchSize + 1}
+		stream  = rlp.NewStream(limited, 0)
+		decoded []subBatchV2
+	)
+	for {
+		var sb subBatchV2
+		if err := stream.Decode(&sb); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		decoded = append(decoded, sb)
+	}
+	if limited.N == 0 {
+		return nil, errBatchTooLarge
+	}
+	return decoded, nil
+}
@@ -0,0 +1,241 @@
+package derivation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+func testL2BlockAt(num, timestamp uint64, txs ethTypes.Transactions) *ethTypes.Block {
+	header := &ethTypes.Header{Number: new(big.Int).SetUint64(num), Time: timestamp}
+	return ethTypes.NewBlockWithHeader(header).WithBody(txs, nil)
+}
+
+// Returns a V2 sub-batch of consecutive L2 blocks (with the given timestamps and txs) starting at `firstL2BlockNum`.
+func testSubBatchV2(
+	t *testing.T,
+	l1Origin types.BlockID,
+	firstL2BlockNum uint64,
+	timestamps []uint64,
+	blocks ...ethTypes.Transactions,
+) subBatchV2 {
+	t.Helper()
+	sb := subBatchV2{L1OriginNum: l1Origin.GetNumber(), L1OriginHash: l1Origin.GetHash(), FirstL2BlockNum: firstL2BlockNum}
+	for i, txs := range blocks {
+		raw, _, err := marshallTxs(txs)
+		if err != nil {
+			t.Fatalf("failed to marshall txs: %v", err)
+		}
+		sb.Blocks = append(sb.Blocks, txBlockV2{Timestamp: timestamps[i], Txs: raw})
+	}
+	return sb
+}
+
+func TestBatchV2EncoderRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		txs     = testL2Txs(t, key, 0, 3)
+		originA = types.NewBlockID(5, common.Hash{0xa})
+		originB = types.NewBlockID(6, common.Hash{0xb})
+		e       = NewBatchV2Encoder(byteSizingPolicy{10_000})
+	)
+	blocks := []struct {
+		block      *ethTypes.Block
+		l1Origin   types.BlockID
+		isNewEpoch bool
+	}{
+		{testL2BlockAt(1, 101, txs[:2]), originA, false},
+		// Empty blocks are encoded too.
+		{testL2BlockAt(2, 102, nil), originA, false},
+		{testL2BlockAt(3, 104, txs[2:]), originB, true},
+	}
+	for _, b := range blocks {
+		if err := e.ProcessBlock(b.block, b.l1Origin, b.isNewEpoch); err != nil {
+			t.Fatalf("block %d: failed to process: %v", b.block.NumberU64(), err)
+		}
+	}
+	batch, err := e.GetBatch(true)
+	if err != nil {
+		t.Fatalf("failed to get batch: %v", err)
+	}
+	if batch[0] != V2 {
+		t.Fatalf("got version %d, want %d", batch[0], V2)
+	}
+	decoded, err := decodeV2(batch[1:])
+	if err != nil {
+		t.Fatalf("failed to decode batch: %v", err)
+	}
+	want := []subBatchV2{
+		testSubBatchV2(t, originA, 1, []uint64{101, 102}, txs[:2], nil),
+		testSubBatchV2(t, originB, 3, []uint64{104}, txs[2:]),
+	}
+	if len(decoded) != len(want) {
+		t.Fatalf("decoded %d sub-batches, want %d", len(decoded), len(want))
+	}
+	for i := range want {
+		if decoded[i].l1Origin() != want[i].l1Origin() || decoded[i].FirstL2BlockNum != want[i].FirstL2BlockNum {
+			t.Errorf("sub-batch %d: got origin %s from block %d, want origin %s from block %d",
+				i, decoded[i].l1Origin(), decoded[i].FirstL2BlockNum, want[i].l1Origin(), want[i].FirstL2BlockNum)
+		}
+		if len(decoded[i].Blocks) != len(want[i].Blocks) {
+			t.Fatalf("sub-batch %d: decoded %d blocks, want %d", i, len(decoded[i].Blocks), len(want[i].Blocks))
+		}
+		for j := range want[i].Blocks {
+			got, _ := rlp.EncodeToBytes(decoded[i].Blocks[j])
+			wantEnc, _ := rlp.EncodeToBytes(want[i].Blocks[j])
+			if !bytes.Equal(got, wantEnc) {
+				t.Errorf("sub-batch %d, block %d: decoded %+v, want %+v", i, j, decoded[i].Blocks[j], want[i].Blocks[j])
+			}
+		}
+	}
+}
+
+// Returns a V2 sub-batch whose RLP encoding is exactly `size` bytes.
+func subBatchV2OfSize(t *testing.T, firstL2BlockNum uint64, size int) subBatchV2 {
+	t.Helper()
+	// The encoding overhead is constant for payloads of similar size, so measure it once and adjust.
+	sb := subBatchV2{FirstL2BlockNum: firstL2BlockNum, Blocks: []txBlockV2{{Txs: rawTxBlock{make(hexutil.Bytes, size)}}}}
+	encoded, err := rlp.EncodeToBytes(sb)
+	if err != nil {
+		t.Fatalf("failed to encode sub-batch: %v", err)
+	}
+	sb.Blocks[0].Txs[0] = make(hexutil.Bytes, 2*size-len(encoded))
+	if encoded, _ = rlp.EncodeToBytes(sb); len(encoded) != size {
+		t.Fatalf("sub-batch size %d, want %d", len(encoded), size)
+	}
+	return sb
+}
+
+func TestDecodeV2Limit(t *testing.T) {
+	const subBatchSize = 1024 * 1024
+	tests := []struct {
+		name          string
+		numSubBatches int
+		wantErr       error
+	}{
+		{"at max size", maxDecompressedBatchSize / subBatchSize, nil},
+		// The limit falls exactly on a sub-batch boundary, so decoding would otherwise succeed.
+		{"one sub-batch past max size", maxDecompressedBatchSize/subBatchSize + 1, errBatchTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				buf = bytes.NewBuffer(nil)
+				w   = zlib.NewWriter(buf)
+			)
+			for i := 0; i < tt.numSubBatches; i++ {
+				if err := rlp.Encode(w, subBatchV2OfSize(t, uint64(i+1), subBatchSize)); err != nil {
+					t.Fatalf("failed to encode sub-batch: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("failed to compress: %v", err)
+			}
+			decoded, err := decodeV2(buf.Bytes())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(decoded) != tt.numSubBatches {
+				t.Errorf("decoded %d sub-batches, want %d", len(decoded), tt.numSubBatches)
+			}
+		})
+	}
+}
+
+func TestDeriveFromSubBatchesV2(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		txs = testL2Txs(t, key, 0, 2)
+		l1  = newTestL1Client()
+		// The current epoch, a later canonical L1 block, and the L1 block the batch is appended in.
+		epoch     = l1.addBlock(1)
+		origin    = l1.addBlock(2)
+		inclusion = l1.addBlock(3)
+		epochID   = types.NewBlockID(epoch.NumberU64(), epoch.Hash())
+		originID  = types.NewBlockID(origin.NumberU64(), origin.Hash())
+	)
+	const (
+		lastL2BlockNum = 1
+		lastL2Time     = 100
+	)
+	tests := []struct {
+		name       string
+		subBatches []subBatchV2
+		wantNums   []uint64
+		wantTxs    []ethTypes.Transactions
+		wantEpoch  types.BlockID
+	}{
+		{
+			name: "valid",
+			subBatches: []subBatchV2{
+				testSubBatchV2(t, types.EmptyBlockID, 2, []uint64{101}, txs[:1]),
+				testSubBatchV2(t, originID, 3, []uint64{102, 104}, nil, txs[1:]),
+			},
+			wantNums:  []uint64{2, 3, 4},
+			wantTxs:   []ethTypes.Transactions{txs[:1], nil, txs[1:]},
+			wantEpoch: originID,
+		},
+		{
+			name:       "non-contiguous blocks",
+			subBatches: []subBatchV2{testSubBatchV2(t, types.EmptyBlockID, 3, []uint64{101}, txs[:1])},
+		},
+		{
+			name:       "repeated timestamp",
+			subBatches: []subBatchV2{testSubBatchV2(t, types.EmptyBlockID, 2, []uint64{101, 101}, txs[:1], nil)},
+		},
+		{
+			name:       "timestamp not after the last derived block",
+			subBatches: []subBatchV2{testSubBatchV2(t, types.EmptyBlockID, 2, []uint64{lastL2Time}, txs[:1])},
+		},
+		{
+			name:       "non-canonical L1 origin",
+			subBatches: []subBatchV2{testSubBatchV2(t, types.NewBlockID(2, common.Hash{0xff}), 2, []uint64{101}, txs[:1])},
+		},
+		{
+			name: "L1 origin not before the inclusion block",
+			subBatches: []subBatchV2{
+				testSubBatchV2(t, types.NewBlockID(inclusion.NumberU64(), inclusion.Hash()), 2, []uint64{101}, txs[:1]),
+			},
+		},
+		{
+			name:       "L1 origin before the current epoch",
+			subBatches: []subBatchV2{testSubBatchV2(t, types.NewBlockID(0, common.Hash{0x1}), 2, []uint64{101}, txs[:1])},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPipeline(t, l1, nil, nil, lastL2BlockNum)
+			p.lastL2Time, p.l1Epoch, p.l1EpochHash = lastL2Time, epochID.GetNumber(), epochID.GetHash()
+			inclusionID := types.NewBlockID(inclusion.NumberU64(), inclusion.Hash())
+			attrs, err := p.deriveFromSubBatchesV2(context.Background(), tt.subBatches, inclusionID)
+			if tt.wantNums == nil {
+				var invalidErr InvalidBatchError
+				if !errors.As(err, &invalidErr) {
+					t.Fatalf("got error %v, want an invalid batch error", err)
+				}
+				// Invalid batches must not advance the pipeline.
+				if p.LastL2BlockNum() != lastL2BlockNum || p.lastL2Time != lastL2Time || p.l1EpochHash != epochID.GetHash() {
+					t.Errorf("pipeline advanced past invalid batch")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to derive: %v", err)
+			}
+			checkDerived(t, attrs, tt.wantNums, tt.wantTxs)
+			if got := types.NewBlockID(p.l1Epoch, p.l1EpochHash); got != tt.wantEpoch {
+				t.Errorf("got epoch %s, want %s", got, tt.wantEpoch)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package derivation
+
+import (
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+// An encoder/decoder pair for a batch format version.
+type batchFormat struct {
+	newEncoder func(policy SizingPolicy) VersionedDataEncoder
+	decode     func(data []byte) (interface{}, error)
+}
+
+// All supported batch formats, keyed by version.
+var batchFormats = map[BatchEncoderVersion]batchFormat{
+	V0: {
+		newEncoder: func(policy SizingPolicy) VersionedDataEncoder { return NewBatchV0Encoder(policy) },
+		decode:     func(data []byte) (interface{}, error) { return decodeV0(data) },
+	},
+	V1: {
+		newEncoder: func(policy SizingPolicy) VersionedDataEncoder { return NewBatchV1Encoder(policy) },
+		decode:     func(data []byte) (interface{}, error) { return decodeV1(data) },
+	},
+	V2: {
+		newEncoder: func(policy SizingPolicy) VersionedDataEncoder { return NewBatchV2Encoder(policy) },
+		decode:     func(data []byte) (interface{}, error) { return decodeV2(data) },
+	},
+}
+
+// Returns true if the batch format version is supported.
+func IsKnownVersion(version BatchEncoderVersion) bool {
+	_, ok := batchFormats[version]
+	return ok
+}
+
+// Maps batch format versions to their L1 activation heights.
+// A version may only be used in batches appended in L1 blocks at or after its activation height.
+// V0 is active from genesis unless configured otherwise.
+type BatchVersionRegistry struct {
+	activations map[BatchEncoderVersion]uint64
+}
+
+func NewBatchVersionRegistry(activations map[BatchEncoderVersion]uint64) (*BatchVersionRegistry, error) {
+	r := &BatchVersionRegistry{activations: map[BatchEncoderVersion]uint64{V0: 0}}
+	for version, height := range activations {
+		if !IsKnownVersion(version) {
+			return nil, fmt.Errorf("unknown batch version: %d", version)
+		}
+		r.activations[version] = height
+	}
+	return r, nil
+}
+
+// Returns true if the version is active at the given L1 block number.
+func (r *BatchVersionRegistry) IsActive(version BatchEncoderVersion, l1BlockNum uint64) bool {
+	height, ok := r.activations[version]
+	return ok && l1BlockNum >= height
+}
+
+// Returns the latest version (no later than `maxVersion`) that is active at the given L1 block number.
+func (r *BatchVersionRegistry) LatestActiveVersion(
+	maxVersion BatchEncoderVersion,
+	l1BlockNum uint64,
+) (BatchEncoderVersion, error) {
+	var (
+		latest BatchEncoderVersion
+		found  bool
+	)
+	for version := range r.activations {
+		if version <= maxVersion && r.IsActive(version, l1BlockNum) && (!found || version > latest) {
+			latest, found = version, true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no batch version <= %d active at L1 block %d", maxVersion, l1BlockNum)
+	}
+	return latest, nil
+}
+
+// Creates a new encoder for the given version.
+func (r *BatchVersionRegistry) NewEncoder(version BatchEncoderVersion, policy SizingPolicy) (VersionedDataEncoder, error) {
+	format, ok := batchFormats[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown batch version: %d", version)
+	}
+	return format.newEncoder(policy), nil
+}
+
+// Decodes versioned batch data appended in the given L1 block.
+// Returns a `DecodeTxBatchError` if the version is unknown or not active at that block.
+func (r *BatchVersionRegistry) Decode(data []byte, l1BlockNum uint64) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, &DecodeTxBatchError{"empty batch data"}
+	}
+	if !r.IsActive(data[0], l1BlockNum) {
+		return nil, &DecodeTxBatchError{fmt.Sprintf("batch version %d not active at L1 block %d", data[0], l1BlockNum)}
+	}
+	return DecodeBatch(data)
+}
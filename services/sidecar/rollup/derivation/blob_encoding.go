@@ -0,0 +1,70 @@
+package derivation
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+const (
+	fieldElementsPerBlob = 4096
+	bytesPerFieldElement = 32
+	// The first byte of each field element is left empty, so it is always less than the BLS modulus.
+	usableBytesPerFieldElement = bytesPerFieldElement - 1
+	// Number of bytes used to encode the length of the data.
+	blobLengthPrefixSize = 4
+	// Max number of data bytes that can be stored in a single blob.
+	BlobCapacity = fieldElementsPerBlob * usableBytesPerFieldElement
+	// Max number of blobs that fit in a single L1 block.
+	MaxBlobsPerTx = params.MaxBlobGasPerBlock / params.BlobTxBlobGasPerBlob
+)
+
+// Returns the number of blobs required to encode data of length `dataLen`.
+func NumBlobs(dataLen int) int {
+	return (dataLen + blobLengthPrefixSize + BlobCapacity - 1) / BlobCapacity
+}
+
+// Encodes data into blobs, as: uint32(len(data)) || data, spread across the usable bytes of each field element.
+func EncodeBlobs(data []byte) ([]kzg4844.Blob, error) {
+	numBlobs := NumBlobs(len(data))
+	if numBlobs > MaxBlobsPerTx {
+		return nil, fmt.Errorf("data too large to fit in blobs (len=%d, max=%d)", len(data), MaxBlobsPerTx*BlobCapacity-blobLengthPrefixSize)
+	}
+	stream := make([]byte, blobLengthPrefixSize, blobLengthPrefixSize+len(data))
+	binary.BigEndian.PutUint32(stream, uint32(len(data)))
+	stream = append(stream, data...)
+	blobs := make([]kzg4844.Blob, numBlobs)
+	for i := range blobs {
+		for fe := 0; fe < fieldElementsPerBlob && len(stream) > 0; fe++ {
+			offset := fe*bytesPerFieldElement + 1
+			n := copy(blobs[i][offset:offset+usableBytesPerFieldElement], stream)
+			stream = stream[n:]
+		}
+	}
+	return blobs, nil
+}
+
+// Decodes data from blobs encoded by `EncodeBlobs`.
+func DecodeBlobs(blobs []kzg4844.Blob) ([]byte, error) {
+	stream := make([]byte, 0, len(blobs)*BlobCapacity)
+	for i := range blobs {
+		for fe := 0; fe < fieldElementsPerBlob; fe++ {
+			offset := fe * bytesPerFieldElement
+			if blobs[i][offset] != 0 {
+				return nil, fmt.Errorf("invalid field element %d in blob %d", fe, i)
+			}
+			stream = append(stream, blobs[i][offset+1:offset+bytesPerFieldElement]...)
+		}
+	}
+	if len(stream) < blobLengthPrefixSize {
+		return nil, fmt.Errorf("missing length prefix")
+	}
+	dataLen := uint64(binary.BigEndian.Uint32(stream))
+	stream = stream[blobLengthPrefixSize:]
+	if dataLen > uint64(len(stream)) {
+		return nil, fmt.Errorf("invalid data length: %d (max=%d)", dataLen, len(stream))
+	}
+	return stream[:dataLen], nil
+}
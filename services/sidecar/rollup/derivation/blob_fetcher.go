@@ -0,0 +1,177 @@
+package derivation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+var errNoBlobFetcher = errors.New("found blob batch, but no L1 beacon endpoint is configured")
+
+// Retrieves the blobs carried by L1 blob txs.
+type BlobFetcher interface {
+	// Returns the blobs with the given versioned hashes (in order), included in the L1 block with the given header.
+	GetBlobs(ctx context.Context, l1Header *ethTypes.Header, hashes []common.Hash) ([]kzg4844.Blob, error)
+}
+
+// Returns the part of blob-carried data that's passed as calldata alongside the blobs
+// (i.e. what the inbox sees): the frame header for frames, or the version byte otherwise.
+func BlobHeader(data []byte) []byte {
+	n := 1
+	if IsFrame(data) {
+		n = FrameHeaderSize
+	}
+	if n > len(data) {
+		n = len(data)
+	}
+	return data[:n]
+}
+
+// Returns the data carried in the blobs of a blob tx, checking it against the header passed as calldata.
+// Returns an `InvalidBatchError` if the blobs don't hold validly-encoded data with that header.
+func fetchBlobData(
+	ctx context.Context,
+	fetcher BlobFetcher,
+	l1Header *ethTypes.Header,
+	tx *ethTypes.Transaction,
+	header []byte,
+) ([]byte, error) {
+	if fetcher == nil {
+		return nil, errNoBlobFetcher
+	}
+	blobs, err := fetcher.GetBlobs(ctx, l1Header, tx.BlobHashes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blobs: %w", err)
+	}
+	data, err := DecodeBlobs(blobs)
+	if err != nil {
+		return nil, InvalidBatchError{fmt.Sprintf("invalid blob encoding: %s", err)}
+	}
+	if !bytes.Equal(BlobHeader(data), header) {
+		return nil, InvalidBatchError{fmt.Sprintf("blob data header %x does not match calldata %x", BlobHeader(data), header)}
+	}
+	return data, nil
+}
+
+// Fetches blobs from an L1 beacon node (over the standard beacon API).
+// Blobs are checked against their KZG commitments and proofs, so the node needn't be trusted.
+type BeaconBlobFetcher struct {
+	url    string
+	client *http.Client
+
+	mu             sync.Mutex
+	genesisTime    uint64 // Fetched on first use.
+	secondsPerSlot uint64 // Fetched on first use.
+}
+
+func NewBeaconBlobFetcher(url string) *BeaconBlobFetcher {
+	return &BeaconBlobFetcher{url: strings.TrimSuffix(url, "/"), client: &http.Client{}}
+}
+
+type beaconBlobSidecar struct {
+	Blob          kzg4844.Blob       `json:"blob"`
+	KZGCommitment kzg4844.Commitment `json:"kzg_commitment"`
+	KZGProof      kzg4844.Proof      `json:"kzg_proof"`
+}
+
+func (f *BeaconBlobFetcher) GetBlobs(
+	ctx context.Context,
+	l1Header *ethTypes.Header,
+	hashes []common.Hash,
+) ([]kzg4844.Blob, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	slot, err := f.slot(ctx, l1Header.Time)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data []beaconBlobSidecar `json:"data"`
+	}
+	if err := f.get(ctx, fmt.Sprintf("/eth/v1/beacon/blob_sidecars/%d", slot), &resp); err != nil {
+		return nil, fmt.Errorf("failed to get blob sidecars (slot=%d): %w", slot, err)
+	}
+	sidecars := make(map[common.Hash]*beaconBlobSidecar, len(resp.Data))
+	for i := range resp.Data {
+		sidecar := &resp.Data[i]
+		sidecars[kzg4844.CalcBlobHashV1(sha256.New(), &sidecar.KZGCommitment)] = sidecar
+	}
+	blobs := make([]kzg4844.Blob, len(hashes))
+	for i, hash := range hashes {
+		sidecar, ok := sidecars[hash]
+		if !ok {
+			return nil, fmt.Errorf("blob not found (slot=%d, hash=%s)", slot, hash)
+		}
+		if err := kzg4844.VerifyBlobProof(sidecar.Blob, sidecar.KZGCommitment, sidecar.KZGProof); err != nil {
+			return nil, fmt.Errorf("invalid blob proof (slot=%d, hash=%s): %w", slot, hash, err)
+		}
+		blobs[i] = sidecar.Blob
+	}
+	return blobs, nil
+}
+
+// Returns the beacon slot of the L1 block with the given timestamp.
+func (f *BeaconBlobFetcher) slot(ctx context.Context, time uint64) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.secondsPerSlot == 0 {
+		var genesis struct {
+			Data struct {
+				GenesisTime string `json:"genesis_time"`
+			} `json:"data"`
+		}
+		if err := f.get(ctx, "/eth/v1/beacon/genesis", &genesis); err != nil {
+			return 0, fmt.Errorf("failed to get beacon genesis: %w", err)
+		}
+		var spec struct {
+			Data struct {
+				SecondsPerSlot string `json:"SECONDS_PER_SLOT"`
+			} `json:"data"`
+		}
+		if err := f.get(ctx, "/eth/v1/config/spec", &spec); err != nil {
+			return 0, fmt.Errorf("failed to get beacon spec: %w", err)
+		}
+		genesisTime, err := strconv.ParseUint(genesis.Data.GenesisTime, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid beacon genesis time: %w", err)
+		}
+		secondsPerSlot, err := strconv.ParseUint(spec.Data.SecondsPerSlot, 10, 64)
+		if err != nil || secondsPerSlot == 0 {
+			return 0, fmt.Errorf("invalid beacon seconds per slot: %q", spec.Data.SecondsPerSlot)
+		}
+		f.genesisTime, f.secondsPerSlot = genesisTime, secondsPerSlot
+	}
+	if time < f.genesisTime {
+		return 0, fmt.Errorf("L1 block time %d precedes beacon genesis %d", time, f.genesisTime)
+	}
+	return (time - f.genesisTime) / f.secondsPerSlot, nil
+}
+
+func (f *BeaconBlobFetcher) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,321 @@
+package derivation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/holiman/uint256"
+)
+
+const testSecondsPerSlot = 12 // Matches the L1 block times of `testL1Client`, so slot == L1 block number.
+
+// A beacon node stand-in, serving blob sidecars by slot.
+type testBeacon struct {
+	sidecars map[uint64][]beaconBlobSidecar
+	server   *httptest.Server
+}
+
+func newTestBeacon(t *testing.T) *testBeacon {
+	t.Helper()
+	b := &testBeacon{sidecars: map[uint64][]beaconBlobSidecar{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/genesis", func(w http.ResponseWriter, _ *http.Request) {
+		writeTestJSON(w, map[string]any{"genesis_time": "0"})
+	})
+	mux.HandleFunc("/eth/v1/config/spec", func(w http.ResponseWriter, _ *http.Request) {
+		writeTestJSON(w, map[string]any{"SECONDS_PER_SLOT": strconv.Itoa(testSecondsPerSlot)})
+	})
+	mux.HandleFunc("/eth/v1/beacon/blob_sidecars/", func(w http.ResponseWriter, r *http.Request) {
+		slot, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/blob_sidecars/"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTestJSON(w, b.sidecars[slot])
+	})
+	b.server = httptest.NewServer(mux)
+	t.Cleanup(b.server.Close)
+	return b
+}
+
+func writeTestJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// Returns the sidecars (with KZG commitments and proofs) of the given blobs, and their versioned hashes.
+func testBlobSidecars(t *testing.T, blobs []kzg4844.Blob) ([]beaconBlobSidecar, []common.Hash) {
+	t.Helper()
+	var (
+		sidecars []beaconBlobSidecar
+		hashes   []common.Hash
+	)
+	for _, blob := range blobs {
+		commitment, err := kzg4844.BlobToCommitment(blob)
+		if err != nil {
+			t.Fatalf("failed to compute commitment: %v", err)
+		}
+		proof, err := kzg4844.ComputeBlobProof(blob, commitment)
+		if err != nil {
+			t.Fatalf("failed to compute proof: %v", err)
+		}
+		sidecars = append(sidecars, beaconBlobSidecar{Blob: blob, KZGCommitment: commitment, KZGProof: proof})
+		hashes = append(hashes, kzg4844.CalcBlobHashV1(sha256.New(), &commitment))
+	}
+	return sidecars, hashes
+}
+
+// Returns an `appendTxBatch` blob tx carrying `blobs`, with `header` as calldata, sent by the batcher.
+// Its sidecars are served by the beacon at slot `l1BlockNum`.
+func (c *testL1Client) blobBatchTx(
+	t *testing.T,
+	beacon *testBeacon,
+	l1BlockNum uint64,
+	header []byte,
+	blobs []kzg4844.Blob,
+) *ethTypes.Transaction {
+	t.Helper()
+	sidecars, hashes := testBlobSidecars(t, blobs)
+	beacon.sidecars[l1BlockNum] = append(beacon.sidecars[l1BlockNum], sidecars...)
+	c.nonce++
+	return signBatchTx(t, &ethTypes.BlobTx{
+		ChainID:    uint256.MustFromBig(testChainID),
+		Nonce:      c.nonce,
+		GasTipCap:  new(uint256.Int),
+		GasFeeCap:  new(uint256.Int),
+		To:         testInboxAddr,
+		Value:      new(uint256.Int),
+		Data:       packAppendTxBatch(t, header),
+		BlobFeeCap: new(uint256.Int),
+		BlobHashes: hashes,
+	})
+}
+
+func TestBlobHeader(t *testing.T) {
+	frame := (&Frame{FrameNum: 1, Data: []byte{1, 2, 3}}).Marshal()
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"unframed batch", []byte{V0, 1, 2, 3}, []byte{V0}},
+		{"frame", frame, frame[:FrameHeaderSize]},
+		{"DA commitment", []byte{DACommitmentV0, 1, 2}, []byte{DACommitmentV0}},
+		{"empty", nil, []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BlobHeader(tt.data); string(got) != string(tt.want) {
+				t.Errorf("got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeaconBlobFetcher(t *testing.T) {
+	var (
+		beacon               = newTestBeacon(t)
+		blobs, _             = EncodeBlobs(make([]byte, BlobCapacity+1))
+		sidecars, hashes     = testBlobSidecars(t, blobs)
+		unrelatedSidecars, _ = testBlobSidecars(t, []kzg4844.Blob{{}})
+		tamperedSidecar      = sidecars[0]
+	)
+	// Serve the sidecars out of order, along with unrelated ones.
+	beacon.sidecars[3] = append(unrelatedSidecars, sidecars[1], sidecars[0])
+	tamperedSidecar.Blob[1] = 0xff
+	beacon.sidecars[4] = []beaconBlobSidecar{tamperedSidecar}
+
+	tests := []struct {
+		name    string
+		time    uint64
+		hashes  []common.Hash
+		want    []kzg4844.Blob
+		wantErr bool
+	}{
+		{name: "returns blobs in order", time: 3 * testSecondsPerSlot, hashes: hashes, want: blobs},
+		{name: "maps time within slot", time: 3*testSecondsPerSlot + 5, hashes: hashes[1:], want: blobs[1:]},
+		{name: "no hashes", time: 0, hashes: nil},
+		{name: "missing blob", time: 3 * testSecondsPerSlot, hashes: []common.Hash{crypto.Keccak256Hash()}, wantErr: true},
+		{name: "blob not matching commitment", time: 4 * testSecondsPerSlot, hashes: hashes[:1], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewBeaconBlobFetcher(beacon.server.URL + "/")
+			got, err := f.GetBlobs(context.Background(), &ethTypes.Header{Time: tt.time}, tt.hashes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d blobs, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("blob %d does not match", i)
+				}
+			}
+		})
+	}
+}
+
+func TestDeriveBlobBatches(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		txs        = testL2Txs(t, key, 0, 3)
+		batch      = testV0Batch(t, testSubBatch(t, 2, txs[:2], txs[2:]))
+		frames, _  = SplitIntoFrames(batch, uint64(len(batch)/2+FrameHeaderSize))
+		wantNums   = []uint64{2, 3}
+		wantTxs    = []ethTypes.Transactions{txs[:2], txs[2:]}
+		otherBatch = testV0Batch(t, testSubBatch(t, 2, txs[:1]))
+	)
+	if len(frames) != 2 {
+		t.Fatalf("split batch into %d frames, want 2", len(frames))
+	}
+	tests := []struct {
+		name string
+		data [][]byte // Data posted in blobs, one tx each.
+		// Modifies the header passed as calldata and the blobs of the first tx, before it's sent.
+		tamper   func(header []byte, blobs []kzg4844.Blob) []byte
+		wantNums []uint64
+		wantTxs  []ethTypes.Transactions
+	}{
+		{name: "derives unframed batch", data: [][]byte{batch}, wantNums: wantNums, wantTxs: wantTxs},
+		{
+			name:     "reassembles frames",
+			data:     [][]byte{frames[0].Marshal(), frames[1].Marshal()},
+			wantNums: wantNums,
+			wantTxs:  wantTxs,
+		},
+		{
+			name:     "skips blobs not matching calldata header",
+			data:     [][]byte{otherBatch, batch},
+			tamper:   func([]byte, []kzg4844.Blob) []byte { return []byte{V1} },
+			wantNums: wantNums,
+			wantTxs:  wantTxs,
+		},
+		{
+			name: "skips invalid blob encoding",
+			data: [][]byte{otherBatch, batch},
+			tamper: func(header []byte, blobs []kzg4844.Blob) []byte {
+				blobs[0][0] = 1 // Still a valid field element, but not a valid encoding.
+				return header
+			},
+			wantNums: wantNums,
+			wantTxs:  wantTxs,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				l1       = newTestL1Client()
+				beacon   = newTestBeacon(t)
+				batchTxs []*ethTypes.Transaction
+			)
+			for i, data := range tt.data {
+				// Post the data as the disseminator does.
+				blobs, err := EncodeBlobs(data)
+				if err != nil {
+					t.Fatalf("failed to encode blobs: %v", err)
+				}
+				header := BlobHeader(data)
+				if i == 0 && tt.tamper != nil {
+					header = tt.tamper(header, blobs)
+				}
+				batchTxs = append(batchTxs, l1.blobBatchTx(t, beacon, 1, header, blobs))
+			}
+			l1.addBlock(1, batchTxs...)
+			p := newTestPipeline(t, l1, NewBeaconBlobFetcher(beacon.server.URL), nil, 1)
+			attrs, err := p.Derive(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("failed to derive: %v", err)
+			}
+			checkDerived(t, attrs, tt.wantNums, tt.wantTxs)
+		})
+	}
+}
+
+func TestDeriveUnavailableBlobs(t *testing.T) {
+	batch := testV0Batch(t, testSubBatch(t, 2, nil))
+	blobs, err := EncodeBlobs(batch)
+	if err != nil {
+		t.Fatalf("failed to encode blobs: %v", err)
+	}
+	tests := []struct {
+		name    string
+		fetcher func(beacon *testBeacon) BlobFetcher
+		wantErr error
+	}{
+		{"no blob fetcher", func(*testBeacon) BlobFetcher { return nil }, errNoBlobFetcher},
+		{"blobs not served", func(b *testBeacon) BlobFetcher {
+			delete(b.sidecars, 1)
+			return NewBeaconBlobFetcher(b.server.URL)
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				l1     = newTestL1Client()
+				beacon = newTestBeacon(t)
+			)
+			l1.addBlock(1, l1.blobBatchTx(t, beacon, 1, BlobHeader(batch), blobs))
+			p := newTestPipeline(t, l1, tt.fetcher(beacon), nil, 1)
+			// The batch can't be skipped, since it may be valid: derivation must not advance.
+			_, err := p.Derive(context.Background(), 1)
+			if err == nil {
+				t.Fatal("derived from unavailable blobs")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if p.LastL2BlockNum() != 1 {
+				t.Errorf("last L2 block %d, want 1", p.LastL2BlockNum())
+			}
+		})
+	}
+}
+
+func TestLastPostedL2BlockNumBlobs(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		txs    = testL2Txs(t, key, 0, 2)
+		l1     = newTestL1Client()
+		beacon = newTestBeacon(t)
+	)
+	calldataBatch := testV0Batch(t, testSubBatch(t, 2, txs[:1]))
+	blobBatch := testV0Batch(t, testSubBatch(t, 3, txs[1:]))
+	invalidBatch := testV0Batch(t, testSubBatch(t, 4, nil))
+	blobs, _ := EncodeBlobs(blobBatch)
+	invalidBlobs, _ := EncodeBlobs(invalidBatch)
+	l1.addBlock(1, l1.batchTx(t, calldataBatch))
+	l1.addBlock(2, l1.blobBatchTx(t, beacon, 2, BlobHeader(blobBatch), blobs))
+	// Blob data not matching its calldata header is skipped.
+	l1.addBlock(3, l1.blobBatchTx(t, beacon, 3, []byte{V1}, invalidBlobs))
+
+	registry, err := NewBatchVersionRegistry(nil)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	s, err := NewInboxScanner(
+		testPipelineConfig{}, l1, NewBeaconBlobFetcher(beacon.server.URL), registry, inboxDAProvider{}, testBatcherAddr,
+	)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	last, found, err := s.LastPostedL2BlockNum(context.Background())
+	if err != nil {
+		t.Fatalf("failed to scan inbox: %v", err)
+	}
+	if !found || last != 3 {
+		t.Errorf("got last posted block %d (found: %v), want 3", last, found)
+	}
+}
@@ -0,0 +1,87 @@
+package derivation
+
+import (
+	"bytes"
+
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// A (partially) received channel.
+type channel struct {
+	frames       map[uint16][]byte
+	lastFrameNum *uint16 // nil until the last frame is received
+	openedAt     uint64  // L1 block number in which the first frame was received
+}
+
+func (c *channel) isComplete() bool {
+	return c.lastFrameNum != nil && len(c.frames) == int(*c.lastFrameNum)+1
+}
+
+func (c *channel) assemble() []byte {
+	var buf bytes.Buffer
+	for i := 0; i <= int(*c.lastFrameNum); i++ {
+		buf.Write(c.frames[uint16(i)])
+	}
+	return buf.Bytes()
+}
+
+// Reassembles batches from frames. Channels that aren't completed within `timeout` L1 blocks are dropped.
+type channelBank struct {
+	timeout  uint64
+	channels map[ChannelID]*channel
+}
+
+func newChannelBank(timeout uint64) *channelBank {
+	return &channelBank{timeout: timeout, channels: map[ChannelID]*channel{}}
+}
+
+// Adds a frame received in L1 block `l1BlockNum`.
+// Returns the assembled batch data if the frame completes its channel, or nil otherwise.
+func (b *channelBank) addFrame(frame Frame, l1BlockNum uint64) ([]byte, error) {
+	ch, ok := b.channels[frame.ChannelID]
+	if !ok {
+		ch = &channel{frames: map[uint16][]byte{}, openedAt: l1BlockNum}
+		b.channels[frame.ChannelID] = ch
+	}
+	if _, ok := ch.frames[frame.FrameNum]; ok {
+		log.Warn("Ignoring duplicate frame", "channel", frame.ChannelID, "frame#", frame.FrameNum)
+		return nil, nil
+	}
+	if frame.IsLast {
+		if ch.lastFrameNum != nil {
+			return nil, fmt.Errorf("channel %s has two last frames: %d and %d", frame.ChannelID, *ch.lastFrameNum, frame.FrameNum)
+		}
+		for num := range ch.frames {
+			if num > frame.FrameNum {
+				return nil, fmt.Errorf("frame %d of channel %s is past its last frame %d", num, frame.ChannelID, frame.FrameNum)
+			}
+		}
+		lastFrameNum := frame.FrameNum
+		ch.lastFrameNum = &lastFrameNum
+	}
+	if ch.lastFrameNum != nil && frame.FrameNum > *ch.lastFrameNum {
+		return nil, fmt.Errorf("frame %d of channel %s is past its last frame %d", frame.FrameNum, frame.ChannelID, *ch.lastFrameNum)
+	}
+	ch.frames[frame.FrameNum] = frame.Data
+	if !ch.isComplete() {
+		return nil, nil
+	}
+	delete(b.channels, frame.ChannelID)
+	log.Info("Reassembled channel", "channel", frame.ChannelID, "num_frames", len(ch.frames))
+	return ch.assemble(), nil
+}
+
+// Drops channels that timed out as of L1 block `l1BlockNum`.
+func (b *channelBank) prune(l1BlockNum uint64) {
+	for id, ch := range b.channels {
+		if l1BlockNum > ch.openedAt+b.timeout {
+			log.Warn("Dropping timed-out channel", "channel", id, "opened_at", ch.openedAt, "num_frames", len(ch.frames))
+			delete(b.channels, id)
+		}
+	}
+}
+
+func (b *channelBank) reset() {
+	b.channels = map[ChannelID]*channel{}
+}
@@ -0,0 +1,53 @@
+package derivation
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChannelBankTimeout(t *testing.T) {
+	const timeout = 10
+	var (
+		batch  = testBatchData(25)
+		frames []Frame
+	)
+	for _, data := range marshalFrames(t, batch, FrameHeaderSize+10) {
+		f, err := UnmarshalFrame(data)
+		if err != nil {
+			t.Fatalf("failed to unmarshal frame: %v", err)
+		}
+		frames = append(frames, f)
+	}
+	tests := []struct {
+		name string
+		// L1 block in which the remaining frames are received (the first is received in block 1).
+		l1BlockNum   uint64
+		wantComplete bool
+	}{
+		{"completed at timeout", 1 + timeout, true},
+		// The channel is dropped, so the remaining frames open a new, incomplete one.
+		{"completed past timeout", 1 + timeout + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank := newChannelBank(timeout)
+			if _, err := bank.addFrame(frames[0], 1); err != nil {
+				t.Fatalf("failed to add frame: %v", err)
+			}
+			bank.prune(tt.l1BlockNum)
+			var got []byte
+			for _, f := range frames[1:] {
+				var err error
+				if got, err = bank.addFrame(f, tt.l1BlockNum); err != nil {
+					t.Fatalf("failed to add frame %d: %v", f.FrameNum, err)
+				}
+			}
+			if tt.wantComplete != bytes.Equal(got, batch) {
+				t.Errorf("got batch %x, want complete: %t", got, tt.wantComplete)
+			}
+			if !tt.wantComplete && len(bank.channels) != 1 {
+				t.Errorf("got %d open channels, want 1", len(bank.channels))
+			}
+		})
+	}
+}
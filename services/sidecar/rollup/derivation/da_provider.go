@@ -0,0 +1,126 @@
+package derivation
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+// Version byte of a DA commitment, appended to the inbox in place of a batch stored off-chain.
+// Encoded as: version || keccak256(batch).
+const DACommitmentV0 byte = 0x81
+
+const daCommitmentSize = 1 + common.HashLength
+
+// Names of the supported DA providers.
+const (
+	InboxDAProvider    = "inbox"    // Batches are appended to the sequencer inbox in full.
+	OffchainDAProvider = "offchain" // Batches are stored by an off-chain DA server; only commitments are appended.
+)
+
+type DAConfig interface {
+	GetDAProvider() string
+	GetDAServer() string
+}
+
+// Makes batches available to derivers.
+type DAProvider interface {
+	// Makes a batch available, returning the data to append to the sequencer inbox in its place.
+	Put(ctx context.Context, batch []byte) ([]byte, error)
+	// Resolves data appended to the sequencer inbox into the batch it stands for.
+	// Returns an `InvalidBatchError` if the data can never resolve to a valid batch.
+	Get(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// Stores batches off-chain, keyed by their hash.
+type BatchStore interface {
+	Put(ctx context.Context, key common.Hash, batch []byte) error
+	Get(ctx context.Context, key common.Hash) ([]byte, error)
+}
+
+func NewDAProvider(cfg DAConfig) (DAProvider, error) {
+	switch cfg.GetDAProvider() {
+	case "", InboxDAProvider:
+		return inboxDAProvider{}, nil
+	case OffchainDAProvider:
+		store, err := NewBatchStore(cfg.GetDAServer())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create batch store: %w", err)
+		}
+		return NewOffchainDAProvider(store), nil
+	default:
+		return nil, fmt.Errorf("unknown DA provider: %s", cfg.GetDAProvider())
+	}
+}
+
+// Creates a batch store for the given DA server endpoint: an HTTP(S) URL, or a local directory (as a stand-in).
+func NewBatchStore(endpoint string) (BatchStore, error) {
+	if endpoint == "" {
+		return nil, errors.New("missing DA server endpoint")
+	}
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return NewHTTPBatchStore(endpoint), nil
+	}
+	return NewFileBatchStore(strings.TrimPrefix(endpoint, "file://"))
+}
+
+// Returns true if the (versioned) data is a DA commitment.
+func IsDACommitment(data []byte) bool { return len(data) > 0 && data[0] == DACommitmentV0 }
+
+func newDACommitment(batch []byte) []byte {
+	return append([]byte{DACommitmentV0}, crypto.Keccak256(batch)...)
+}
+
+// Appends batches to the inbox as-is.
+type inboxDAProvider struct{}
+
+func (inboxDAProvider) Put(_ context.Context, batch []byte) ([]byte, error) { return batch, nil }
+
+func (inboxDAProvider) Get(_ context.Context, data []byte) ([]byte, error) {
+	// Batches must be appended in full, so a commitment doesn't stand for any batch.
+	if IsDACommitment(data) {
+		return nil, InvalidBatchError{"found DA commitment, but batches must be appended to the inbox"}
+	}
+	return data, nil
+}
+
+// Stores batches off-chain, appending only commitments to the inbox.
+// Data that isn't a commitment (e.g. batches appended before switching providers) is resolved as-is.
+type OffchainDAProvider struct {
+	store BatchStore
+}
+
+func NewOffchainDAProvider(store BatchStore) *OffchainDAProvider {
+	return &OffchainDAProvider{store: store}
+}
+
+func (p *OffchainDAProvider) Put(ctx context.Context, batch []byte) ([]byte, error) {
+	commitment := newDACommitment(batch)
+	if err := p.store.Put(ctx, common.BytesToHash(commitment[1:]), batch); err != nil {
+		return nil, fmt.Errorf("failed to store batch: %w", err)
+	}
+	return commitment, nil
+}
+
+func (p *OffchainDAProvider) Get(ctx context.Context, data []byte) ([]byte, error) {
+	if !IsDACommitment(data) {
+		return data, nil
+	}
+	if len(data) != daCommitmentSize {
+		return nil, InvalidBatchError{fmt.Sprintf("invalid DA commitment size: %d", len(data))}
+	}
+	key := common.BytesToHash(data[1:])
+	batch, err := p.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch (key=%s): %w", key, err)
+	}
+	// Don't trust the store: a mismatching batch is treated as invalid, rather than unavailable.
+	if hash := crypto.Keccak256Hash(batch); hash != key {
+		return nil, InvalidBatchError{fmt.Sprintf("batch does not match commitment (key=%s, hash=%s)", key, hash)}
+	}
+	return batch, nil
+}
@@ -0,0 +1,140 @@
+package derivation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// A DA server storing batches in memory.
+type testDAServer struct {
+	mu      sync.Mutex
+	batches map[string][]byte
+}
+
+func newTestDAServer(t *testing.T) *httptest.Server {
+	s := &testDAServer{batches: make(map[string][]byte)}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *testDAServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodPut:
+		batch, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.batches[key] = batch
+	case http.MethodGet:
+		batch, ok := s.batches[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(batch)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestBatchStores(t *testing.T) {
+	fileStore, err := NewFileBatchStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file store: %v", err)
+	}
+	stores := map[string]BatchStore{"file": fileStore, "http": NewHTTPBatchStore(newTestDAServer(t).URL + "/")}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx   = context.Background()
+				batch = []byte{V0, 0x01, 0x02}
+				key   = common.Hash{1}
+			)
+			if _, err := store.Get(ctx, key); err == nil {
+				t.Fatal("got missing batch")
+			}
+			if err := store.Put(ctx, key, batch); err != nil {
+				t.Fatalf("failed to put batch: %v", err)
+			}
+			got, err := store.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("failed to get batch: %v", err)
+			}
+			if string(got) != string(batch) {
+				t.Errorf("got batch %x, want %x", got, batch)
+			}
+		})
+	}
+}
+
+func TestOffchainDAProvider(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileBatchStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	da := NewOffchainDAProvider(store)
+	batch := []byte{V0, 0x01, 0x02}
+	commitment, err := da.Put(ctx, batch)
+	if err != nil {
+		t.Fatalf("failed to put batch: %v", err)
+	}
+	if !IsDACommitment(commitment) || len(commitment) != daCommitmentSize {
+		t.Fatalf("got invalid commitment %x", commitment)
+	}
+	// Stored under a different key, so that its hash mismatches.
+	tampered := newDACommitment([]byte{V0, 0x03})
+	if err := os.WriteFile(store.path(common.BytesToHash(tampered[1:])), batch, 0o644); err != nil {
+		t.Fatalf("failed to write tampered batch: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		want        []byte
+		wantInvalid bool
+		wantErr     bool
+	}{
+		{name: "resolves commitment", data: commitment, want: batch},
+		{name: "passes through batches", data: batch, want: batch},
+		{name: "invalid commitment size", data: commitment[:10], wantInvalid: true},
+		{name: "batch mismatching commitment", data: tampered, wantInvalid: true},
+		// Unavailable batches aren't invalid (they may become available).
+		{name: "missing batch", data: newDACommitment([]byte{V0, 0x04}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := da.Get(ctx, tt.data)
+			if isInvalid := errors.As(err, &InvalidBatchError{}); isInvalid != tt.wantInvalid {
+				t.Fatalf("got error %v, want invalid batch: %t", err, tt.wantInvalid)
+			}
+			if (err != nil) != (tt.wantErr || tt.wantInvalid) {
+				t.Fatalf("got error %v", err)
+			}
+			if err == nil && string(got) != string(tt.want) {
+				t.Errorf("got batch %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInboxDAProviderRejectsCommitments(t *testing.T) {
+	_, err := inboxDAProvider{}.Get(context.Background(), newDACommitment([]byte{V0}))
+	if !errors.As(err, &InvalidBatchError{}) {
+		t.Errorf("got error %v, want an invalid batch error", err)
+	}
+}
@@ -0,0 +1,390 @@
+package derivation
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+type PipelineConfig interface {
+	GetSequencerInboxAddr() common.Address
+	GetL1OracleAddr() common.Address
+	GetSeqWindowSize() uint64
+}
+
+type L1Client interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*ethTypes.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethTypes.Receipt, error)
+}
+
+// Attributes of an L2 block, derived from L1.
+type L2BlockAttributes struct {
+	Number      uint64                // L2 block number
+	Timestamp   uint64                // L2 block timestamp (0 if not encoded in the batch, i.e. pre-V2)
+	Txs         ethTypes.Transactions // L2 txs (incl. the L1 oracle tx, if any)
+	L1Epoch     uint64                // L1 epoch (set by the last L1 oracle tx)
+	L1EpochHash common.Hash           // L1 epoch hash (empty if not encoded in the batch, i.e. pre-V2)
+	L1Block     types.BlockID         // L1 block the batch was appended in
+	BatchNum    uint64                // # of batches appended to the inbox (since derivation started) before this one
+}
+
+// Derives L2 blocks from batches appended to the sequencer inbox.
+// Not thread-safe; L1 blocks must be processed in order.
+type DerivationPipeline struct {
+	cfg            PipelineConfig
+	registry       *BatchVersionRegistry
+	l1Client       L1Client
+	blobs          BlobFetcher // Nil if blob batches can't be derived from.
+	da             DAProvider
+	channelBank    *channelBank
+	lastL2BlockNum uint64      // last derived L2 block number
+	lastL2Time     uint64      // timestamp of the last derived L2 block (0 if unknown)
+	l1Epoch        uint64      // current L1 epoch
+	l1EpochHash    common.Hash // current L1 epoch hash (empty if unknown)
+	numBatches     uint64      // # of batches appended to the inbox in the L1 blocks derived from
+}
+
+type InvalidBatchError struct{ Msg string }
+
+func (e InvalidBatchError) Error() string { return e.Msg }
+
+func NewDerivationPipeline(
+	cfg PipelineConfig,
+	registry *BatchVersionRegistry,
+	l1Client L1Client,
+	blobs BlobFetcher,
+	da DAProvider,
+	lastL2BlockNum uint64,
+) (*DerivationPipeline, error) {
+	if err := bridge.EnsureUtilInit(); err != nil {
+		return nil, fmt.Errorf("failed to initialize bridge serialization: %w", err)
+	}
+	// Channels must be completed within the sequencing window.
+	return &DerivationPipeline{
+		cfg:            cfg,
+		registry:       registry,
+		l1Client:       l1Client,
+		blobs:          blobs,
+		da:             da,
+		channelBank:    newChannelBank(cfg.GetSeqWindowSize()),
+		lastL2BlockNum: lastL2BlockNum,
+	}, nil
+}
+
+func (p *DerivationPipeline) LastL2BlockNum() uint64 { return p.lastL2BlockNum }
+
+// Resets the pipeline to the given (already-derived) L2 block number and L1 epoch.
+func (p *DerivationPipeline) Reset(lastL2BlockNum uint64, l1Epoch uint64) {
+	p.channelBank.reset()
+	p.lastL2BlockNum = lastL2BlockNum
+	p.lastL2Time = 0
+	p.l1Epoch = l1Epoch
+	p.l1EpochHash = common.Hash{}
+}
+
+// Derives L2 block attributes from all batches appended in L1 blocks [start, end], in order.
+func (p *DerivationPipeline) DeriveRange(ctx context.Context, start, end uint64) ([]L2BlockAttributes, error) {
+	var attrs []L2BlockAttributes
+	for i := start; i <= end; i++ {
+		derived, err := p.Derive(ctx, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive from L1 block (num=%d): %w", i, err)
+		}
+		attrs = append(attrs, derived...)
+	}
+	return attrs, nil
+}
+
+// Derives L2 block attributes from all batches appended in the given L1 block, in order.
+// Invalid batches are skipped.
+func (p *DerivationPipeline) Derive(ctx context.Context, l1BlockNum uint64) ([]L2BlockAttributes, error) {
+	l1Block, err := p.l1Client.BlockByNumber(ctx, new(big.Int).SetUint64(l1BlockNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 block: %w", err)
+	}
+	var (
+		l1BlockID = types.NewBlockID(l1Block.NumberU64(), l1Block.Hash())
+		attrs     []L2BlockAttributes
+	)
+	p.channelBank.prune(l1BlockID.GetNumber())
+	for _, tx := range l1Block.Transactions() {
+		data, err := p.extractBatchData(ctx, l1Block.Header(), tx)
+		if err == nil && data == nil {
+			continue
+		}
+		// Every append counts towards the inbox's batches (see `TxAccumulator`), whether it's valid or not.
+		batchNum := p.numBatches
+		p.numBatches++
+		if err != nil {
+			if !errors.As(err, &InvalidBatchError{}) {
+				return nil, fmt.Errorf("failed to extract batch data (tx=%s): %w", tx.Hash(), err)
+			}
+			log.Warn("Skipping invalid batch", "tx_hash", tx.Hash(), "l1Block#", l1BlockID.GetNumber(), "err", err)
+			continue
+		}
+		if IsFrame(data) {
+			data, err = p.addFrame(data, l1BlockID)
+			if err != nil {
+				log.Warn("Skipping invalid frame", "tx_hash", tx.Hash(), "l1Block#", l1BlockID.GetNumber(), "err", err)
+				continue
+			}
+		}
+		if data == nil {
+			continue
+		}
+		// Resolve commitments to batches stored off-chain.
+		// Unless the commitment is invalid, failing to do so isn't a batch validity issue
+		// (the batch may just be unavailable for now), so abort.
+		data, err = p.da.Get(ctx, data)
+		if err != nil {
+			if !errors.As(err, &InvalidBatchError{}) {
+				return nil, fmt.Errorf("failed to get batch from DA provider (tx=%s): %w", tx.Hash(), err)
+			}
+			log.Warn("Skipping invalid batch", "tx_hash", tx.Hash(), "l1Block#", l1BlockID.GetNumber(), "err", err)
+			continue
+		}
+		derived, err := p.deriveFromBatch(ctx, data, l1BlockID)
+		if err != nil {
+			if !errors.As(err, &InvalidBatchError{}) {
+				return nil, fmt.Errorf("failed to derive from batch (tx=%s): %w", tx.Hash(), err)
+			}
+			log.Warn("Skipping invalid batch", "tx_hash", tx.Hash(), "l1Block#", l1BlockID.GetNumber(), "err", err)
+			continue
+		}
+		log.Info("Derived L2 blocks from batch", "tx_hash", tx.Hash(), "l1Block#", l1BlockID.GetNumber(), "#blocks", len(derived))
+		for i := range derived {
+			derived[i].BatchNum = batchNum
+		}
+		attrs = append(attrs, derived...)
+	}
+	return attrs, nil
+}
+
+// Returns the batch data of a successful `appendTxBatch` call to the inbox, or nil if the tx is not one.
+// For blob txs, the data is read from the blobs; returns an `InvalidBatchError` if they're invalid.
+func (p *DerivationPipeline) extractBatchData(
+	ctx context.Context,
+	l1Header *ethTypes.Header,
+	tx *ethTypes.Transaction,
+) ([]byte, error) {
+	if tx.To() == nil || *tx.To() != p.cfg.GetSequencerInboxAddr() || !bridge.IsAppendTxBatchTx(tx) {
+		return nil, nil
+	}
+	receipt, err := p.l1Client.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	if receipt.Status != ethTypes.ReceiptStatusSuccessful {
+		log.Trace("Skipping reverted batch tx", "tx_hash", tx.Hash())
+		return nil, nil
+	}
+	in, err := bridge.UnpackAppendTxBatchInput(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack input: %w", err)
+	}
+	data, ok := in[0].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected input type: %T", in[0])
+	}
+	if len(tx.BlobHashes()) > 0 {
+		return fetchBlobData(ctx, p.blobs, l1Header, tx, data)
+	}
+	return data, nil
+}
+
+// Adds a frame to its channel. Returns the channel's batch data if the frame completes it, or nil otherwise.
+func (p *DerivationPipeline) addFrame(data []byte, l1BlockID types.BlockID) ([]byte, error) {
+	frame, err := UnmarshalFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	return p.channelBank.addFrame(frame, l1BlockID.GetNumber())
+}
+
+// Decodes a batch and derives L2 block attributes from it.
+// Returns an `InvalidBatchError` if the batch is invalid.
+func (p *DerivationPipeline) deriveFromBatch(
+	ctx context.Context,
+	data []byte,
+	l1BlockID types.BlockID,
+) ([]L2BlockAttributes, error) {
+	decoded, err := p.registry.Decode(data, l1BlockID.GetNumber())
+	if err != nil {
+		return nil, InvalidBatchError{err.Error()}
+	}
+	switch subBatches := decoded.(type) {
+	case []subBatch:
+		return p.deriveFromSubBatches(subBatches, l1BlockID)
+	case []subBatchV2:
+		return p.deriveFromSubBatchesV2(ctx, subBatches, l1BlockID)
+	default:
+		return nil, InvalidBatchError{fmt.Sprintf("unsupported decoded batch type: %T", decoded)}
+	}
+}
+
+// Derives L2 block attributes from V0/V1 sub-batches.
+// Empty L2 blocks (skipped by the encoder) are re-created in place.
+func (p *DerivationPipeline) deriveFromSubBatches(
+	subBatches []subBatch,
+	l1BlockID types.BlockID,
+) ([]L2BlockAttributes, error) {
+	var (
+		lastL2BlockNum = p.lastL2BlockNum
+		l1Epoch        = p.l1Epoch
+		attrs          []L2BlockAttributes
+	)
+	for _, sb := range subBatches {
+		for i, rawTxs := range sb.TxBlocks {
+			blockNum := sb.FirstL2BlockNum + uint64(i)
+			if blockNum <= lastL2BlockNum {
+				log.Trace("Skipping already-derived block", "l2Block#", blockNum)
+				continue
+			}
+			// Re-create the empty blocks skipped by the encoder.
+			for num := lastL2BlockNum + 1; num < blockNum; num++ {
+				attrs = append(attrs, L2BlockAttributes{Number: num, L1Epoch: l1Epoch, L1Block: l1BlockID})
+			}
+			txs, err := unmarshallTxs(rawTxs)
+			if err != nil {
+				return nil, InvalidBatchError{fmt.Sprintf("invalid txs in block %d: %s", blockNum, err)}
+			}
+			if epoch, ok := p.getEpoch(txs); ok {
+				l1Epoch = epoch
+			}
+			attrs = append(attrs, L2BlockAttributes{Number: blockNum, Txs: txs, L1Epoch: l1Epoch, L1Block: l1BlockID})
+			lastL2BlockNum = blockNum
+		}
+	}
+	// Only advance the pipeline if the whole batch is valid.
+	p.lastL2BlockNum = lastL2BlockNum
+	p.lastL2Time = 0
+	p.l1Epoch = l1Epoch
+	p.l1EpochHash = common.Hash{}
+	return attrs, nil
+}
+
+// Derives L2 block attributes from V2 sub-batches, checking that L1 origins and timestamps are consistent.
+func (p *DerivationPipeline) deriveFromSubBatchesV2(
+	ctx context.Context,
+	subBatches []subBatchV2,
+	l1BlockID types.BlockID,
+) ([]L2BlockAttributes, error) {
+	var (
+		lastL2BlockNum = p.lastL2BlockNum
+		lastL2Time     = p.lastL2Time
+		l1Epoch        = types.NewBlockID(p.l1Epoch, p.l1EpochHash)
+		attrs          []L2BlockAttributes
+	)
+	for _, sb := range subBatches {
+		// An empty L1 origin continues the current epoch.
+		if l1Origin := sb.l1Origin(); l1Origin != types.EmptyBlockID {
+			if err := p.checkL1Origin(ctx, l1Origin, l1Epoch, l1BlockID); err != nil {
+				return nil, err
+			}
+			l1Epoch = l1Origin
+		}
+		for i, block := range sb.Blocks {
+			blockNum := sb.FirstL2BlockNum + uint64(i)
+			if blockNum <= lastL2BlockNum {
+				log.Trace("Skipping already-derived block", "l2Block#", blockNum)
+				continue
+			}
+			if blockNum != lastL2BlockNum+1 {
+				return nil, InvalidBatchError{fmt.Sprintf("non-contiguous block %d (last derived: %d)", blockNum, lastL2BlockNum)}
+			}
+			if block.Timestamp <= lastL2Time {
+				return nil, InvalidBatchError{fmt.Sprintf("non-increasing timestamp in block %d: %d", blockNum, block.Timestamp)}
+			}
+			txs, err := unmarshallTxs(block.Txs)
+			if err != nil {
+				return nil, InvalidBatchError{fmt.Sprintf("invalid txs in block %d: %s", blockNum, err)}
+			}
+			if epoch, ok := p.getEpoch(txs); ok && epoch != l1Epoch.GetNumber() {
+				return nil, InvalidBatchError{fmt.Sprintf("oracle tx in block %d sets epoch %d, expected %d", blockNum, epoch, l1Epoch.GetNumber())}
+			}
+			attrs = append(attrs, L2BlockAttributes{
+				Number:      blockNum,
+				Timestamp:   block.Timestamp,
+				Txs:         txs,
+				L1Epoch:     l1Epoch.GetNumber(),
+				L1EpochHash: l1Epoch.GetHash(),
+				L1Block:     l1BlockID,
+			})
+			lastL2BlockNum = blockNum
+			lastL2Time = block.Timestamp
+		}
+	}
+	// Only advance the pipeline if the whole batch is valid.
+	p.lastL2BlockNum = lastL2BlockNum
+	p.lastL2Time = lastL2Time
+	p.l1Epoch = l1Epoch.GetNumber()
+	p.l1EpochHash = l1Epoch.GetHash()
+	return attrs, nil
+}
+
+// Checks that a sub-batch's L1 origin doesn't precede the current epoch,
+// precedes the L1 block the batch was appended in, and is canonical.
+func (p *DerivationPipeline) checkL1Origin(
+	ctx context.Context,
+	l1Origin types.BlockID,
+	l1Epoch types.BlockID,
+	l1BlockID types.BlockID,
+) error {
+	if l1Origin.GetNumber() < l1Epoch.GetNumber() {
+		return InvalidBatchError{fmt.Sprintf("L1 origin %s precedes current epoch %s", l1Origin, l1Epoch)}
+	}
+	if l1Origin.GetNumber() >= l1BlockID.GetNumber() {
+		return InvalidBatchError{fmt.Sprintf("L1 origin %s does not precede L1 block %s", l1Origin, l1BlockID)}
+	}
+	if l1Origin.GetNumber() == l1Epoch.GetNumber() && (l1Epoch.GetHash() != common.Hash{}) {
+		if l1Origin.GetHash() != l1Epoch.GetHash() {
+			return InvalidBatchError{fmt.Sprintf("L1 origin %s conflicts with current epoch %s", l1Origin, l1Epoch)}
+		}
+		return nil
+	}
+	header, err := p.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(l1Origin.GetNumber()))
+	if err != nil {
+		return fmt.Errorf("failed to get L1 origin header: %w", err)
+	}
+	if header.Hash() != l1Origin.GetHash() {
+		return InvalidBatchError{fmt.Sprintf("L1 origin %s is not canonical (expected hash %s)", l1Origin, header.Hash())}
+	}
+	return nil
+}
+
+// Returns the L1 epoch set by the block's oracle tx, if it exists.
+func (p *DerivationPipeline) getEpoch(txs ethTypes.Transactions) (uint64, bool) {
+	if len(txs) == 0 {
+		return 0, false
+	}
+	firstTx := txs[0]
+	if firstTx.To() == nil || *firstTx.To() != p.cfg.GetL1OracleAddr() {
+		return 0, false
+	}
+	epoch, _, _, _, _, err := bridge.UnpackL1OracleInput(firstTx)
+	if err != nil {
+		log.Warn("Could not unpack oracle tx", "tx_hash", firstTx.Hash(), "err", err)
+		return 0, false
+	}
+	return epoch, true
+}
+
+func unmarshallTxs(rawTxs rawTxBlock) (ethTypes.Transactions, error) {
+	txs := make(ethTypes.Transactions, 0, len(rawTxs))
+	for i, rawTx := range rawTxs {
+		var tx ethTypes.Transaction
+		if err := tx.UnmarshalBinary(rawTx); err != nil {
+			return nil, fmt.Errorf("could not unmarshall tx %d: %w", i, err)
+		}
+		txs = append(txs, &tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,416 @@
+package derivation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/specularL2/specular/services/sidecar/bindings"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
+)
+
+var (
+	testInboxAddr     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testOracleAddr    = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testChainID       = big.NewInt(13527)
+	testBatcherKey, _ = crypto.GenerateKey()
+	testBatcherAddr   = crypto.PubkeyToAddress(testBatcherKey.PublicKey)
+)
+
+type testPipelineConfig struct{}
+
+func (testPipelineConfig) GetSequencerInboxAddr() common.Address { return testInboxAddr }
+func (testPipelineConfig) GetL1OracleAddr() common.Address       { return testOracleAddr }
+func (testPipelineConfig) GetSeqWindowSize() uint64              { return 10 }
+
+// An in-memory L1 chain.
+type testL1Client struct {
+	blocks   map[uint64]*ethTypes.Block
+	receipts map[common.Hash]*ethTypes.Receipt
+	nonce    uint64 // Nonce of the next batch tx, to keep tx hashes unique.
+}
+
+func newTestL1Client() *testL1Client {
+	return &testL1Client{blocks: map[uint64]*ethTypes.Block{}, receipts: map[common.Hash]*ethTypes.Receipt{}}
+}
+
+func (c *testL1Client) BlockByNumber(_ context.Context, number *big.Int) (*ethTypes.Block, error) {
+	block, ok := c.blocks[number.Uint64()]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return block, nil
+}
+
+func (c *testL1Client) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	block, err := c.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (c *testL1Client) TransactionReceipt(_ context.Context, txHash common.Hash) (*ethTypes.Receipt, error) {
+	receipt, ok := c.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (c *testL1Client) BlockNumber(context.Context) (uint64, error) {
+	var head uint64
+	for num := range c.blocks {
+		if num > head {
+			head = num
+		}
+	}
+	return head, nil
+}
+
+func (c *testL1Client) TransactionByHash(_ context.Context, hash common.Hash) (*ethTypes.Transaction, bool, error) {
+	for _, block := range c.blocks {
+		if tx := block.Transaction(hash); tx != nil {
+			return tx, false, nil
+		}
+	}
+	return nil, false, ethereum.NotFound
+}
+
+// Returns a `TxBatchAppended` log for each successful inbox tx in the queried range.
+func (c *testL1Client) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	var logs []ethTypes.Log
+	for num := q.FromBlock.Uint64(); num <= q.ToBlock.Uint64(); num++ {
+		block, ok := c.blocks[num]
+		if !ok {
+			continue
+		}
+		for _, tx := range block.Transactions() {
+			if tx.To() == nil || *tx.To() != testInboxAddr || c.receipts[tx.Hash()].Status != ethTypes.ReceiptStatusSuccessful {
+				continue
+			}
+			logs = append(logs, ethTypes.Log{
+				Address:     testInboxAddr,
+				Topics:      []common.Hash{bridge.InboxEvent(bridge.TxBatchAppendedEventName).ID},
+				BlockNumber: num,
+				TxHash:      tx.Hash(),
+				BlockHash:   block.Hash(),
+			})
+		}
+	}
+	return logs, nil
+}
+
+// Adds an L1 block with the given txs, which succeed unless a receipt was already set for them.
+func (c *testL1Client) addBlock(num uint64, txs ...*ethTypes.Transaction) *ethTypes.Block {
+	header := &ethTypes.Header{Number: new(big.Int).SetUint64(num), Time: num * 12}
+	if parent, ok := c.blocks[num-1]; ok {
+		header.ParentHash = parent.Hash()
+	}
+	block := ethTypes.NewBlockWithHeader(header).WithBody(txs, nil)
+	for _, tx := range txs {
+		if _, ok := c.receipts[tx.Hash()]; ok {
+			continue
+		}
+		c.receipts[tx.Hash()] = &ethTypes.Receipt{
+			Status:      ethTypes.ReceiptStatusSuccessful,
+			TxHash:      tx.Hash(),
+			BlockHash:   block.Hash(),
+			BlockNumber: header.Number,
+		}
+	}
+	c.blocks[num] = block
+	return block
+}
+
+// Returns an `appendTxBatch` tx appending `data` to the inbox, sent by the batcher.
+func (c *testL1Client) batchTx(t *testing.T, data []byte) *ethTypes.Transaction {
+	t.Helper()
+	c.nonce++
+	return signBatchTx(t, &ethTypes.DynamicFeeTx{
+		ChainID: testChainID, Nonce: c.nonce, To: &testInboxAddr, Data: packAppendTxBatch(t, data),
+	})
+}
+
+func packAppendTxBatch(t *testing.T, data []byte) []byte {
+	t.Helper()
+	inboxAbi, err := bindings.ISequencerInboxMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("failed to get inbox ABI: %v", err)
+	}
+	calldata, err := inboxAbi.Pack("appendTxBatch", data)
+	if err != nil {
+		t.Fatalf("failed to pack appendTxBatch input: %v", err)
+	}
+	return calldata
+}
+
+func signBatchTx(t *testing.T, txData ethTypes.TxData) *ethTypes.Transaction {
+	t.Helper()
+	tx, err := ethTypes.SignNewTx(testBatcherKey, ethTypes.LatestSignerForChainID(testChainID), txData)
+	if err != nil {
+		t.Fatalf("failed to sign batch tx: %v", err)
+	}
+	return tx
+}
+
+// Returns `n` signed L2 txs, with nonces starting at `nonce`.
+func testL2Txs(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, n int) ethTypes.Transactions {
+	t.Helper()
+	var (
+		signer = ethTypes.LatestSignerForChainID(testChainID)
+		to     = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		txs    ethTypes.Transactions
+	)
+	for i := 0; i < n; i++ {
+		tx, err := ethTypes.SignNewTx(key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: testChainID, Nonce: nonce + uint64(i), To: &to, Gas: 21000, Value: big.NewInt(1),
+		})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// Encodes a V0 batch of the given sub-batches.
+func testV0Batch(t *testing.T, subBatches ...subBatch) []byte {
+	t.Helper()
+	encoded, err := rlp.EncodeToBytes(subBatches)
+	if err != nil {
+		t.Fatalf("failed to encode batch: %v", err)
+	}
+	return append([]byte{V0}, encoded...)
+}
+
+// Returns a sub-batch of consecutive L2 blocks (with the given txs) starting at `firstL2BlockNum`.
+func testSubBatch(t *testing.T, firstL2BlockNum uint64, blocks ...ethTypes.Transactions) subBatch {
+	t.Helper()
+	sb := subBatch{FirstL2BlockNum: firstL2BlockNum}
+	for _, txs := range blocks {
+		raw, _, err := marshallTxs(txs)
+		if err != nil {
+			t.Fatalf("failed to marshall txs: %v", err)
+		}
+		sb.TxBlocks = append(sb.TxBlocks, raw)
+	}
+	return sb
+}
+
+func newTestPipeline(
+	t *testing.T,
+	l1Client L1Client,
+	blobs BlobFetcher,
+	da DAProvider,
+	lastL2BlockNum uint64,
+) *DerivationPipeline {
+	t.Helper()
+	registry, err := NewBatchVersionRegistry(nil)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	if da == nil {
+		da = inboxDAProvider{}
+	}
+	p, err := NewDerivationPipeline(testPipelineConfig{}, registry, l1Client, blobs, da, lastL2BlockNum)
+	if err != nil {
+		t.Fatalf("failed to create pipeline: %v", err)
+	}
+	return p
+}
+
+// Returns the L2 block numbers and tx hashes of the given attributes.
+func summarize(attrs []L2BlockAttributes) ([]uint64, [][]common.Hash) {
+	var (
+		nums   []uint64
+		hashes [][]common.Hash
+	)
+	for _, a := range attrs {
+		nums = append(nums, a.Number)
+		var h []common.Hash
+		for _, tx := range a.Txs {
+			h = append(h, tx.Hash())
+		}
+		hashes = append(hashes, h)
+	}
+	return nums, hashes
+}
+
+func txHashes(txs ethTypes.Transactions) []common.Hash {
+	var hashes []common.Hash
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash())
+	}
+	return hashes
+}
+
+func checkDerived(t *testing.T, attrs []L2BlockAttributes, wantNums []uint64, wantTxs []ethTypes.Transactions) {
+	t.Helper()
+	nums, hashes := summarize(attrs)
+	if len(nums) != len(wantNums) {
+		t.Fatalf("derived blocks %v, want %v", nums, wantNums)
+	}
+	for i := range nums {
+		if nums[i] != wantNums[i] {
+			t.Fatalf("derived blocks %v, want %v", nums, wantNums)
+		}
+		want := txHashes(wantTxs[i])
+		if len(hashes[i]) != len(want) {
+			t.Fatalf("block %d: derived %d txs, want %d", nums[i], len(hashes[i]), len(want))
+		}
+		for j := range want {
+			if hashes[i][j] != want[j] {
+				t.Errorf("block %d, tx %d: derived %s, want %s", nums[i], j, hashes[i][j], want[j])
+			}
+		}
+	}
+}
+
+func TestDerive(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		txs         = testL2Txs(t, key, 0, 4)
+		block2      = txs[:2]
+		block3      = txs[2:3]
+		block5      = txs[3:]
+		validBatch  = testV0Batch(t, testSubBatch(t, 2, block2, block3), testSubBatch(t, 5, block5))
+		otherTarget = common.HexToAddress("0x4000000000000000000000000000000000000004")
+	)
+	tests := []struct {
+		name      string
+		lastL2    uint64
+		batchData [][]byte
+		// Modifies the L1 block's txs or their receipts, before the block is added.
+		prepare  func(t *testing.T, l1 *testL1Client, txs []*ethTypes.Transaction) []*ethTypes.Transaction
+		wantNums []uint64
+		wantTxs  []ethTypes.Transactions
+		wantLast uint64
+	}{
+		{
+			name:      "derives blocks and re-creates skipped empty ones",
+			lastL2:    1,
+			batchData: [][]byte{validBatch},
+			wantNums:  []uint64{2, 3, 4, 5},
+			wantTxs:   []ethTypes.Transactions{block2, block3, nil, block5},
+			wantLast:  5,
+		},
+		{
+			name:      "skips already-derived blocks",
+			lastL2:    3,
+			batchData: [][]byte{validBatch},
+			wantNums:  []uint64{4, 5},
+			wantTxs:   []ethTypes.Transactions{nil, block5},
+			wantLast:  5,
+		},
+		{
+			name:      "skips invalid batches",
+			lastL2:    1,
+			batchData: [][]byte{{V0, 0xde, 0xad}, {0x7f}, validBatch},
+			wantNums:  []uint64{2, 3, 4, 5},
+			wantTxs:   []ethTypes.Transactions{block2, block3, nil, block5},
+			wantLast:  5,
+		},
+		{
+			// Previously, DA commitments aborted derivation, even though they can never resolve to a batch here.
+			name:      "skips DA commitments without an off-chain DA provider",
+			lastL2:    1,
+			batchData: [][]byte{newDACommitment(validBatch), {DACommitmentV0, 0x01}, validBatch},
+			wantNums:  []uint64{2, 3, 4, 5},
+			wantTxs:   []ethTypes.Transactions{block2, block3, nil, block5},
+			wantLast:  5,
+		},
+		{
+			name:      "skips batches with invalid txs",
+			lastL2:    1,
+			batchData: [][]byte{testV0Batch(t, subBatch{FirstL2BlockNum: 2, TxBlocks: []rawTxBlock{{{0x02, 0x01}}}})},
+			wantLast:  1,
+		},
+		{
+			name:      "ignores reverted batch txs",
+			lastL2:    1,
+			batchData: [][]byte{validBatch},
+			prepare: func(t *testing.T, l1 *testL1Client, txs []*ethTypes.Transaction) []*ethTypes.Transaction {
+				for _, tx := range txs {
+					l1.receipts[tx.Hash()] = &ethTypes.Receipt{Status: ethTypes.ReceiptStatusFailed, TxHash: tx.Hash()}
+				}
+				return txs
+			},
+			wantLast: 1,
+		},
+		{
+			name:      "ignores txs to other contracts",
+			lastL2:    1,
+			batchData: [][]byte{validBatch},
+			prepare: func(t *testing.T, l1 *testL1Client, txs []*ethTypes.Transaction) []*ethTypes.Transaction {
+				var redirected []*ethTypes.Transaction
+				for _, tx := range txs {
+					l1.nonce++
+					redirected = append(redirected, ethTypes.NewTx(&ethTypes.DynamicFeeTx{
+						ChainID: testChainID, Nonce: l1.nonce, To: &otherTarget, Data: tx.Data(),
+					}))
+				}
+				return redirected
+			},
+			wantLast: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l1 := newTestL1Client()
+			var batchTxs []*ethTypes.Transaction
+			for _, data := range tt.batchData {
+				batchTxs = append(batchTxs, l1.batchTx(t, data))
+			}
+			if tt.prepare != nil {
+				batchTxs = tt.prepare(t, l1, batchTxs)
+			}
+			l1Block := l1.addBlock(1, batchTxs...)
+			p := newTestPipeline(t, l1, nil, nil, tt.lastL2)
+			attrs, err := p.Derive(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("failed to derive: %v", err)
+			}
+			checkDerived(t, attrs, tt.wantNums, tt.wantTxs)
+			for _, a := range attrs {
+				if a.L1Block.GetHash() != l1Block.Hash() {
+					t.Errorf("block %d: L1 block %s, want %s", a.Number, a.L1Block, l1Block.Hash())
+				}
+			}
+			if p.LastL2BlockNum() != tt.wantLast {
+				t.Errorf("last L2 block %d, want %d", p.LastL2BlockNum(), tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestDeriveBatchNums(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	var (
+		txs = testL2Txs(t, key, 0, 2)
+		l1  = newTestL1Client()
+		// Invalid batches count towards the inbox's batches too.
+		l1Block1 = []*ethTypes.Transaction{l1.batchTx(t, testV0Batch(t, testSubBatch(t, 2, txs[:1]))), l1.batchTx(t, []byte{0x7f})}
+		l1Block2 = []*ethTypes.Transaction{l1.batchTx(t, testV0Batch(t, testSubBatch(t, 3, txs[1:])))}
+	)
+	l1.addBlock(1, l1Block1...)
+	l1.addBlock(2, l1Block2...)
+	p := newTestPipeline(t, l1, nil, nil, 1)
+	attrs, err := p.DeriveRange(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("failed to derive: %v", err)
+	}
+	var got [][2]uint64
+	for _, a := range attrs {
+		got = append(got, [2]uint64{a.Number, a.BatchNum})
+	}
+	if want := [][2]uint64{{2, 0}, {3, 2}}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got block and batch numbers %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package derivation
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+// Version byte of a frame. The high bit distinguishes frames from (unframed) batch versions.
+const FrameV0 byte = 0x80
+
+const (
+	channelIDSize = 16
+	// version || channel_id || frame_num || is_last
+	FrameHeaderSize = 1 + channelIDSize + 2 + 1
+)
+
+// Identifies a channel, i.e. a single batch split across several frames.
+type ChannelID [channelIDSize]byte
+
+func (id ChannelID) String() string { return fmt.Sprintf("%x", id[:]) }
+
+// Derives a channel ID from batch data. Deterministic, so re-sent frames of the same batch share a channel.
+func NewChannelID(batch []byte) ChannelID {
+	var id ChannelID
+	copy(id[:], crypto.Keccak256(batch))
+	return id
+}
+
+// A chunk of a batch, sent in a single `appendTxBatch` call.
+// Encoded as: version || channel_id || frame_num (uint16) || is_last (bool) || data.
+type Frame struct {
+	ChannelID ChannelID
+	FrameNum  uint16
+	IsLast    bool
+	Data      []byte
+}
+
+func (f *Frame) Marshal() []byte {
+	buf := make([]byte, FrameHeaderSize, FrameHeaderSize+len(f.Data))
+	buf[0] = FrameV0
+	copy(buf[1:], f.ChannelID[:])
+	binary.BigEndian.PutUint16(buf[1+channelIDSize:], f.FrameNum)
+	if f.IsLast {
+		buf[FrameHeaderSize-1] = 1
+	}
+	return append(buf, f.Data...)
+}
+
+// Returns true if the (versioned) data is a frame.
+func IsFrame(data []byte) bool { return len(data) > 0 && data[0] == FrameV0 }
+
+func UnmarshalFrame(data []byte) (Frame, error) {
+	if len(data) < FrameHeaderSize {
+		return Frame{}, fmt.Errorf("frame too short: %d", len(data))
+	}
+	if data[0] != FrameV0 {
+		return Frame{}, fmt.Errorf("invalid frame version: %d", data[0])
+	}
+	var f Frame
+	copy(f.ChannelID[:], data[1:])
+	f.FrameNum = binary.BigEndian.Uint16(data[1+channelIDSize:])
+	switch data[FrameHeaderSize-1] {
+	case 0:
+	case 1:
+		f.IsLast = true
+	default:
+		return Frame{}, fmt.Errorf("invalid is_last flag: %d", data[FrameHeaderSize-1])
+	}
+	f.Data = data[FrameHeaderSize:]
+	return f, nil
+}
+
+// Splits a batch into frames, each of which (incl. header) is at most `maxFrameSize` bytes.
+func SplitIntoFrames(batch []byte, maxFrameSize uint64) ([]Frame, error) {
+	if maxFrameSize <= FrameHeaderSize {
+		return nil, fmt.Errorf("max frame size must exceed frame header size (%d)", FrameHeaderSize)
+	}
+	var (
+		id        = NewChannelID(batch)
+		chunkSize = int(maxFrameSize - FrameHeaderSize)
+		numFrames = (len(batch) + chunkSize - 1) / chunkSize
+	)
+	if numFrames > math.MaxUint16+1 {
+		return nil, fmt.Errorf("batch too large to frame (num_frames=%d)", numFrames)
+	}
+	frames := make([]Frame, 0, numFrames)
+	for i := 0; i < numFrames; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		frames = append(frames, Frame{
+			ChannelID: id,
+			FrameNum:  uint16(i),
+			IsLast:    i == numFrames-1,
+			Data:      batch[i*chunkSize : end],
+		})
+	}
+	return frames, nil
+}
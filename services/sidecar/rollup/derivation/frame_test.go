@@ -0,0 +1,171 @@
+package derivation
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testBatchData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+// Splits a batch into frames and returns their encodings, as appended to the inbox.
+func marshalFrames(t *testing.T, batch []byte, maxFrameSize uint64) [][]byte {
+	t.Helper()
+	frames, err := SplitIntoFrames(batch, maxFrameSize)
+	if err != nil {
+		t.Fatalf("failed to split batch: %v", err)
+	}
+	var encoded [][]byte
+	for _, f := range frames {
+		data := f.Marshal()
+		if uint64(len(data)) > maxFrameSize {
+			t.Fatalf("frame %d is %d bytes, max %d", f.FrameNum, len(data), maxFrameSize)
+		}
+		encoded = append(encoded, data)
+	}
+	return encoded
+}
+
+func TestSplitIntoFrames(t *testing.T) {
+	tests := []struct {
+		name          string
+		batchSize     int
+		maxFrameSize  uint64
+		wantNumFrames int
+	}{
+		{"single frame", 10, FrameHeaderSize + 10, 1},
+		{"exact multiple", 30, FrameHeaderSize + 10, 3},
+		{"partial last frame", 31, FrameHeaderSize + 10, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := testBatchData(tt.batchSize)
+			encoded := marshalFrames(t, batch, tt.maxFrameSize)
+			if len(encoded) != tt.wantNumFrames {
+				t.Fatalf("got %d frames, want %d", len(encoded), tt.wantNumFrames)
+			}
+			var reassembled []byte
+			for i, data := range encoded {
+				if !IsFrame(data) {
+					t.Fatalf("frame %d not recognized as a frame", i)
+				}
+				f, err := UnmarshalFrame(data)
+				if err != nil {
+					t.Fatalf("failed to unmarshal frame %d: %v", i, err)
+				}
+				if f.ChannelID != NewChannelID(batch) || f.FrameNum != uint16(i) || f.IsLast != (i == len(encoded)-1) {
+					t.Errorf("frame %d: got header (%s, %d, %t)", i, f.ChannelID, f.FrameNum, f.IsLast)
+				}
+				reassembled = append(reassembled, f.Data...)
+			}
+			if !bytes.Equal(reassembled, batch) {
+				t.Errorf("reassembled %x, want %x", reassembled, batch)
+			}
+		})
+	}
+	if _, err := SplitIntoFrames(testBatchData(10), FrameHeaderSize); err == nil {
+		t.Error("split into frames without room for data")
+	}
+}
+
+func TestUnmarshalFrameErrors(t *testing.T) {
+	valid := (&Frame{ChannelID: ChannelID{1}, FrameNum: 1, IsLast: true, Data: []byte{0xaa}}).Marshal()
+	withByte := func(i int, b byte) []byte {
+		data := append([]byte(nil), valid...)
+		data[i] = b
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", valid[:FrameHeaderSize-1]},
+		{"batch version", withByte(0, V1)},
+		{"unknown frame version", withByte(0, FrameV0+1)},
+		{"invalid is_last flag", withByte(FrameHeaderSize-1, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnmarshalFrame(tt.data); err == nil {
+				t.Errorf("unmarshalled invalid frame %x", tt.data)
+			}
+		})
+	}
+	// A header alone is a valid (empty) frame.
+	if f, err := UnmarshalFrame(valid[:FrameHeaderSize]); err != nil || len(f.Data) != 0 {
+		t.Errorf("got frame %+v (err=%v), want an empty frame", f, err)
+	}
+}
+
+func TestChannelBankReassembly(t *testing.T) {
+	batch := testBatchData(25)
+	frames := marshalFrames(t, batch, FrameHeaderSize+10) // 3 frames.
+	tests := []struct {
+		name  string
+		order []int // Indices of the frames, in order of arrival.
+	}{
+		{"in order", []int{0, 1, 2}},
+		{"out of order", []int{2, 0, 1}},
+		{"duplicated", []int{1, 1, 0, 1, 2}},
+		{"last frame duplicated", []int{2, 2, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank := newChannelBank(10)
+			for i, idx := range tt.order {
+				f, err := UnmarshalFrame(frames[idx])
+				if err != nil {
+					t.Fatalf("failed to unmarshal frame: %v", err)
+				}
+				got, err := bank.addFrame(f, 1)
+				if err != nil {
+					t.Fatalf("failed to add frame %d: %v", idx, err)
+				}
+				if i < len(tt.order)-1 {
+					if got != nil {
+						t.Fatalf("channel completed after %d frames", i+1)
+					}
+					continue
+				}
+				if !bytes.Equal(got, batch) {
+					t.Errorf("reassembled %x, want %x", got, batch)
+				}
+			}
+			if len(bank.channels) != 0 {
+				t.Errorf("%d channels left open", len(bank.channels))
+			}
+		})
+	}
+}
+
+func TestChannelBankInvalidFrames(t *testing.T) {
+	id := ChannelID{1}
+	tests := []struct {
+		name   string
+		frames []Frame
+	}{
+		{"two last frames", []Frame{{ChannelID: id, FrameNum: 2, IsLast: true}, {ChannelID: id, FrameNum: 3, IsLast: true}}},
+		{"frame past last frame", []Frame{{ChannelID: id, FrameNum: 1, IsLast: true}, {ChannelID: id, FrameNum: 2}}},
+		{"last frame before received frame", []Frame{{ChannelID: id, FrameNum: 2}, {ChannelID: id, FrameNum: 1, IsLast: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank := newChannelBank(10)
+			var err error
+			for _, f := range tt.frames {
+				if _, err = bank.addFrame(f, 1); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				t.Error("accepted invalid frames")
+			}
+		})
+	}
+}
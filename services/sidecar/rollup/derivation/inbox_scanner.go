@@ -0,0 +1,161 @@
+package derivation
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Max # of L1 blocks to query logs for at once.
+const maxLogQueryRange = 1000
+
+type ScannerConfig interface {
+	GetSequencerInboxAddr() common.Address
+	GetSeqWindowSize() uint64
+}
+
+type ScannerL1Client interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethTypes.Log, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*ethTypes.Transaction, bool, error)
+}
+
+// Finds the L2 blocks already posted to the sequencer inbox by a given batcher.
+type InboxScanner struct {
+	cfg      ScannerConfig
+	l1Client ScannerL1Client
+	blobs    BlobFetcher // Nil if blob batches can't be scanned.
+	registry *BatchVersionRegistry
+	da       DAProvider
+	batcher  common.Address
+}
+
+func NewInboxScanner(
+	cfg ScannerConfig,
+	l1Client ScannerL1Client,
+	blobs BlobFetcher,
+	registry *BatchVersionRegistry,
+	da DAProvider,
+	batcher common.Address,
+) (*InboxScanner, error) {
+	if err := bridge.EnsureUtilInit(); err != nil {
+		return nil, fmt.Errorf("failed to initialize bridge serialization: %w", err)
+	}
+	return &InboxScanner{cfg: cfg, l1Client: l1Client, blobs: blobs, registry: registry, da: da, batcher: batcher}, nil
+}
+
+// Returns the number of the last L2 block included in a batch appended by the batcher
+// within the last sequencing window of L1 blocks (by scanning `TxBatchAppended` events and their calldata).
+// Returns false if no such batch was found.
+func (s *InboxScanner) LastPostedL2BlockNum(ctx context.Context) (uint64, bool, error) {
+	l1Head, err := s.l1Client.BlockNumber(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get L1 head: %w", err)
+	}
+	var (
+		window      = s.cfg.GetSeqWindowSize()
+		start       uint64
+		channelBank = newChannelBank(window)
+		lastPosted  uint64
+		found       bool
+	)
+	if l1Head > window {
+		start = l1Head - window
+	}
+	log.Info("Scanning inbox for posted batches", "batcher", s.batcher, "from", start, "to", l1Head)
+	for from := start; from <= l1Head; from += maxLogQueryRange {
+		to := from + maxLogQueryRange - 1
+		if to > l1Head {
+			to = l1Head
+		}
+		logs, err := s.l1Client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{s.cfg.GetSequencerInboxAddr()},
+			Topics:    [][]common.Hash{{bridge.InboxEvent(bridge.TxBatchAppendedEventName).ID}},
+		})
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to filter logs (from=%d, to=%d): %w", from, to, err)
+		}
+		for _, l := range logs {
+			data, err := s.batchData(ctx, l)
+			if err != nil {
+				if !errors.As(err, &InvalidBatchError{}) {
+					return 0, false, fmt.Errorf("failed to get batch data (tx=%s): %w", l.TxHash, err)
+				}
+				log.Warn("Skipping invalid batch", "tx_hash", l.TxHash, "err", err)
+				continue
+			}
+			if data == nil {
+				continue
+			}
+			if IsFrame(data) {
+				frame, err := UnmarshalFrame(data)
+				if err != nil {
+					log.Warn("Skipping invalid frame", "tx_hash", l.TxHash, "err", err)
+					continue
+				}
+				channelBank.prune(l.BlockNumber)
+				if data, err = channelBank.addFrame(frame, l.BlockNumber); err != nil || data == nil {
+					continue
+				}
+			}
+			if data, err = s.da.Get(ctx, data); err != nil {
+				log.Warn("Skipping unavailable batch", "tx_hash", l.TxHash, "err", err)
+				continue
+			}
+			decoded, err := s.registry.Decode(data, l.BlockNumber)
+			if err != nil {
+				log.Warn("Skipping invalid batch", "tx_hash", l.TxHash, "err", err)
+				continue
+			}
+			if _, last, ok := l2BlockRange(decoded); ok && (!found || last > lastPosted) {
+				lastPosted, found = last, true
+			}
+		}
+	}
+	return lastPosted, found, nil
+}
+
+// Returns the batch data of the `appendTxBatch` tx that emitted the log, or nil if it wasn't sent by the batcher.
+// For blob txs, the data is read from the blobs; returns an `InvalidBatchError` if they're invalid.
+func (s *InboxScanner) batchData(ctx context.Context, l ethTypes.Log) ([]byte, error) {
+	tx, _, err := s.l1Client.TransactionByHash(ctx, l.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	if !bridge.IsAppendTxBatchTx(tx) {
+		return nil, nil
+	}
+	sender, err := ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+	if sender != s.batcher {
+		return nil, nil
+	}
+	in, err := bridge.UnpackAppendTxBatchInput(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack input: %w", err)
+	}
+	data, ok := in[0].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected input type: %T", in[0])
+	}
+	if len(tx.BlobHashes()) == 0 {
+		return data, nil
+	}
+	header, err := s.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(l.BlockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 header: %w", err)
+	}
+	return fetchBlobData(ctx, s.blobs, header, tx, data)
+}
@@ -0,0 +1,52 @@
+package derivation
+
+import "github.com/ethereum/go-ethereum/params"
+
+// Returns the L1 gas charged for `data` as tx calldata (excluding the base tx cost).
+func CalldataGas(data []byte) uint64 {
+	var gas uint64
+	for _, b := range data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	return gas
+}
+
+// Returns the L1 blob gas charged for `numBlobs` blobs.
+func BlobGas(numBlobs int) uint64 {
+	return uint64(numBlobs) * params.BlobTxBlobGasPerBlob
+}
+
+// Returns the estimated L1 gas of an `appendTxBatch` tx whose batch data is `numBytes` long,
+// `numZeroBytes` of which are zero. Includes the intrinsic tx gas and ABI-encoding overhead.
+func AppendTxBatchGas(numBytes, numZeroBytes uint64) uint64 {
+	var (
+		numNonZeroBytes = numBytes - numZeroBytes
+		// The data is right-padded with zeros to a multiple of 32 bytes.
+		numPaddingBytes = (32 - numBytes%32) % 32
+		// Selector (4 non-zero bytes), plus offset and length words (assumed to have 3 non-zero bytes in total).
+		overheadGas = 7*params.TxDataNonZeroGasEIP2028 + (2*32-3)*params.TxDataZeroGas
+	)
+	return params.TxGas + overheadGas +
+		(numZeroBytes+numPaddingBytes)*params.TxDataZeroGas +
+		numNonZeroBytes*params.TxDataNonZeroGasEIP2028
+}
+
+// Returns the estimated L1 gas of an `appendTxBatch` tx carrying `data` (see `AppendTxBatchGas`).
+func AppendTxBatchDataGas(data []byte) uint64 {
+	return AppendTxBatchGas(uint64(len(data)), countZeroBytes(data))
+}
+
+// Returns the number of zero bytes in `data`.
+func countZeroBytes(data []byte) uint64 {
+	var n uint64
+	for _, b := range data {
+		if b == 0 {
+			n++
+		}
+	}
+	return n
+}
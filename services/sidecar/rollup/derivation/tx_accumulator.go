@@ -0,0 +1,100 @@
+package derivation
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+// Mirrors the sequencer inbox's tx accumulator (see `SequencerInbox.verifyTxInclusion`), which commits to
+// all L2 txs in inbox order as: acc' = keccak256(acc || numTxs || txContextHash || keccak256(encodedTx)).
+// The accumulator after the txs of the i-th batch appended to the inbox is that batch's.
+type TxAccumulator struct {
+	Acc    common.Hash
+	NumTxs uint64
+}
+
+// An L2 tx, as committed to by the accumulator.
+type AccumulatedTx struct {
+	L2BlockNum  uint64
+	ContextHash common.Hash // keccak256(l2BlockCoinbase || l2BlockNumber || l2BlockTimestamp)
+	DataHash    common.Hash // keccak256(encodedTx)
+}
+
+// The txs of a batch appended to the inbox, along with the accumulator before them.
+type AccumulatedBatch struct {
+	Num    uint64 // # of batches appended to the inbox before this one.
+	Before TxAccumulator
+	Txs    []AccumulatedTx
+}
+
+func NewAccumulatedTx(coinbase common.Address, l2BlockNum, l2Timestamp uint64, encodedTx []byte) AccumulatedTx {
+	return AccumulatedTx{
+		L2BlockNum: l2BlockNum,
+		ContextHash: crypto.Keccak256Hash(
+			coinbase.Bytes(),
+			math.U256Bytes(new(big.Int).SetUint64(l2BlockNum)),
+			math.U256Bytes(new(big.Int).SetUint64(l2Timestamp)),
+		),
+		DataHash: crypto.Keccak256Hash(encodedTx),
+	}
+}
+
+func (a TxAccumulator) Add(tx AccumulatedTx) TxAccumulator {
+	return TxAccumulator{
+		Acc: crypto.Keccak256Hash(
+			a.Acc.Bytes(),
+			math.U256Bytes(new(big.Int).SetUint64(a.NumTxs)),
+			tx.ContextHash.Bytes(),
+			tx.DataHash.Bytes(),
+		),
+		NumTxs: a.NumTxs + 1,
+	}
+}
+
+// Returns the accumulator after the first `n` txs of the batch.
+func (b *AccumulatedBatch) AccumulatorAt(n int) TxAccumulator {
+	acc := b.Before
+	for _, tx := range b.Txs[:n] {
+		acc = acc.Add(tx)
+	}
+	return acc
+}
+
+// Returns the index of the tx with the given encoding in the given L2 block, or false if the batch doesn't include it.
+func (b *AccumulatedBatch) IndexOf(l2BlockNum uint64, encodedTx []byte) (int, bool) {
+	dataHash := crypto.Keccak256Hash(encodedTx)
+	for i, tx := range b.Txs {
+		if tx.L2BlockNum == l2BlockNum && tx.DataHash == dataHash {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Returns the proof of inclusion of the batch's `i`-th tx in the inbox, as expected by `verifyTxInclusion`:
+// txContextHash || batchNum || numTxsBefore || numTxsAfterInBatch || accBefore || {txContextHash || txDataHash}*,
+// where the trailing pairs are those of the txs after it in the batch.
+func (b *AccumulatedBatch) InclusionProof(i int) ([]byte, error) {
+	if i < 0 || i >= len(b.Txs) {
+		return nil, fmt.Errorf("tx index %d out of range (batch has %d txs)", i, len(b.Txs))
+	}
+	var (
+		before = b.AccumulatorAt(i)
+		after  = b.Txs[i+1:]
+		proof  = make([]byte, 0, 5*common.HashLength+2*len(after)*common.HashLength)
+	)
+	proof = append(proof, b.Txs[i].ContextHash.Bytes()...)
+	proof = append(proof, math.U256Bytes(new(big.Int).SetUint64(b.Num))...)
+	proof = append(proof, math.U256Bytes(new(big.Int).SetUint64(before.NumTxs))...)
+	proof = append(proof, math.U256Bytes(big.NewInt(int64(len(after))))...)
+	proof = append(proof, before.Acc.Bytes()...)
+	for _, tx := range after {
+		proof = append(proof, tx.ContextHash.Bytes()...)
+		proof = append(proof, tx.DataHash.Bytes()...)
+	}
+	return proof, nil
+}
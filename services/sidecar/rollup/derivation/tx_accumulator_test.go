@@ -0,0 +1,123 @@
+package derivation
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errProofVerificationFailed = errors.New("proof verification failed")
+
+// Port of `SequencerInbox.verifyTxInclusion`, against the given batch accumulators.
+func verifyTxInclusion(encodedTx, proof []byte, accumulators []common.Hash) error {
+	word := func() ([]byte, error) {
+		if len(proof) < common.HashLength {
+			return nil, errors.New("too short")
+		}
+		w := proof[:common.HashLength]
+		proof = proof[common.HashLength:]
+		return w, nil
+	}
+	var words [5][]byte
+	for i := range words {
+		w, err := word()
+		if err != nil {
+			return err
+		}
+		words[i] = w
+	}
+	var (
+		txContextHash      = words[0]
+		batchNum           = new(big.Int).SetBytes(words[1])
+		numTxs             = new(big.Int).SetBytes(words[2])
+		numTxsAfterInBatch = new(big.Int).SetBytes(words[3]).Uint64()
+		acc                = words[4]
+		txDataHash         = crypto.Keccak256(encodedTx)
+	)
+	add := func() {
+		acc = crypto.Keccak256(acc, math.U256Bytes(new(big.Int).Set(numTxs)), txContextHash, txDataHash)
+		numTxs.Add(numTxs, common.Big1)
+	}
+	add()
+	for i := uint64(0); i < numTxsAfterInBatch; i++ {
+		var err error
+		if txContextHash, err = word(); err != nil {
+			return err
+		}
+		if txDataHash, err = word(); err != nil {
+			return err
+		}
+		add()
+	}
+	if !batchNum.IsUint64() || batchNum.Uint64() >= uint64(len(accumulators)) {
+		return errors.New("batch out of range")
+	}
+	if common.BytesToHash(acc) != accumulators[batchNum.Uint64()] {
+		return errProofVerificationFailed
+	}
+	return nil
+}
+
+func TestTxInclusionProof(t *testing.T) {
+	var (
+		coinbase = common.HexToAddress("0x5000000000000000000000000000000000000005")
+		// Encoded txs of each batch's L2 blocks (by number), as appended to the inbox in order.
+		batches = []map[uint64][][]byte{
+			{1: {{0x01}, {0x02}}, 2: {{0x03}}},
+			{3: {{0x04}}},
+			{},
+			{4: {{0x05}}, 5: {{0x06}, {0x07}}},
+		}
+		acc          TxAccumulator
+		accumulated  []*AccumulatedBatch
+		accumulators []common.Hash
+	)
+	for i, blocks := range batches {
+		batch := &AccumulatedBatch{Num: uint64(i), Before: acc}
+		for num := uint64(1); num <= 5; num++ {
+			for _, encodedTx := range blocks[num] {
+				tx := NewAccumulatedTx(coinbase, num, 100+num, encodedTx)
+				batch.Txs = append(batch.Txs, tx)
+				acc = acc.Add(tx)
+			}
+		}
+		accumulated = append(accumulated, batch)
+		accumulators = append(accumulators, acc.Acc)
+	}
+
+	for _, batch := range accumulated {
+		for num, txs := range batches[batch.Num] {
+			for _, encodedTx := range txs {
+				i, ok := batch.IndexOf(num, encodedTx)
+				if !ok {
+					t.Fatalf("batch %d: tx %x of block %d not found", batch.Num, encodedTx, num)
+				}
+				proof, err := batch.InclusionProof(i)
+				if err != nil {
+					t.Fatalf("batch %d: failed to prove tx %d: %v", batch.Num, i, err)
+				}
+				if err := verifyTxInclusion(encodedTx, proof, accumulators); err != nil {
+					t.Errorf("batch %d: proof of tx %d failed verification: %v", batch.Num, i, err)
+				}
+				// The proof must commit to the tx's context (checked against the OSP's by the challenge).
+				want := NewAccumulatedTx(coinbase, num, 100+num, encodedTx).ContextHash
+				if got := common.BytesToHash(proof[:common.HashLength]); got != want {
+					t.Errorf("batch %d: proof of tx %d has context hash %s, want %s", batch.Num, i, got, want)
+				}
+				if err := verifyTxInclusion([]byte{0xff}, proof, accumulators); !errors.Is(err, errProofVerificationFailed) {
+					t.Errorf("batch %d: proof of tx %d verified for another tx (err=%v)", batch.Num, i, err)
+				}
+			}
+		}
+	}
+	if _, ok := accumulated[0].IndexOf(2, []byte{0x01}); ok {
+		t.Error("found tx in the wrong block")
+	}
+	if _, err := accumulated[2].InclusionProof(0); err == nil {
+		t.Error("proved tx of an empty batch")
+	}
+}
@@ -12,6 +12,7 @@ import (
 type BridgeClient struct {
 	*bindings.ISequencerInbox
 	*bindings.IRollup
+	backend bind.ContractBackend
 }
 
 type ProtocolConfig interface {
@@ -28,11 +29,17 @@ func NewBridgeClient(backend bind.ContractBackend, cfg ProtocolConfig) (*BridgeC
 	if err != nil {
 		return nil, err
 	}
-	return &BridgeClient{ISequencerInbox: inbox, IRollup: rollup}, nil
+	return &BridgeClient{ISequencerInbox: inbox, IRollup: rollup, backend: backend}, nil
 }
 
 func (c *BridgeClient) RequireFirstUnresolvedAssertionIsConfirmable(ctx context.Context) error {
-	return c.IRollup.RequireFirstUnresolvedAssertionIsConfirmable(&bind.CallOpts{Pending: false, Context: ctx})
+	err := c.IRollup.RequireFirstUnresolvedAssertionIsConfirmable(&bind.CallOpts{Pending: false, Context: ctx})
+	return DecodeError(err)
+}
+
+func (c *BridgeClient) RequireFirstUnresolvedAssertionIsRejectable(ctx context.Context, stakerAddress common.Address) error {
+	err := c.IRollup.RequireFirstUnresolvedAssertionIsRejectable(&bind.CallOpts{Pending: false, Context: ctx}, stakerAddress)
+	return DecodeError(err)
 }
 
 func (c *BridgeClient) GetStaker(ctx context.Context, addr common.Address) (bindings.IRollupStaker, error) {
@@ -50,3 +57,102 @@ func (c *BridgeClient) GetLastConfirmedAssertionID(ctx context.Context) (*big.In
 func (c *BridgeClient) GetRequiredStakeAmount(ctx context.Context) (*big.Int, error) {
 	return c.IRollup.CurrentRequiredStake(&bind.CallOpts{Pending: false, Context: ctx})
 }
+
+func (c *BridgeClient) GetWithdrawableFunds(ctx context.Context, addr common.Address) (*big.Int, error) {
+	return c.IRollup.WithdrawableFunds(&bind.CallOpts{Pending: false, Context: ctx}, addr)
+}
+
+func (c *BridgeClient) GetMinimumAssertionPeriod(ctx context.Context) (*big.Int, error) {
+	return c.IRollup.MinimumAssertionPeriod(&bind.CallOpts{Pending: false, Context: ctx})
+}
+
+// Returns the AssertionCreated events emitted in L1 blocks [start, end].
+func (c *BridgeClient) GetAssertionCreatedEvents(
+	ctx context.Context,
+	start, end uint64,
+) ([]*bindings.IRollupAssertionCreated, error) {
+	iter, err := c.IRollup.FilterAssertionCreated(&bind.FilterOpts{Start: start, End: &end, Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var events []*bindings.IRollupAssertionCreated
+	for iter.Next() {
+		events = append(events, iter.Event)
+	}
+	return events, iter.Error()
+}
+
+// Returns the StakerStaked events emitted in L1 blocks [start, end].
+func (c *BridgeClient) GetStakerStakedEvents(
+	ctx context.Context,
+	start, end uint64,
+) ([]*bindings.IRollupStakerStaked, error) {
+	iter, err := c.IRollup.FilterStakerStaked(&bind.FilterOpts{Start: start, End: &end, Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var events []*bindings.IRollupStakerStaked
+	for iter.Next() {
+		events = append(events, iter.Event)
+	}
+	return events, iter.Error()
+}
+
+// Returns the AssertionChallenged events emitted in L1 blocks [start, end].
+func (c *BridgeClient) GetAssertionChallengedEvents(
+	ctx context.Context,
+	start, end uint64,
+) ([]*bindings.IRollupAssertionChallenged, error) {
+	iter, err := c.IRollup.FilterAssertionChallenged(&bind.FilterOpts{Start: start, End: &end, Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var events []*bindings.IRollupAssertionChallenged
+	for iter.Next() {
+		events = append(events, iter.Event)
+	}
+	return events, iter.Error()
+}
+
+// ISymChallenge
+
+func (c *BridgeClient) GetCurrentResponder(ctx context.Context, challenge common.Address) (common.Address, error) {
+	caller, err := bindings.NewISymChallengeCaller(challenge, c.backend)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return caller.CurrentResponder(&bind.CallOpts{Pending: false, Context: ctx})
+}
+
+func (c *BridgeClient) GetCurrentResponderTimeLeft(ctx context.Context, challenge common.Address) (*big.Int, error) {
+	caller, err := bindings.NewISymChallengeCaller(challenge, c.backend)
+	if err != nil {
+		return nil, err
+	}
+	return caller.CurrentResponderTimeLeft(&bind.CallOpts{Pending: false, Context: ctx})
+}
+
+// Returns the Bisected events emitted by the challenge since L1 block `start`, in order.
+func (c *BridgeClient) GetBisectedEvents(
+	ctx context.Context,
+	challenge common.Address,
+	start uint64,
+) ([]*bindings.ISymChallengeBisected, error) {
+	filterer, err := bindings.NewISymChallengeFilterer(challenge, c.backend)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := filterer.FilterBisected(&bind.FilterOpts{Start: start, Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var events []*bindings.ISymChallengeBisected
+	for iter.Next() {
+		events = append(events, iter.Event)
+	}
+	return events, iter.Error()
+}
@@ -0,0 +1,152 @@
+package bridge
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+const (
+	rollupContract    = "IRollup"
+	inboxContract     = "ISequencerInbox"
+	challengeContract = "IChallenge"
+)
+
+// IRollup.sol errors
+var (
+	ErrInvalidConfigChange             = newContractError(rollupContract, "InvalidConfigChange")
+	ErrInvalidInboxSize                = newContractError(rollupContract, "InvalidInboxSize")
+	ErrDuplicateAssertion              = newContractError(rollupContract, "DuplicateAssertion")
+	ErrNotStaked                       = newContractError(rollupContract, "NotStaked")
+	ErrInsufficientStake               = newContractError(rollupContract, "InsufficientStake")
+	ErrStakedOnUnconfirmedAssertion    = newContractError(rollupContract, "StakedOnUnconfirmedAssertion")
+	ErrTransferFailed                  = newContractError(rollupContract, "TransferFailed")
+	ErrAssertionOutOfRange             = newContractError(rollupContract, "AssertionOutOfRange")
+	ErrParentAssertionUnstaked         = newContractError(rollupContract, "ParentAssertionUnstaked")
+	ErrMinimumAssertionPeriodNotPassed = newContractError(rollupContract, "MinimumAssertionPeriodNotPassed")
+	ErrPreviousStateHash               = newContractError(rollupContract, "PreviousStateHash")
+	ErrEmptyAssertion                  = newContractError(rollupContract, "EmptyAssertion")
+	ErrInboxReadLimitExceeded          = newContractError(rollupContract, "InboxReadLimitExceeded")
+	ErrWrongOrder                      = newContractError(rollupContract, "WrongOrder")
+	ErrUnproposedAssertion             = newContractError(rollupContract, "UnproposedAssertion")
+	ErrAssertionAlreadyResolved        = newContractError(rollupContract, "AssertionAlreadyResolved")
+	ErrNoUnresolvedAssertion           = newContractError(rollupContract, "NoUnresolvedAssertion")
+	ErrConfirmationPeriodPending       = newContractError(rollupContract, "ConfirmationPeriodPending")
+	ErrNotSiblings                     = newContractError(rollupContract, "NotSiblings")
+	ErrInvalidParent                   = newContractError(rollupContract, "InvalidParent")
+	ErrNotInChallenge                  = newContractError(rollupContract, "NotInChallenge")
+	ErrInDifferentChallenge            = newContractError(rollupContract, "InDifferentChallenge")
+	ErrChallengedStaker                = newContractError(rollupContract, "ChallengedStaker")
+	ErrNotAllStaked                    = newContractError(rollupContract, "NotAllStaked")
+	ErrStakerStakedOnTarget            = newContractError(rollupContract, "StakerStakedOnTarget")
+	ErrStakersPresent                  = newContractError(rollupContract, "StakersPresent")
+	ErrNoStaker                        = newContractError(rollupContract, "NoStaker")
+	ErrRoleAlreadyGranted              = newContractError(rollupContract, "RoleAlreadyGranted")
+	ErrNoRoleToRevoke                  = newContractError(rollupContract, "NoRoleToRevoke")
+)
+
+// ISequencerInbox.sol errors
+var (
+	ErrProofVerificationFailed = newContractError(inboxContract, "ProofVerificationFailed")
+	ErrEmptyBatch              = newContractError(inboxContract, "EmptyBatch")
+	ErrTxBatchDataUnderflow    = newContractError(inboxContract, "TxBatchDataUnderflow")
+	ErrTxBatchDataOverflow     = newContractError(inboxContract, "TxBatchDataOverflow")
+	ErrTxBatchVersionIncorrect = newContractError(inboxContract, "TxBatchVersionIncorrect")
+)
+
+// IChallenge.sol errors
+var (
+	ErrNotYourTurn        = newContractError(challengeContract, "NotYourTurn")
+	ErrDeadlineExpired    = newContractError(challengeContract, "DeadlineExpired")
+	ErrDeadlineNotPassed  = newContractError(challengeContract, "DeadlineNotPassed")
+	ErrNotInitialized     = newContractError(challengeContract, "NotInitialized")
+	ErrAlreadyInitialized = newContractError(challengeContract, "AlreadyInitialized")
+)
+
+// A custom error reverted by a protocol contract.
+// Under `errors.Is`, matches the `Err*` value with the same contract and name (regardless of args).
+type ContractError struct {
+	Contract string
+	Name     string
+	Args     []any
+	Err      error // JSON-RPC error the revert was decoded from (nil for `Err*` values).
+}
+
+// All `Err*` values, by contract and name.
+var contractErrors = map[string]map[string]*ContractError{}
+
+func newContractError(contract, name string) *ContractError {
+	err := &ContractError{Contract: contract, Name: name}
+	if contractErrors[contract] == nil {
+		contractErrors[contract] = map[string]*ContractError{}
+	}
+	contractErrors[contract][name] = err
+	return err
+}
+
+func (e *ContractError) Error() string {
+	if len(e.Args) == 0 {
+		return fmt.Sprintf("%s reverted with %s()", e.Contract, e.Name)
+	}
+	return fmt.Sprintf("%s reverted with %s%v", e.Contract, e.Name, e.Args)
+}
+
+func (e *ContractError) Unwrap() error { return e.Err }
+
+func (e *ContractError) Is(target error) bool {
+	t, ok := target.(*ContractError)
+	return ok && t.Contract == e.Contract && t.Name == e.Name
+}
+
+// Decodes the revert data carried by a JSON-RPC error into a `*ContractError`, by matching its
+// selector against the errors of the IRollup, ISequencerInbox and IChallenge ABIs.
+// Returns `err` unchanged if it carries no revert data, or the data matches no known error.
+func DecodeError(err error) error {
+	data, ok := revertData(err)
+	if !ok || len(data) < MethodNumBytes || EnsureUtilInit() != nil {
+		return err
+	}
+	contracts := []struct {
+		name string
+		abi  *abi.ABI
+	}{
+		{rollupContract, serializationUtil.rollupAbi},
+		{inboxContract, serializationUtil.inboxAbi},
+		{challengeContract, serializationUtil.challengeAbi},
+	}
+	for _, c := range contracts {
+		for name, abiErr := range c.abi.Errors {
+			if !bytes.Equal(data[:MethodNumBytes], abiErr.ID[:MethodNumBytes]) {
+				continue
+			}
+			unpacked, unpackErr := abiErr.Unpack(data)
+			if unpackErr != nil {
+				return err
+			}
+			args, _ := unpacked.([]any)
+			return &ContractError{Contract: c.name, Name: name, Args: args, Err: err}
+		}
+	}
+	return err
+}
+
+// Extracts revert data from a JSON-RPC error, if any.
+func revertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	hex, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, err := hexutil.Decode(hex)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
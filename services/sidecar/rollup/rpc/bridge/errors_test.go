@@ -0,0 +1,140 @@
+package bridge
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// A JSON-RPC error carrying (revert) data.
+type testDataError struct{ data any }
+
+func (e testDataError) Error() string  { return "execution reverted" }
+func (e testDataError) ErrorData() any { return e.data }
+
+// Returns the revert data of a custom error with the given args.
+func revertDataOf(t *testing.T, contractAbi *abi.ABI, name string, args ...any) string {
+	t.Helper()
+	abiErr, ok := contractAbi.Errors[name]
+	if !ok {
+		t.Fatalf("unknown error: %s", name)
+	}
+	packed, err := abiErr.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatalf("failed to pack args: %v", err)
+	}
+	return hexutil.Encode(append(abiErr.ID[:MethodNumBytes], packed...))
+}
+
+func TestDecodeError(t *testing.T) {
+	if err := EnsureUtilInit(); err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+	var (
+		rollupAbi = serializationUtil.rollupAbi
+		c1, c2    = common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	)
+	tests := []struct {
+		name     string
+		err      error
+		want     error
+		wantArgs []any
+	}{
+		{
+			name: "rollup error",
+			err:  testDataError{revertDataOf(t, rollupAbi, "NoUnresolvedAssertion")},
+			want: ErrNoUnresolvedAssertion,
+		},
+		{
+			name:     "rollup error with args",
+			err:      testDataError{revertDataOf(t, rollupAbi, "InDifferentChallenge", c1, c2)},
+			want:     ErrInDifferentChallenge,
+			wantArgs: []any{c1, c2},
+		},
+		{
+			name: "inbox error",
+			err:  testDataError{revertDataOf(t, serializationUtil.inboxAbi, "EmptyBatch")},
+			want: ErrEmptyBatch,
+		},
+		{
+			name: "challenge error",
+			err:  testDataError{revertDataOf(t, serializationUtil.challengeAbi, "NotYourTurn")},
+			want: ErrNotYourTurn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := DecodeError(tt.err)
+			if !errors.Is(decoded, tt.want) {
+				t.Fatalf("got %v, want %v", decoded, tt.want)
+			}
+			// The original error is kept.
+			if !errors.Is(decoded, tt.err) {
+				t.Errorf("decoded error %v doesn't wrap %v", decoded, tt.err)
+			}
+			var contractErr *ContractError
+			if !errors.As(decoded, &contractErr) {
+				t.Fatalf("got %T, want *ContractError", decoded)
+			}
+			if len(contractErr.Args) != len(tt.wantArgs) {
+				t.Fatalf("got args %v, want %v", contractErr.Args, tt.wantArgs)
+			}
+			for i := range tt.wantArgs {
+				if contractErr.Args[i] != tt.wantArgs[i] {
+					t.Errorf("got args %v, want %v", contractErr.Args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeErrorUnchanged(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"nil", nil},
+		{"no data", errors.New("execution reverted")},
+		{"non-string data", testDataError{42}},
+		{"non-hex data", testDataError{"0xzz"}},
+		{"short data", testDataError{"0x8e93"}},
+		{"unknown selector", testDataError{"0xdeadbeef"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeError(tt.err); got != tt.err {
+				t.Errorf("got %v, want %v unchanged", got, tt.err)
+			}
+		})
+	}
+}
+
+// The `Err*` values are maintained by hand, so must be checked against the contract ABIs.
+func TestContractErrorsMatchABIs(t *testing.T) {
+	if err := EnsureUtilInit(); err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+	abis := map[string]*abi.ABI{
+		rollupContract:    serializationUtil.rollupAbi,
+		inboxContract:     serializationUtil.inboxAbi,
+		challengeContract: serializationUtil.challengeAbi,
+	}
+	for contract, contractAbi := range abis {
+		for name := range contractAbi.Errors {
+			if _, ok := contractErrors[contract][name]; !ok {
+				t.Errorf("no Err* value for %s error %s", contract, name)
+			}
+		}
+		for name := range contractErrors[contract] {
+			if _, ok := contractAbi.Errors[name]; !ok {
+				t.Errorf("Err* value for %s error %s not in the ABI", contract, name)
+			}
+		}
+	}
+	if len(contractErrors) != len(abis) {
+		t.Errorf("got Err* values for %d contracts, want %d", len(contractErrors), len(abis))
+	}
+}
@@ -0,0 +1,291 @@
+package bridge
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/specularL2/specular/services/sidecar/bindings"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/api"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+const (
+	rollupWatchInterval = 12 * time.Second
+	// Max # of L1 blocks to query logs for at once.
+	maxLogQueryRange = 1000
+)
+
+type RollupEventKind uint8
+
+const (
+	AssertionCreated RollupEventKind = iota
+	AssertionChallenged
+	AssertionConfirmed
+	AssertionRejected
+	StakerStaked
+)
+
+var rollupEventNames = map[RollupEventKind]string{
+	AssertionCreated:    "AssertionCreated",
+	AssertionChallenged: "AssertionChallenged",
+	AssertionConfirmed:  "AssertionConfirmed",
+	AssertionRejected:   "AssertionRejected",
+	StakerStaked:        "StakerStaked",
+}
+
+func (k RollupEventKind) String() string { return rollupEventNames[k] }
+
+// An assertion lifecycle event emitted by the Rollup contract.
+// Exactly one of the typed event fields is set, according to `Kind`.
+type RollupEvent struct {
+	Kind RollupEventKind
+	// Set if the event was published before, but its L1 block has since been reorged out.
+	Removed             bool
+	AssertionCreated    *bindings.IRollupAssertionCreated
+	AssertionChallenged *bindings.IRollupAssertionChallenged
+	AssertionConfirmed  *bindings.IRollupAssertionConfirmed
+	AssertionRejected   *bindings.IRollupAssertionRejected
+	StakerStaked        *bindings.IRollupStakerStaked
+	Raw                 ethTypes.Log
+}
+
+type watcherEthState interface {
+	Head() types.BlockID
+	Finalized() types.BlockID
+}
+
+// Watches the L1 heads tracked by an `EthState` for Rollup contract events, publishing them to `Broker`.
+// Events in L1 blocks that get reorged out are re-published with `Removed` set, newest first.
+type RollupWatcher struct {
+	Broker *utils.Broker[RollupEvent]
+
+	client     *BridgeClient
+	l1State    watcherEthState
+	rollupAddr common.Address
+	kinds      map[common.Hash]RollupEventKind // Event kinds by topic.
+
+	start  uint64         // First L1 block watched.
+	tip    types.BlockID  // Last L1 block scanned.
+	blocks []watchedBlock // Non-finalized L1 blocks with events, oldest first.
+}
+
+type watchedBlock struct {
+	id     types.BlockID
+	events []RollupEvent
+}
+
+func NewRollupWatcher(client *BridgeClient, l1State watcherEthState, cfg ProtocolConfig) (*RollupWatcher, error) {
+	if err := EnsureUtilInit(); err != nil {
+		return nil, err
+	}
+	kinds := make(map[common.Hash]RollupEventKind, len(rollupEventNames))
+	for kind, name := range rollupEventNames {
+		kinds[rollupEvent(name).ID] = kind
+	}
+	return &RollupWatcher{
+		Broker:     utils.NewBroker[RollupEvent](),
+		client:     client,
+		l1State:    l1State,
+		rollupAddr: cfg.GetRollupAddr(),
+		kinds:      kinds,
+	}, nil
+}
+
+// Starts watching from the L1 block after the last confirmed assertion was proposed.
+func (w *RollupWatcher) Start(ctx context.Context, eg api.ErrGroup) error {
+	lastConfirmedID, err := w.client.GetLastConfirmedAssertionID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get last confirmed assertion ID: %w", err)
+	}
+	lastConfirmed, err := w.client.GetAssertion(ctx, lastConfirmedID)
+	if err != nil {
+		return fmt.Errorf("failed to get last confirmed assertion: %w", err)
+	}
+	w.start = lastConfirmed.ProposalTime.Uint64() + 1
+	w.tip = types.NewBlockID(w.start-1, common.Hash{})
+	sub := event.NewSubscription(func(unsub <-chan struct{}) error {
+		ticker := time.NewTicker(rollupWatchInterval)
+		defer ticker.Stop()
+		for {
+			if err := w.poll(ctx); err != nil {
+				log.Errorf("Failed to poll rollup events: %w", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-unsub:
+				return nil
+			}
+		}
+	})
+	eg.Go(func() error { return w.Broker.Start(ctx, sub) })
+	log.Info("Rollup watcher started", "start", w.start)
+	return nil
+}
+
+// Publishes events in L1 blocks up to the current head, first handling any reorg.
+// Each range of blocks is fully fetched before any of its events are published and the tip advanced past it,
+// so that failures can't cause events to be published twice.
+func (w *RollupWatcher) poll(ctx context.Context) error {
+	if err := w.handleReorg(ctx); err != nil {
+		return fmt.Errorf("failed to handle reorg: %w", err)
+	}
+	head := w.l1State.Head()
+	for w.tip.GetNumber() < head.GetNumber() {
+		from := w.tip.GetNumber() + 1
+		to := from + maxLogQueryRange - 1
+		if to > head.GetNumber() {
+			to = head.GetNumber()
+		}
+		events, err := w.fetch(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		tip := head
+		if to != head.GetNumber() {
+			header, err := w.client.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+			if err != nil {
+				return fmt.Errorf("failed to get header (num=%d): %w", to, err)
+			}
+			tip = types.NewBlockIDFromHeader(header)
+		}
+		for _, ev := range events {
+			w.record(ev)
+			log.Trace("Publishing rollup event", "kind", ev.Kind, "l1Block#", ev.Raw.BlockNumber)
+			w.Broker.Publish(ev)
+		}
+		w.tip = tip
+	}
+	w.prune()
+	return nil
+}
+
+// Returns the events in L1 blocks [from, to], in order.
+func (w *RollupWatcher) fetch(ctx context.Context, from, to uint64) ([]RollupEvent, error) {
+	logs, err := w.client.backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{w.rollupAddr},
+		Topics:    [][]common.Hash{w.topics()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter logs (from=%d, to=%d): %w", from, to, err)
+	}
+	events := make([]RollupEvent, 0, len(logs))
+	for _, l := range logs {
+		ev, err := w.parse(l)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse log (tx=%s, idx=%d): %w", l.TxHash, l.Index, err)
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// If the last scanned L1 block was reorged out, rewinds to the last canonical block with events,
+// publishing the events of all reorged-out blocks as removed.
+func (w *RollupWatcher) handleReorg(ctx context.Context) error {
+	if w.tip.GetHash() == (common.Hash{}) {
+		return nil
+	}
+	canonical, err := w.isCanonical(ctx, w.tip)
+	if err != nil || canonical {
+		return err
+	}
+	log.Warn("L1 reorg detected, rewinding rollup watcher", "tip", w.tip)
+	for len(w.blocks) > 0 {
+		last := w.blocks[len(w.blocks)-1]
+		canonical, err := w.isCanonical(ctx, last.id)
+		if err != nil {
+			return err
+		}
+		if canonical {
+			break
+		}
+		for i := len(last.events) - 1; i >= 0; i-- {
+			ev := last.events[i]
+			ev.Removed = true
+			w.Broker.Publish(ev)
+		}
+		w.blocks = w.blocks[:len(w.blocks)-1]
+	}
+	if len(w.blocks) > 0 {
+		w.tip = w.blocks[len(w.blocks)-1].id
+	} else if finalized := w.l1State.Finalized(); finalized.GetNumber() >= w.start {
+		w.tip = finalized
+	} else {
+		w.tip = types.NewBlockID(w.start-1, common.Hash{})
+	}
+	log.Info("Rewound rollup watcher", "tip", w.tip)
+	return nil
+}
+
+func (w *RollupWatcher) isCanonical(ctx context.Context, id types.BlockID) (bool, error) {
+	header, err := w.client.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(id.GetNumber()))
+	if err != nil {
+		return false, fmt.Errorf("failed to get header (num=%d): %w", id.GetNumber(), err)
+	}
+	return header.Hash() == id.GetHash(), nil
+}
+
+// Tracks a published event, in case its block is reorged out.
+func (w *RollupWatcher) record(ev RollupEvent) {
+	id := types.NewBlockID(ev.Raw.BlockNumber, ev.Raw.BlockHash)
+	if n := len(w.blocks); n > 0 && w.blocks[n-1].id == id {
+		w.blocks[n-1].events = append(w.blocks[n-1].events, ev)
+		return
+	}
+	w.blocks = append(w.blocks, watchedBlock{id, []RollupEvent{ev}})
+}
+
+// Stops tracking events in finalized blocks.
+func (w *RollupWatcher) prune() {
+	finalized := w.l1State.Finalized().GetNumber()
+	for len(w.blocks) > 0 && w.blocks[0].id.GetNumber() <= finalized {
+		w.blocks = w.blocks[1:]
+	}
+}
+
+func (w *RollupWatcher) topics() []common.Hash {
+	topics := make([]common.Hash, 0, len(w.kinds))
+	for topic := range w.kinds {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+func (w *RollupWatcher) parse(l ethTypes.Log) (RollupEvent, error) {
+	if len(l.Topics) == 0 {
+		return RollupEvent{}, fmt.Errorf("log has no topics")
+	}
+	kind, ok := w.kinds[l.Topics[0]]
+	if !ok {
+		return RollupEvent{}, fmt.Errorf("unknown event topic: %s", l.Topics[0])
+	}
+	var (
+		ev  = RollupEvent{Kind: kind, Raw: l}
+		err error
+	)
+	switch kind {
+	case AssertionCreated:
+		ev.AssertionCreated, err = w.client.IRollup.ParseAssertionCreated(l)
+	case AssertionChallenged:
+		ev.AssertionChallenged, err = w.client.IRollup.ParseAssertionChallenged(l)
+	case AssertionConfirmed:
+		ev.AssertionConfirmed, err = w.client.IRollup.ParseAssertionConfirmed(l)
+	case AssertionRejected:
+		ev.AssertionRejected, err = w.client.IRollup.ParseAssertionRejected(l)
+	case StakerStaked:
+		ev.StakerStaked, err = w.client.IRollup.ParseStakerStaked(l)
+	}
+	return ev, err
+}
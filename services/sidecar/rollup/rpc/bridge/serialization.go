@@ -1,6 +1,7 @@
 package bridge
 
 import (
+	"bytes"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -16,15 +17,19 @@ const (
 	AppendTxBatchFnName = "appendTxBatch"
 	// IRollup.sol functions
 	StakeFnName                           = "stake"
+	UnstakeFnName                         = "unstake"
+	RemoveStakeFnName                     = "removeStake"
 	AdvanceStakeFnName                    = "advanceStake"
+	WithdrawFnName                        = "withdraw"
 	CreateAssertionFnName                 = "createAssertion"
 	ConfirmFirstUnresolvedAssertionFnName = "confirmFirstUnresolvedAssertion"
 	RejectFirstUnresolvedAssertionFnName  = "rejectFirstUnresolvedAssertion"
+	ChallengeAssertionFnName              = "challengeAssertion"
 	// IChallenge.sol functions
-	bisectExecutionFn = "bisectExecution"
-	// IRollup.sol errors (TODO: figure out a work-around to hardcoding)
-	NoUnresolvedAssertionErr     = "Error: VM Exception while processing transaction: reverted with custom error 'NoUnresolvedAssertion()'"
-	ConfirmationPeriodPendingErr = "Error: VM Exception while processing transaction: reverted with custom error 'ConfirmationPeriodPending()'"
+	initializeChallengeLengthFn = "initializeChallengeLength"
+	bisectExecutionFn           = "bisectExecution"
+	verifyOneStepProofFn        = "verifyOneStepProof"
+	timeoutFn                   = "timeout"
 	// L1Oracle.sol functions
 	SetL1OracleValues = "setL1OracleValues"
 
@@ -45,6 +50,15 @@ type bridgeSerializationUtil struct {
 
 func InboxEvent(name string) abi.Event { return serializationUtil.inboxAbi.Events[name] }
 
+// Returns true iff the tx calls `appendTxBatch` (based on its method selector).
+func IsAppendTxBatchTx(tx *types.Transaction) bool {
+	data := tx.Data()
+	if len(data) < MethodNumBytes {
+		return false
+	}
+	return bytes.Equal(data[:MethodNumBytes], serializationUtil.inboxAbi.Methods[AppendTxBatchFnName].ID)
+}
+
 func UnpackAppendTxBatchInput(tx *types.Transaction) ([]any, error) {
 	return serializationUtil.inboxAbi.Methods[AppendTxBatchFnName].Inputs.Unpack(tx.Data()[MethodNumBytes:])
 }
@@ -55,6 +69,8 @@ func packAppendTxBatchInput(batch []byte) ([]byte, error) {
 
 // IRollup.sol
 
+func rollupEvent(name string) abi.Event { return serializationUtil.rollupAbi.Events[name] }
+
 func UnpackCreateAssertionInput(tx *types.Transaction) (common.Hash, *big.Int, error) {
 	in, err := serializationUtil.rollupAbi.Unpack(CreateAssertionFnName, tx.Data()[MethodNumBytes:])
 	if err != nil {
@@ -65,18 +81,90 @@ func UnpackCreateAssertionInput(tx *types.Transaction) (common.Hash, *big.Int, e
 	return vmHash, inboxSize, err
 }
 
+// Returns the amount passed to `unstake`.
+func UnpackUnstakeInput(tx *types.Transaction) (*big.Int, error) {
+	in, err := unpackRollupInput(tx, UnstakeFnName)
+	if err != nil {
+		return nil, err
+	}
+	return in[0].(*big.Int), nil
+}
+
+// Returns the staker address passed to `removeStake`.
+func UnpackRemoveStakeInput(tx *types.Transaction) (common.Address, error) {
+	in, err := unpackRollupInput(tx, RemoveStakeFnName)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return in[0].(common.Address), nil
+}
+
+// Returns the assertion ID passed to `advanceStake`.
+func UnpackAdvanceStakeInput(tx *types.Transaction) (*big.Int, error) {
+	in, err := unpackRollupInput(tx, AdvanceStakeFnName)
+	if err != nil {
+		return nil, err
+	}
+	return in[0].(*big.Int), nil
+}
+
+// Returns the players and assertion IDs passed to `challengeAssertion`.
+func UnpackChallengeAssertionInput(tx *types.Transaction) ([2]common.Address, [2]*big.Int, error) {
+	if !hasMethodID(tx, serializationUtil.rollupAbi.Methods[ChallengeAssertionFnName].ID) {
+		return [2]common.Address{}, [2]*big.Int{}, fmt.Errorf("tx does not call %s", ChallengeAssertionFnName)
+	}
+	in, err := serializationUtil.rollupAbi.Methods[ChallengeAssertionFnName].Inputs.Unpack(tx.Data()[MethodNumBytes:])
+	if err != nil {
+		return [2]common.Address{}, [2]*big.Int{}, err
+	}
+	return in[0].([2]common.Address), in[1].([2]*big.Int), nil
+}
+
+// IChallenge.sol
+
+// Returns true iff the tx calls `bisectExecution` (based on its method selector).
+func IsBisectExecutionTx(tx *types.Transaction) bool {
+	return hasMethodID(tx, serializationUtil.challengeAbi.Methods[bisectExecutionFn].ID)
+}
+
 func UnpackBisectExecutionInput(tx *types.Transaction) ([]any, error) {
 	return serializationUtil.challengeAbi.Methods[bisectExecutionFn].Inputs.Unpack(tx.Data()[MethodNumBytes:])
 }
 
+// Returns the bisection passed to `bisectExecution`.
+func UnpackBisection(tx *types.Transaction) ([]common.Hash, error) {
+	in, err := UnpackBisectExecutionInput(tx)
+	if err != nil {
+		return nil, err
+	}
+	raw := in[0].([][32]byte)
+	bisection := make([]common.Hash, len(raw))
+	for i := range raw {
+		bisection[i] = raw[i]
+	}
+	return bisection, nil
+}
+
 func packStakeInput() ([]byte, error) {
 	return serializationUtil.rollupAbi.Pack(StakeFnName)
 }
 
+func packUnstakeInput(stakeAmount *big.Int) ([]byte, error) {
+	return serializationUtil.rollupAbi.Pack(UnstakeFnName, stakeAmount)
+}
+
+func packRemoveStakeInput(stakerAddress common.Address) ([]byte, error) {
+	return serializationUtil.rollupAbi.Pack(RemoveStakeFnName, stakerAddress)
+}
+
 func packAdvanceStakeInput(assertionID *big.Int) ([]byte, error) {
 	return serializationUtil.rollupAbi.Pack(AdvanceStakeFnName, assertionID)
 }
 
+func packWithdrawInput() ([]byte, error) {
+	return serializationUtil.rollupAbi.Pack(WithdrawFnName)
+}
+
 func packCreateAssertionInput(vmHash common.Hash, blockNum *big.Int) ([]byte, error) {
 	return serializationUtil.rollupAbi.Pack(CreateAssertionFnName, vmHash, blockNum)
 }
@@ -89,6 +177,78 @@ func packRejectFirstUnresolvedAssertionInput(stakerAddress common.Address) ([]by
 	return serializationUtil.rollupAbi.Pack(RejectFirstUnresolvedAssertionFnName, stakerAddress)
 }
 
+func packChallengeAssertionInput(players [2]common.Address, assertionIDs [2]*big.Int) ([]byte, error) {
+	return serializationUtil.rollupAbi.Pack(ChallengeAssertionFnName, players, assertionIDs)
+}
+
+func packInitializeChallengeLengthInput(numSteps *big.Int) ([]byte, error) {
+	return serializationUtil.challengeAbi.Pack(initializeChallengeLengthFn, numSteps)
+}
+
+func packBisectExecutionInput(
+	bisection []common.Hash,
+	challengedSegmentIndex *big.Int,
+	prevBisection []common.Hash,
+	prevChallengedSegmentStart *big.Int,
+	prevChallengedSegmentLength *big.Int,
+) ([]byte, error) {
+	return serializationUtil.challengeAbi.Pack(
+		bisectExecutionFn,
+		toBytes32s(bisection),
+		challengedSegmentIndex,
+		toBytes32s(prevBisection),
+		prevChallengedSegmentStart,
+		prevChallengedSegmentLength,
+	)
+}
+
+func packVerifyOneStepProofInput(
+	oneStepProof []byte,
+	txInclusionProof []byte,
+	verificationCtx bindings.VerificationContextLibRawContext,
+	challengedStepIndex *big.Int,
+	prevBisection []common.Hash,
+	prevChallengedSegmentStart *big.Int,
+	prevChallengedSegmentLength *big.Int,
+) ([]byte, error) {
+	return serializationUtil.challengeAbi.Pack(
+		verifyOneStepProofFn,
+		oneStepProof,
+		txInclusionProof,
+		verificationCtx,
+		challengedStepIndex,
+		toBytes32s(prevBisection),
+		prevChallengedSegmentStart,
+		prevChallengedSegmentLength,
+	)
+}
+
+func packTimeoutInput() ([]byte, error) {
+	return serializationUtil.challengeAbi.Pack(timeoutFn)
+}
+
+func toBytes32s(hashes []common.Hash) [][32]byte {
+	out := make([][32]byte, len(hashes))
+	for i := range hashes {
+		out[i] = hashes[i]
+	}
+	return out
+}
+
+// Unpacks the inputs of a tx calling the Rollup method `name`.
+func unpackRollupInput(tx *types.Transaction, name string) ([]any, error) {
+	method := serializationUtil.rollupAbi.Methods[name]
+	if !hasMethodID(tx, method.ID) {
+		return nil, fmt.Errorf("tx does not call %s", name)
+	}
+	return method.Inputs.Unpack(tx.Data()[MethodNumBytes:])
+}
+
+func hasMethodID(tx *types.Transaction, id []byte) bool {
+	data := tx.Data()
+	return len(data) >= MethodNumBytes && bytes.Equal(data[:MethodNumBytes], id)
+}
+
 // L1Oracle.sol
 
 func UnpackL1OracleInput(tx *types.Transaction) (uint64, uint64, uint64, common.Hash, common.Hash, error) {
@@ -105,7 +265,7 @@ func UnpackL1OracleInput(tx *types.Transaction) (uint64, uint64, uint64, common.
 }
 
 // Ensures serializationUtil is initialized. Must be called prior to the methods above.
-func ensureUtilInit() error {
+func EnsureUtilInit() error {
 	if serializationUtil == nil {
 		inboxAbi, err := bindings.ISequencerInboxMetaData.GetAbi()
 		if err != nil {
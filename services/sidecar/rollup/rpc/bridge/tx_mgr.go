@@ -6,6 +6,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/specularL2/specular/services/sidecar/bindings"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
 )
 
@@ -17,6 +19,9 @@ type TxManager struct {
 
 type EthTxManager interface {
 	Send(ctx context.Context, candidate txmgr.TxCandidate) (*types.Receipt, error)
+	SendAsync(ctx context.Context, candidate txmgr.TxCandidate, resultCh chan<- txmgr.SendResult) error
+	SuggestGasPriceCaps(ctx context.Context) (*big.Int, *big.Int, *big.Int, error)
+	LatestNonce(ctx context.Context) (uint64, error)
 }
 
 type bridgeConfig interface {
@@ -25,22 +30,70 @@ type bridgeConfig interface {
 }
 
 func NewTxManager(txMgr EthTxManager, cfg bridgeConfig) (*TxManager, error) {
-	err := ensureUtilInit()
+	err := EnsureUtilInit()
 	return &TxManager{EthTxManager: txMgr, cfg: cfg}, err
 }
 
 // ISequencerInbox
 
+// Appends a batch, sending the tx with the given nonce (replacing any pending tx with that nonce).
 func (m *TxManager) AppendTxBatch(
 	ctx context.Context,
 	batch []byte,
+	nonce uint64,
 ) (*types.Receipt, error) {
 	data, err := packAppendTxBatchInput(batch)
 	if err != nil {
 		return nil, err
 	}
 	addr := m.cfg.GetSequencerInboxAddr()
-	return m.Send(ctx, txmgr.TxCandidate{TxData: data, To: &addr})
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &addr, Nonce: &nonce})
+}
+
+// Appends a batch carried in blobs (EIP-4844). Only `header` is passed as calldata.
+func (m *TxManager) AppendBlobTxBatch(
+	ctx context.Context,
+	header []byte,
+	blobs []kzg4844.Blob,
+	nonce uint64,
+) (*types.Receipt, error) {
+	data, err := packAppendTxBatchInput(header)
+	if err != nil {
+		return nil, err
+	}
+	addr := m.cfg.GetSequencerInboxAddr()
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &addr, Blobs: blobs, Nonce: &nonce})
+}
+
+// Like `AppendTxBatch`, but returns once the tx is signed, delivering the outcome on `resultCh`.
+func (m *TxManager) AppendTxBatchAsync(
+	ctx context.Context,
+	batch []byte,
+	nonce uint64,
+	resultCh chan<- txmgr.SendResult,
+) error {
+	data, err := packAppendTxBatchInput(batch)
+	if err != nil {
+		return err
+	}
+	addr := m.cfg.GetSequencerInboxAddr()
+	return m.SendAsync(ctx, txmgr.TxCandidate{TxData: data, To: &addr, Nonce: &nonce}, resultCh)
+}
+
+// Like `AppendBlobTxBatch`, but returns once the tx is signed, delivering the outcome on `resultCh`.
+func (m *TxManager) AppendBlobTxBatchAsync(
+	ctx context.Context,
+	header []byte,
+	blobs []kzg4844.Blob,
+	nonce uint64,
+	resultCh chan<- txmgr.SendResult,
+) error {
+	data, err := packAppendTxBatchInput(header)
+	if err != nil {
+		return err
+	}
+	addr := m.cfg.GetSequencerInboxAddr()
+	return m.SendAsync(ctx, txmgr.TxCandidate{TxData: data, To: &addr, Blobs: blobs, Nonce: &nonce}, resultCh)
 }
 
 // IRollup
@@ -50,7 +103,31 @@ func (m *TxManager) Stake(ctx context.Context, stakeAmount *big.Int) (*types.Rec
 	if err != nil {
 		return nil, err
 	}
-	return m.sendRollupTx(ctx, data, stakeAmount.Uint64())
+	return m.sendRollupTx(ctx, data, stakeAmount)
+}
+
+func (m *TxManager) Unstake(ctx context.Context, stakeAmount *big.Int) (*types.Receipt, error) {
+	data, err := packUnstakeInput(stakeAmount)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendRollupTx(ctx, data, nil)
+}
+
+func (m *TxManager) RemoveStake(ctx context.Context, stakerAddress common.Address) (*types.Receipt, error) {
+	data, err := packRemoveStakeInput(stakerAddress)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendRollupTx(ctx, data, nil)
+}
+
+func (m *TxManager) Withdraw(ctx context.Context) (*types.Receipt, error) {
+	data, err := packWithdrawInput()
+	if err != nil {
+		return nil, err
+	}
+	return m.sendRollupTx(ctx, data, nil)
 }
 
 func (m *TxManager) AdvanceStake(ctx context.Context, assertionID *big.Int) (*types.Receipt, error) {
@@ -58,7 +135,7 @@ func (m *TxManager) AdvanceStake(ctx context.Context, assertionID *big.Int) (*ty
 	if err != nil {
 		return nil, err
 	}
-	return m.sendRollupTx(ctx, data, 0)
+	return m.sendRollupTx(ctx, data, nil)
 }
 
 func (m *TxManager) CreateAssertion(ctx context.Context, vmHash common.Hash, blockNum *big.Int) (*types.Receipt, error) {
@@ -66,7 +143,7 @@ func (m *TxManager) CreateAssertion(ctx context.Context, vmHash common.Hash, blo
 	if err != nil {
 		return nil, err
 	}
-	return m.sendRollupTx(ctx, data, 0)
+	return m.sendRollupTx(ctx, data, nil)
 }
 
 func (m *TxManager) ConfirmFirstUnresolvedAssertion(ctx context.Context) (*types.Receipt, error) {
@@ -74,7 +151,7 @@ func (m *TxManager) ConfirmFirstUnresolvedAssertion(ctx context.Context) (*types
 	if err != nil {
 		return nil, err
 	}
-	return m.sendRollupTx(ctx, data, 0)
+	return m.sendRollupTx(ctx, data, nil)
 }
 
 func (m *TxManager) RejectFirstUnresolvedAssertion(ctx context.Context, stakerAddress common.Address) (*types.Receipt, error) {
@@ -82,10 +159,98 @@ func (m *TxManager) RejectFirstUnresolvedAssertion(ctx context.Context, stakerAd
 	if err != nil {
 		return nil, err
 	}
-	return m.sendRollupTx(ctx, data, 0)
+	return m.sendRollupTx(ctx, data, nil)
 }
 
-func (m *TxManager) sendRollupTx(ctx context.Context, data []byte, value uint64) (*types.Receipt, error) {
+func (m *TxManager) ChallengeAssertion(
+	ctx context.Context,
+	players [2]common.Address,
+	assertionIDs [2]*big.Int,
+) (*types.Receipt, error) {
+	data, err := packChallengeAssertionInput(players, assertionIDs)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendRollupTx(ctx, data, nil)
+}
+
+// ISymChallenge
+
+func (m *TxManager) InitializeChallengeLength(
+	ctx context.Context,
+	challenge common.Address,
+	numSteps *big.Int,
+) (*types.Receipt, error) {
+	data, err := packInitializeChallengeLengthInput(numSteps)
+	if err != nil {
+		return nil, err
+	}
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &challenge})
+}
+
+func (m *TxManager) BisectExecution(
+	ctx context.Context,
+	challenge common.Address,
+	bisection []common.Hash,
+	challengedSegmentIndex *big.Int,
+	prevBisection []common.Hash,
+	prevChallengedSegmentStart *big.Int,
+	prevChallengedSegmentLength *big.Int,
+) (*types.Receipt, error) {
+	data, err := packBisectExecutionInput(
+		bisection, challengedSegmentIndex, prevBisection, prevChallengedSegmentStart, prevChallengedSegmentLength,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &challenge})
+}
+
+func (m *TxManager) VerifyOneStepProof(
+	ctx context.Context,
+	challenge common.Address,
+	oneStepProof []byte,
+	txInclusionProof []byte,
+	verificationCtx bindings.VerificationContextLibRawContext,
+	challengedStepIndex *big.Int,
+	prevBisection []common.Hash,
+	prevChallengedSegmentStart *big.Int,
+	prevChallengedSegmentLength *big.Int,
+) (*types.Receipt, error) {
+	data, err := packVerifyOneStepProofInput(
+		oneStepProof,
+		txInclusionProof,
+		verificationCtx,
+		challengedStepIndex,
+		prevBisection,
+		prevChallengedSegmentStart,
+		prevChallengedSegmentLength,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &challenge})
+}
+
+func (m *TxManager) TimeoutChallenge(ctx context.Context, challenge common.Address) (*types.Receipt, error) {
+	data, err := packTimeoutInput()
+	if err != nil {
+		return nil, err
+	}
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &challenge})
+}
+
+// Sends a tx to the Rollup contract, with the given value (none if nil).
+func (m *TxManager) sendRollupTx(ctx context.Context, data []byte, value *big.Int) (*types.Receipt, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
 	addr := m.cfg.GetRollupAddr()
-	return m.Send(ctx, txmgr.TxCandidate{TxData: data, To: &addr, Value: big.NewInt(0).SetUint64(value)})
+	return m.send(ctx, txmgr.TxCandidate{TxData: data, To: &addr, Value: value})
+}
+
+// Sends the tx, decoding reverts into `*ContractError`s (see `DecodeError`).
+func (m *TxManager) send(ctx context.Context, candidate txmgr.TxCandidate) (*types.Receipt, error) {
+	receipt, err := m.Send(ctx, candidate)
+	return receipt, DecodeError(err)
 }
@@ -7,10 +7,12 @@ import (
 
 	"github.com/avast/retry-go/v4"
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/specularL2/specular/services/sidecar/proof"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 	"github.com/specularL2/specular/services/sidecar/utils/log"
 )
@@ -78,3 +80,42 @@ func (c *EthClient) TxPoolStatus(ctx context.Context) (map[string]hexutil.Uint,
 	err := c.C.CallContext(ctx, &status, "txpool_status")
 	return status, err
 }
+
+// Returns the execution state hashes across L2 blocks [startNum, endNum), as generated by the node's prover.
+func (c *EthClient) GenerateStateHashes(
+	ctx context.Context,
+	startNum, endNum uint64,
+	config *proof.ProverConfig,
+) ([]common.Hash, error) {
+	var hashes []common.Hash
+	err := c.C.CallContext(
+		ctx, &hashes, "proof_generateStateHashes", hexutil.Uint64(startNum), hexutil.Uint64(endNum), config,
+	)
+	return hashes, err
+}
+
+// Returns a one-step proof from the `step`-th execution state across L2 blocks [startNum, endNum).
+func (c *EthClient) GenerateOneStepProof(
+	ctx context.Context,
+	startNum, endNum, step uint64,
+	config *proof.ProverConfig,
+) (*proof.OneStepProofResult, error) {
+	var result *proof.OneStepProofResult
+	err := c.C.CallContext(
+		ctx, &result, "proof_generateOneStepProof",
+		hexutil.Uint64(startNum), hexutil.Uint64(endNum), hexutil.Uint64(step), config,
+	)
+	if err == nil && result == nil {
+		err = ethereum.NotFound
+	}
+	return result, err
+}
+
+// Returns the storage root of `account` at the given block, as reported by `eth_getProof`.
+func (c *EthClient) StorageRootAt(ctx context.Context, account common.Address, blockNum *big.Int) (common.Hash, error) {
+	var result struct {
+		StorageHash common.Hash `json:"storageHash"`
+	}
+	err := c.C.CallContext(ctx, &result, "eth_getProof", account, []common.Hash{}, hexutil.EncodeBig(blockNum))
+	return result.StorageHash, err
+}
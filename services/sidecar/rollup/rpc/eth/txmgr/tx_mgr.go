@@ -3,6 +3,7 @@ package txmgr
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"math/big"
 	"strings"
@@ -11,9 +12,12 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/uint256"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 	"github.com/specularL2/specular/services/sidecar/utils/retry"
 )
@@ -21,6 +25,8 @@ import (
 const (
 	// Geth requires a minimum fee bump of 10% for tx resubmission
 	priceBump int64 = 10
+	// Geth requires a minimum fee bump of 100% for blob tx resubmission
+	blobPriceBump int64 = 100
 
 	// The multiplier applied to fee suggestions to put a hard limit on fee increases
 	feeLimitMultiplier = 5
@@ -28,6 +34,7 @@ const (
 
 // new = old * (100 + priceBump) / 100
 var priceBumpPercent = big.NewInt(100 + priceBump)
+var blobPriceBumpPercent = big.NewInt(100 + blobPriceBump)
 var oneHundred = big.NewInt(100)
 
 // ETHBackend is the set of methods that the transaction manager uses to resubmit gas & determine
@@ -102,6 +109,12 @@ type TxCandidate struct {
 	GasLimit uint64
 	// Value is the value to be used in the constructed tx.
 	Value *big.Int
+	// Blobs to be included in the constructed tx (EIP-4844).
+	// If non-empty, a blob tx is constructed; otherwise, a dynamic fee tx is constructed.
+	Blobs []kzg4844.Blob
+	// Nonce to be used in the constructed tx (e.g. to replace a pending tx).
+	// If nil, the next nonce is assigned (see `nextNonce`).
+	Nonce *uint64
 }
 
 // Send is used to publish a transaction with incrementally higher gas prices
@@ -127,6 +140,39 @@ func (m *TxManager) Call(ctx context.Context, msg ethereum.CallMsg, blockNumber
 	return m.backend.CallContract(ctx, msg, blockNumber)
 }
 
+// SendResult is the outcome of a transaction sent with [TxManager.SendAsync].
+type SendResult struct {
+	Receipt *types.Receipt
+	Err     error
+}
+
+// SendAsync crafts and signs a transaction (assigning it the next nonce, unless one is given), then publishes it
+// in the background as [TxManager.Send] does, delivering the outcome on resultCh.
+// Since the nonce is assigned before returning, transactions sent by successive calls
+// have sequential nonces (and are thus included in order).
+// The passed context bounds the background sending; it should outlive this call.
+func (m *TxManager) SendAsync(ctx context.Context, candidate TxCandidate, resultCh chan<- SendResult) error {
+	var cancel context.CancelFunc = func() {}
+	if m.cfg.TxSendTimeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.cfg.TxSendTimeout)
+	}
+	tx, err := m.craftTxWithRetry(ctx, candidate)
+	if err != nil {
+		cancel()
+		m.resetNonce()
+		return err
+	}
+	go func() {
+		defer cancel()
+		receipt, err := m.sendTx(ctx, tx)
+		if err != nil {
+			m.resetNonce()
+		}
+		resultCh <- SendResult{Receipt: receipt, Err: err}
+	}()
+	return nil
+}
+
 // send performs the actual transaction creation and sending.
 func (m *TxManager) send(ctx context.Context, candidate TxCandidate) (*types.Receipt, error) {
 	if m.cfg.TxSendTimeout != 0 {
@@ -134,6 +180,15 @@ func (m *TxManager) send(ctx context.Context, candidate TxCandidate) (*types.Rec
 		ctx, cancel = context.WithTimeout(ctx, m.cfg.TxSendTimeout)
 		defer cancel()
 	}
+	tx, err := m.craftTxWithRetry(ctx, candidate)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendTx(ctx, tx)
+}
+
+// craftTxWithRetry calls craftTx, retrying on failure.
+func (m *TxManager) craftTxWithRetry(ctx context.Context, candidate TxCandidate) (*types.Transaction, error) {
 	tx, err := retry.Do(ctx, 10, retry.Fixed(2*time.Second), func() (*types.Transaction, error) {
 		tx, err := m.craftTx(ctx, candidate)
 		if err != nil {
@@ -144,7 +199,7 @@ func (m *TxManager) send(ctx context.Context, candidate TxCandidate) (*types.Rec
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the tx: %w", err)
 	}
-	return m.sendTx(ctx, tx)
+	return tx, nil
 }
 
 // craftTx creates the signed transaction
@@ -153,52 +208,110 @@ func (m *TxManager) send(ctx context.Context, candidate TxCandidate) (*types.Rec
 // NOTE: If the [TxCandidate.GasLimit] is non-zero, it will be used as the transaction's gas.
 // NOTE: Otherwise, the [TxManager] will query the specified backend for an estimate.
 func (m *TxManager) craftTx(ctx context.Context, candidate TxCandidate) (*types.Transaction, error) {
-	gasTipCap, basefee, err := m.suggestGasPriceCaps(ctx)
+	gasTipCap, basefee, blobBaseFee, err := m.SuggestGasPriceCaps(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price info: %w", err)
 	}
 	gasFeeCap := calcGasFeeCap(basefee, gasTipCap)
 
-	rawTx := &types.DynamicFeeTx{
-		ChainID:   m.cfg.ChainID,
-		To:        candidate.To,
-		GasTipCap: gasTipCap,
-		GasFeeCap: gasFeeCap,
-		Data:      candidate.TxData,
-		Value:     candidate.Value,
-	}
-
-	m.l.Info("Creating tx", "to", rawTx.To, "from", m.cfg.From)
+	m.l.Info("Creating tx", "to", candidate.To, "from", m.cfg.From, "num_blobs", len(candidate.Blobs))
 
+	var gas uint64
 	// If the gas limit is set, we can use that as the gas
 	if candidate.GasLimit != 0 {
-		rawTx.Gas = candidate.GasLimit
+		gas = candidate.GasLimit
 	} else {
 		// Calculate the intrinsic gas for the transaction
-		gas, err := m.backend.EstimateGas(ctx, ethereum.CallMsg{
+		gas, err = m.backend.EstimateGas(ctx, ethereum.CallMsg{
 			From:      m.cfg.From,
 			To:        candidate.To,
 			GasFeeCap: gasFeeCap,
 			GasTipCap: gasTipCap,
-			Data:      rawTx.Data,
-			Value:     rawTx.Value,
+			Data:      candidate.TxData,
+			Value:     candidate.Value,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to estimate gas: %w", err)
 		}
-		rawTx.Gas = gas
+	}
+
+	var txData types.TxData
+	if len(candidate.Blobs) > 0 {
+		if blobBaseFee == nil {
+			return nil, errors.New("blob txs are not supported on pre-cancun L1 blocks")
+		}
+		if candidate.To == nil {
+			return nil, errors.New("blob txs cannot be contract creations")
+		}
+		sidecar, blobHashes, err := makeBlobSidecar(candidate.Blobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create blob sidecar: %w", err)
+		}
+		txData = &types.BlobTx{
+			ChainID:    uint256.MustFromBig(m.cfg.ChainID),
+			To:         *candidate.To,
+			GasTipCap:  uint256.MustFromBig(gasTipCap),
+			GasFeeCap:  uint256.MustFromBig(gasFeeCap),
+			Gas:        gas,
+			Data:       candidate.TxData,
+			Value:      uint256.MustFromBig(valueOrZero(candidate.Value)),
+			BlobFeeCap: uint256.MustFromBig(calcBlobFeeCap(blobBaseFee)),
+			BlobHashes: blobHashes,
+			Sidecar:    sidecar,
+		}
+	} else {
+		txData = &types.DynamicFeeTx{
+			ChainID:   m.cfg.ChainID,
+			To:        candidate.To,
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       gas,
+			Data:      candidate.TxData,
+			Value:     candidate.Value,
+		}
 	}
 
 	// Avoid bumping the nonce if the gas estimation fails.
-	nonce, err := m.nextNonce(ctx)
-	if err != nil {
+	var nonce uint64
+	if candidate.Nonce != nil {
+		nonce = *candidate.Nonce
+	} else if nonce, err = m.nextNonce(ctx); err != nil {
 		return nil, err
 	}
-	rawTx.Nonce = nonce
+	switch tx := txData.(type) {
+	case *types.BlobTx:
+		tx.Nonce = nonce
+	case *types.DynamicFeeTx:
+		tx.Nonce = nonce
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, m.cfg.NetworkTimeout)
 	defer cancel()
-	return m.signer(ctx, m.cfg.From, types.NewTx(rawTx))
+	return m.signer(ctx, m.cfg.From, types.NewTx(txData))
+}
+
+// makeBlobSidecar computes the KZG commitments and proofs for the given blobs,
+// returning the resulting sidecar and the corresponding versioned blob hashes.
+func makeBlobSidecar(blobs []kzg4844.Blob) (*types.BlobTxSidecar, []common.Hash, error) {
+	var (
+		sidecar    = &types.BlobTxSidecar{}
+		blobHashes = make([]common.Hash, 0, len(blobs))
+	)
+	for i, blob := range blobs {
+		commitment, err := kzg4844.BlobToCommitment(blob)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to compute commitment for blob %d: %w", i, err)
+		}
+		proof, err := kzg4844.ComputeBlobProof(blob, commitment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to compute proof for blob %d: %w", i, err)
+		}
+		sidecar.Blobs = append(sidecar.Blobs, blob)
+		sidecar.Commitments = append(sidecar.Commitments, commitment)
+		sidecar.Proofs = append(sidecar.Proofs, proof)
+		blobHashes = append(blobHashes, kzg4844.CalcBlobHashV1(sha256.New(), &commitment))
+	}
+	return sidecar, blobHashes, nil
 }
 
 // nextNonce returns a nonce to use for the next transaction. It uses
@@ -232,6 +345,14 @@ func (m *TxManager) resetNonce() {
 	m.nonce = nil
 }
 
+// LatestNonce returns the nonce of the next tx from the sender, after those mined in the latest block
+// (i.e. disregarding pending txs).
+func (m *TxManager) LatestNonce(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.NetworkTimeout)
+	defer cancel()
+	return m.backend.NonceAt(ctx, m.cfg.From, nil)
+}
+
 // send submits the same transaction several times with increasing gas prices as necessary.
 // It waits for the transaction to be confirmed on chain.
 func (m *TxManager) sendTx(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
@@ -404,12 +525,13 @@ func (m *TxManager) queryReceipt(ctx context.Context, txHash common.Hash, sendSt
 // `feeLimitMultiplier` multiple of the suggested values.
 func (m *TxManager) increaseGasPrice(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
 	m.l.Info("bumping gas price for tx", "hash", tx.Hash(), "tip", tx.GasTipCap(), "fee", tx.GasFeeCap(), "gaslimit", tx.Gas())
-	tip, basefee, err := m.suggestGasPriceCaps(ctx)
+	tip, basefee, blobBaseFee, err := m.SuggestGasPriceCaps(ctx)
 	if err != nil {
 		m.l.Warn("failed to get suggested gas tip and basefee", "err", err)
 		return nil, err
 	}
-	bumpedTip, bumpedFee := updateFees(tx.GasTipCap(), tx.GasFeeCap(), tip, basefee, m.l)
+	isBlobTx := tx.Type() == types.BlobTxType
+	bumpedTip, bumpedFee := updateFees(tx.GasTipCap(), tx.GasFeeCap(), tip, basefee, isBlobTx, m.l)
 
 	// Make sure increase is at most 5x the suggested values
 	maxTip := new(big.Int).Mul(tip, big.NewInt(feeLimitMultiplier))
@@ -423,24 +545,13 @@ func (m *TxManager) increaseGasPrice(ctx context.Context, tx *types.Transaction)
 		bumpedFee.Set(maxFee)
 	}
 
-	rawTx := &types.DynamicFeeTx{
-		ChainID:    tx.ChainId(),
-		Nonce:      tx.Nonce(),
-		GasTipCap:  bumpedTip,
-		GasFeeCap:  bumpedFee,
-		To:         tx.To(),
-		Value:      tx.Value(),
-		Data:       tx.Data(),
-		AccessList: tx.AccessList(),
-	}
-
 	// Re-estimate gaslimit in case things have changed or a previous gaslimit estimate was wrong
 	gas, err := m.backend.EstimateGas(ctx, ethereum.CallMsg{
 		From:      m.cfg.From,
-		To:        rawTx.To,
+		To:        tx.To(),
 		GasFeeCap: bumpedTip,
 		GasTipCap: bumpedFee,
-		Data:      rawTx.Data,
+		Data:      tx.Data(),
 	})
 	if err != nil {
 		// If this is a transaction resubmission, we sometimes see this outcome because the
@@ -453,7 +564,40 @@ func (m *TxManager) increaseGasPrice(ctx context.Context, tx *types.Transaction)
 	if tx.Gas() != gas {
 		m.l.Info("re-estimated gas differs", "oldgas", tx.Gas(), "newgas", gas)
 	}
-	rawTx.Gas = gas
+
+	var rawTx types.TxData
+	if isBlobTx {
+		if blobBaseFee == nil {
+			return nil, errors.New("blob txs are not supported on pre-cancun L1 blocks")
+		}
+		bumpedBlobFee := updateBlobFeeCap(tx.BlobGasFeeCap(), blobBaseFee, m.l)
+		rawTx = &types.BlobTx{
+			ChainID:    uint256.MustFromBig(tx.ChainId()),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  uint256.MustFromBig(bumpedTip),
+			GasFeeCap:  uint256.MustFromBig(bumpedFee),
+			Gas:        gas,
+			To:         *tx.To(),
+			Value:      uint256.MustFromBig(tx.Value()),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			BlobFeeCap: uint256.MustFromBig(bumpedBlobFee),
+			BlobHashes: tx.BlobHashes(),
+			Sidecar:    tx.BlobTxSidecar(),
+		}
+	} else {
+		rawTx = &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  bumpedTip,
+			GasFeeCap:  bumpedFee,
+			Gas:        gas,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, m.cfg.NetworkTimeout)
 	defer cancel()
@@ -465,30 +609,39 @@ func (m *TxManager) increaseGasPrice(ctx context.Context, tx *types.Transaction)
 	return newTx, nil
 }
 
-// suggestGasPriceCaps suggests what the new tip & new basefee should be based on the current L1 conditions
-func (m *TxManager) suggestGasPriceCaps(ctx context.Context) (*big.Int, *big.Int, error) {
+// SuggestGasPriceCaps suggests what the new tip, basefee & blob basefee should be based on the current L1 conditions.
+// The blob basefee is nil if the latest L1 block is pre-cancun.
+func (m *TxManager) SuggestGasPriceCaps(ctx context.Context) (*big.Int, *big.Int, *big.Int, error) {
 	cCtx, cancel := context.WithTimeout(ctx, m.cfg.NetworkTimeout)
 	defer cancel()
 	tip, err := m.backend.SuggestGasTipCap(cCtx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch the suggested gas tip cap: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to fetch the suggested gas tip cap: %w", err)
 	} else if tip == nil {
-		return nil, nil, errors.New("the suggested tip was nil")
+		return nil, nil, nil, errors.New("the suggested tip was nil")
 	}
 	cCtx, cancel = context.WithTimeout(ctx, m.cfg.NetworkTimeout)
 	defer cancel()
 	head, err := m.backend.HeaderByNumber(cCtx, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch the suggested basefee: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to fetch the suggested basefee: %w", err)
 	} else if head.BaseFee == nil {
-		return nil, nil, errors.New("txmgr does not support pre-london blocks that do not have a basefee")
+		return nil, nil, nil, errors.New("txmgr does not support pre-london blocks that do not have a basefee")
+	}
+	var blobBaseFee *big.Int
+	if head.ExcessBlobGas != nil {
+		blobBaseFee = eip4844.CalcBlobFee(*head.ExcessBlobGas)
 	}
-	return tip, head.BaseFee, nil
+	return tip, head.BaseFee, blobBaseFee, nil
 }
 
-// calcThresholdValue returns x * priceBumpPercent / 100
-func calcThresholdValue(x *big.Int) *big.Int {
-	threshold := new(big.Int).Mul(priceBumpPercent, x)
+// calcThresholdValue returns x * priceBumpPercent / 100 (or x * blobPriceBumpPercent / 100 for blob txs)
+func calcThresholdValue(x *big.Int, isBlobTx bool) *big.Int {
+	bumpPercent := priceBumpPercent
+	if isBlobTx {
+		bumpPercent = blobPriceBumpPercent
+	}
+	threshold := new(big.Int).Mul(bumpPercent, x)
 	threshold = threshold.Div(threshold, oneHundred)
 	return threshold
 }
@@ -499,11 +652,11 @@ func calcThresholdValue(x *big.Int) *big.Int {
 //	(a) each satisfies geth's required tx-replacement fee bumps (we use a 10% increase), and
 //	(b) gasTipCap is no less than new tip, and
 //	(c) gasFeeCap is no less than calcGasFee(newBaseFee, newTip)
-func updateFees(oldTip, oldFeeCap, newTip, newBaseFee *big.Int, lgr log.Logger) (*big.Int, *big.Int) {
+func updateFees(oldTip, oldFeeCap, newTip, newBaseFee *big.Int, isBlobTx bool, lgr log.Logger) (*big.Int, *big.Int) {
 	newFeeCap := calcGasFeeCap(newBaseFee, newTip)
 	lgr = lgr.New("old_tip", oldTip, "old_feecap", oldFeeCap, "new_tip", newTip, "new_feecap", newFeeCap)
-	thresholdTip := calcThresholdValue(oldTip)
-	thresholdFeeCap := calcThresholdValue(oldFeeCap)
+	thresholdTip := calcThresholdValue(oldTip, isBlobTx)
+	thresholdFeeCap := calcThresholdValue(oldFeeCap, isBlobTx)
 	if newTip.Cmp(thresholdTip) >= 0 && newFeeCap.Cmp(thresholdFeeCap) >= 0 {
 		lgr.Debug("Using new tip and feecap")
 		return newTip, newFeeCap
@@ -525,6 +678,36 @@ func updateFees(oldTip, oldFeeCap, newTip, newBaseFee *big.Int, lgr log.Logger)
 	}
 }
 
+// updateBlobFeeCap takes an old blob tx's blob fee cap plus a new blob basefee, and returns
+// a blob fee cap that satisfies geth's required blob tx-replacement fee bump (100%) and is no
+// less than calcBlobFeeCap(newBlobBaseFee). To avoid runaway price increases, it is capped
+// at a `feeLimitMultiplier` multiple of the suggested value.
+func updateBlobFeeCap(oldBlobFeeCap, newBlobBaseFee *big.Int, lgr log.Logger) *big.Int {
+	newBlobFeeCap := calcBlobFeeCap(newBlobBaseFee)
+	bumped := calcThresholdValue(oldBlobFeeCap, true)
+	if newBlobFeeCap.Cmp(bumped) > 0 {
+		bumped = newBlobFeeCap
+	}
+	maxBlobFeeCap := new(big.Int).Mul(newBlobFeeCap, big.NewInt(feeLimitMultiplier))
+	if bumped.Cmp(maxBlobFeeCap) > 0 {
+		lgr.Warn("bumped blob fee getting capped at multiple of the suggested value", "bumped", bumped, "suggestion", maxBlobFeeCap)
+		bumped = maxBlobFeeCap
+	}
+	return bumped
+}
+
+// calcBlobFeeCap computes the recommended blob fee cap given the blob basefee: 2*blobBaseFee.
+func calcBlobFeeCap(blobBaseFee *big.Int) *big.Int {
+	return new(big.Int).Mul(blobBaseFee, big.NewInt(2))
+}
+
+func valueOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value
+}
+
 // calcGasFeeCap deterministically computes the recommended gas fee cap given
 // the base fee and gasTipCap. The resulting gasFeeCap is equal to:
 //
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/disseminator"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+var (
+	errDisseminatorDisabled = errors.New("disseminator not enabled")
+	errValidatorDisabled    = errors.New("validator not enabled")
+)
+
+type L1State interface {
+	Tips() (types.BlockID, types.BlockID, types.BlockID)
+}
+
+type Disseminator interface {
+	Pause()
+	Resume()
+	Flush(ctx context.Context) error
+	Status() disseminator.Status
+}
+
+type Validator interface {
+	Pause()
+	Resume()
+	IsPaused() bool
+	LastCreatedAssertion() (uint64, common.Hash)
+}
+
+// Control methods, served under the `admin` namespace.
+// Services that aren't enabled are nil.
+type AdminAPI struct {
+	disseminator Disseminator
+	validator    Validator
+}
+
+func (api *AdminAPI) PauseDisseminator() error {
+	if api.disseminator == nil {
+		return errDisseminatorDisabled
+	}
+	api.disseminator.Pause()
+	return nil
+}
+
+func (api *AdminAPI) ResumeDisseminator() error {
+	if api.disseminator == nil {
+		return errDisseminatorDisabled
+	}
+	api.disseminator.Resume()
+	return nil
+}
+
+// Forces the current batch to be built and sent.
+func (api *AdminAPI) FlushBatch(ctx context.Context) error {
+	if api.disseminator == nil {
+		return errDisseminatorDisabled
+	}
+	return api.disseminator.Flush(ctx)
+}
+
+func (api *AdminAPI) PauseValidator() error {
+	if api.validator == nil {
+		return errValidatorDisabled
+	}
+	api.validator.Pause()
+	return nil
+}
+
+func (api *AdminAPI) ResumeValidator() error {
+	if api.validator == nil {
+		return errValidatorDisabled
+	}
+	api.validator.Resume()
+	return nil
+}
+
+// Read-only methods, served under the `sidecar` namespace.
+type SidecarAPI struct {
+	l1State      L1State
+	disseminator Disseminator
+	validator    Validator
+}
+
+type Status struct {
+	L1           L1Status             `json:"l1"`
+	Disseminator *disseminator.Status `json:"disseminator,omitempty"`
+	Validator    *ValidatorStatus     `json:"validator,omitempty"`
+}
+
+type L1Status struct {
+	Head      types.BlockID `json:"head"`
+	Safe      types.BlockID `json:"safe"`
+	Finalized types.BlockID `json:"finalized"`
+}
+
+type ValidatorStatus struct {
+	Paused               bool             `json:"paused"`
+	LastCreatedAssertion AssertionSummary `json:"last_created_assertion"`
+}
+
+type AssertionSummary struct {
+	L2BlockNum uint64      `json:"l2_block_num"`
+	VMHash     common.Hash `json:"vm_hash"`
+}
+
+// Returns the status of the enabled services.
+func (api *SidecarAPI) Status() Status {
+	var status Status
+	status.L1.Head, status.L1.Safe, status.L1.Finalized = api.l1State.Tips()
+	if api.disseminator != nil {
+		dStatus := api.disseminator.Status()
+		status.Disseminator = &dStatus
+	}
+	if api.validator != nil {
+		blockNum, vmHash := api.validator.LastCreatedAssertion()
+		status.Validator = &ValidatorStatus{
+			Paused:               api.validator.IsPaused(),
+			LastCreatedAssertion: AssertionSummary{L2BlockNum: blockNum, VMHash: vmHash},
+		}
+	}
+	return status
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/api"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+type Config interface {
+	GetRPCAddr() string
+	GetJWTSecretPath() string // Requests are unauthenticated if empty.
+}
+
+// Serves the admin (`admin_`) and status (`sidecar_`) JSON-RPC APIs over HTTP.
+type Server struct {
+	cfg        Config
+	rpcServer  *rpc.Server
+	httpServer *http.Server
+}
+
+// Creates a server for the given services; `disseminator` and `validator` are nil if not enabled.
+func NewServer(cfg Config, l1State L1State, disseminator Disseminator, validator Validator) (*Server, error) {
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("admin", &AdminAPI{disseminator, validator}); err != nil {
+		return nil, fmt.Errorf("failed to register admin API: %w", err)
+	}
+	if err := rpcServer.RegisterName("sidecar", &SidecarAPI{l1State, disseminator, validator}); err != nil {
+		return nil, fmt.Errorf("failed to register sidecar API: %w", err)
+	}
+	var handler http.Handler = rpcServer
+	if path := cfg.GetJWTSecretPath(); path != "" {
+		secret, err := readJWTSecret(path)
+		if err != nil {
+			return nil, err
+		}
+		handler = node.NewJWTHandler(secret, handler)
+	}
+	return &Server{cfg: cfg, rpcServer: rpcServer, httpServer: &http.Server{Handler: handler}}, nil
+}
+
+// Reads a hex-encoded 32-byte JWT secret (as used for the engine API) from the file at `path`.
+func readJWTSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT secret: %w", err)
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid JWT secret in %s: want 32 bytes, got %d", path, len(secret))
+	}
+	return secret, nil
+}
+
+func (s *Server) Start(ctx context.Context, eg api.ErrGroup) error {
+	listener, err := net.Listen("tcp", s.cfg.GetRPCAddr())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.GetRPCAddr(), err)
+	}
+	log.Info("Admin RPC server listening", "addr", listener.Addr())
+	eg.Go(func() error {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("admin RPC server failed: %w", err)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		<-ctx.Done()
+		log.Info("Stopping admin RPC server")
+		sCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		s.rpcServer.Stop()
+		return s.httpServer.Shutdown(sCtx)
+	})
+	return nil
+}
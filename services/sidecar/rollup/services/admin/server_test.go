@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+)
+
+type testConfig struct{ jwtSecretPath string }
+
+func (c testConfig) GetRPCAddr() string       { return "" }
+func (c testConfig) GetJWTSecretPath() string { return c.jwtSecretPath }
+
+type testL1State struct{}
+
+func (testL1State) Tips() (types.BlockID, types.BlockID, types.BlockID) {
+	return types.EmptyBlockID, types.EmptyBlockID, types.EmptyBlockID
+}
+
+func TestServerAuth(t *testing.T) {
+	secret := []byte(strings.Repeat("s", 32))
+	path := filepath.Join(t.TempDir(), "jwt.hex")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("0x%x\n", secret)), 0o600); err != nil {
+		t.Fatalf("failed to write JWT secret: %v", err)
+	}
+	server, err := NewServer(testConfig{path}, testL1State{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(server.httpServer.Handler)
+	defer httpServer.Close()
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"token signed with wrong secret", mustSign(t, []byte(strings.Repeat("x", 32))), http.StatusUnauthorized},
+		{"valid token", mustSign(t, secret), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"sidecar_status","params":[]}`)
+			req, err := http.NewRequest(http.MethodPost, httpServer.URL, body)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReadJWTSecretInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.hex")
+	if err := os.WriteFile(path, []byte("0x1234"), 0o600); err != nil {
+		t.Fatalf("failed to write JWT secret: %v", err)
+	}
+	if _, err := NewServer(testConfig{path}, testL1State{}, nil, nil); err == nil {
+		t.Fatal("created server with a short JWT secret")
+	}
+}
+
+func mustSign(t *testing.T, secret []byte) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iat": time.Now().Unix()}).SignedString(secret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
@@ -2,11 +2,17 @@ package services
 
 import (
 	"crypto/ecdsa"
+	"net"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/lib/el_golang_lib/vmhash"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/disseminator"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/validator"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 	"github.com/urfave/cli/v2"
 )
@@ -18,6 +24,7 @@ type SystemConfig struct {
 	L2Config           `toml:"l2,omitempty"`
 	DisseminatorConfig `toml:"disseminator,omitempty"`
 	ValidatorConfig    `toml:"validator,omitempty"`
+	AdminConfig        `toml:"admin,omitempty"`
 }
 
 func (c *SystemConfig) Protocol() ProtocolConfig         { return c.ProtocolConfig }
@@ -25,17 +32,28 @@ func (c *SystemConfig) L1() L1Config                     { return c.L1Config }
 func (c *SystemConfig) L2() L2Config                     { return c.L2Config }
 func (c *SystemConfig) Disseminator() DisseminatorConfig { return c.DisseminatorConfig }
 func (c *SystemConfig) Validator() ValidatorConfig       { return c.ValidatorConfig }
+func (c *SystemConfig) Admin() AdminConfig               { return c.AdminConfig }
 
 func (c *SystemConfig) validate() error {
 	if !(c.DisseminatorConfig.IsEnabled || c.ValidatorConfig.IsEnabled) {
 		return fmt.Errorf("at least one of disseminator and validator must be enabled")
 	}
+	if err := c.ProtocolConfig.validate(); err != nil {
+		return fmt.Errorf("protocol config invalid: %w", err)
+	}
 	if err := c.DisseminatorConfig.validate(); err != nil {
 		return fmt.Errorf("disseminator config invalid: %w", err)
 	}
+	// Batches posted in blobs can only be recovered (on restart) from the beacon node.
+	if c.DisseminatorConfig.IsEnabled && c.DisseminatorConfig.DAMode != disseminator.CalldataDAMode && c.L1Config.BeaconEndpoint == "" {
+		return fmt.Errorf("disseminator DA mode %s requires an L1 beacon endpoint", c.DisseminatorConfig.DAMode)
+	}
 	if err := c.ValidatorConfig.validate(); err != nil {
 		return fmt.Errorf("validator config invalid: %w", err)
 	}
+	if err := c.AdminConfig.validate(); err != nil {
+		return fmt.Errorf("admin config invalid: %w", err)
+	}
 	return nil
 }
 
@@ -64,6 +82,7 @@ func ParseSystemConfig(cliCtx *cli.Context) (*SystemConfig, error) {
 		L2Config:           newL2ConfigFromCLI(cliCtx),
 		DisseminatorConfig: newDisseminatorConfigFromCLI(cliCtx, disseminatorTxMgrCfg),
 		ValidatorConfig:    newValidatorConfigFromCLI(cliCtx, validatorTxMgrCfg),
+		AdminConfig:        newAdminConfigFromCLI(cliCtx),
 	}
 	// Validate.
 	if err := cfg.validate(); err != nil {
@@ -97,33 +116,63 @@ func (c ProtocolConfig) GetSeqWindowSize() uint64              { return c.Rollup
 func (c ProtocolConfig) GetSequencerInboxAddr() common.Address { return c.Rollup.BatchInboxAddress }
 func (c ProtocolConfig) GetL1ChainID() uint64                  { return c.Rollup.L1ChainID.Uint64() }
 func (c ProtocolConfig) GetL2ChainID() uint64                  { return c.Rollup.L2ChainID.Uint64() }
+func (c ProtocolConfig) GetBatchVersionActivations() map[uint8]uint64 {
+	return c.Rollup.BatchVersionActivations
+}
+func (c ProtocolConfig) GetVMHashActivations() vmhash.Activations {
+	return c.Rollup.VMHashVersionActivations
+}
+func (c ProtocolConfig) GetL2PortalAddr() common.Address {
+	if c.Rollup.L2PortalAddress == (common.Address{}) {
+		return vmhash.DefaultL2PortalAddr
+	}
+	return c.Rollup.L2PortalAddress
+}
 func (c ProtocolConfig) GetL1OracleAddr() common.Address {
 	// TODO: import from package or config
 	return common.HexToAddress("0x2A00000000000000000000000000000000000010")
 }
 
+// Validates the configuration.
+func (c ProtocolConfig) validate() error {
+	if err := c.GetVMHashActivations().Validate(); err != nil {
+		return fmt.Errorf("invalid VM hash version activations: %w", err)
+	}
+	return nil
+}
+
 // L1 configuration
 type L1Config struct {
-	Endpoint string `toml:"endpoint,omitempty"` // L1 API endpoint
+	Endpoint       string `toml:"endpoint,omitempty"`        // L1 API endpoint
+	BeaconEndpoint string `toml:"beacon_endpoint,omitempty"` // L1 beacon API endpoint (to retrieve blobs)
 }
 
 func newL1ConfigFromCLI(cliCtx *cli.Context) L1Config {
-	return L1Config{Endpoint: cliCtx.String(l1EndpointFlag.Name)}
+	return L1Config{
+		Endpoint:       cliCtx.String(l1EndpointFlag.Name),
+		BeaconEndpoint: cliCtx.String(l1BeaconEndpointFlag.Name),
+	}
 }
 
-func (c L1Config) GetEndpoint() string { return c.Endpoint }
+func (c L1Config) GetEndpoint() string       { return c.Endpoint }
+func (c L1Config) GetBeaconEndpoint() string { return c.BeaconEndpoint }
 
 // L2 configuration
 type L2Config struct {
-	Endpoint string `toml:"endpoint,omitempty"` // L2 API endpoint
-	ChainID  uint64 `toml:"chainid,omitempty"`  // L2 chain ID
+	Endpoint   string `toml:"endpoint,omitempty"`    // L2 API endpoint
+	WSEndpoint string `toml:"ws_endpoint,omitempty"` // L2 websocket endpoint (optional)
+	ChainID    uint64 `toml:"chainid,omitempty"`     // L2 chain ID
 }
 
 func newL2ConfigFromCLI(cliCtx *cli.Context) L2Config {
-	return L2Config{Endpoint: cliCtx.String(l2EndpointFlag.Name)}
+	return L2Config{
+		Endpoint:   cliCtx.String(l2EndpointFlag.Name),
+		WSEndpoint: cliCtx.String(l2WSEndpointFlag.Name),
+	}
 }
 
-func (c L2Config) GetEndpoint() string { return c.Endpoint }
+func (c L2Config) GetEndpoint() string   { return c.Endpoint }
+func (c L2Config) GetWSEndpoint() string { return c.WSEndpoint }
 
 // Sequencer node configuration
 type DisseminatorConfig struct {
@@ -141,6 +190,38 @@ type DisseminatorConfig struct {
 	SubSafetyMargin uint64 `toml:"sub_safety_margin,omitempty"`
 	// The target size of a batch tx submitted to L1 (bytes).
 	TargetBatchSize uint64 `toml:"max_l1_tx_size,omitempty"`
+	// The target L1 gas of a batch tx, estimated from its calldata. Overrides TargetBatchSize if set.
+	TargetBatchGas uint64 `toml:"target_batch_gas,omitempty"`
+	// Max age of the oldest block in a batch before it's flushed to L1, regardless of size (0 disables)
+	MaxBatchAge time.Duration `toml:"max_batch_age,omitempty"`
+	// Max # of L2 blocks in a batch before it's flushed to L1, regardless of size (0 disables)
+	MaxBatchBlocks uint64 `toml:"max_batch_blocks,omitempty"`
+	// How batches are posted to L1 (calldata, blobs or auto)
+	DAMode string `toml:"da_mode,omitempty"`
+	// The max size of a frame, i.e. a chunk of a batch sent in a single L1 tx (bytes). 0 disables framing.
+	MaxFrameSize uint64 `toml:"max_frame_size,omitempty"`
+	// The max batch format version to encode with (subject to its L1 activation height)
+	BatchVersion uint8 `toml:"batch_version,omitempty"`
+	// Directory in which batch builder state is persisted. If empty, state is kept in memory only.
+	DataDir string `toml:"datadir,omitempty"`
+	// Database engine for persisted state (leveldb or pebble). If empty, uses the existing database's engine.
+	DBEngine string `toml:"db_engine,omitempty"`
+	// Max # of batch txs in flight at once (sent with sequential nonces). At most 1 disables pipelining.
+	MaxInFlight uint64 `toml:"max_in_flight,omitempty"`
+	// Max L1 basefee at which non-urgent batches are posted (gwei). 0 disables the threshold.
+	MaxL1BaseFeeGwei uint64 `toml:"max_l1_basefee_gwei,omitempty"`
+	// Max total L1 fees spent on non-urgent batches per day (gwei). 0 disables the budget.
+	DailyFeeBudgetGwei uint64 `toml:"daily_fee_budget_gwei,omitempty"`
+	// # of L1 blocks before a batch's deadline at which it's posted regardless of L1 fees
+	UrgencyMargin uint64 `toml:"urgency_margin,omitempty"`
+	// Where batches are made available: in the sequencer inbox (inbox), or on an off-chain DA server (offchain)
+	DAProvider string `toml:"da_provider,omitempty"`
+	// The off-chain DA server endpoint: an HTTP(S) URL, or a local directory (as a stand-in)
+	DAServer string `toml:"da_server,omitempty"`
+	// Whether to build batches without sending them to L1 (e.g. to archive them for analysis)
+	DryRun bool `toml:"dry_run,omitempty"`
+	// Path of a JSONL file (*.jsonl) or directory in which to archive built batches. If empty, batches aren't archived.
+	ArchivePath string `toml:"archive_path,omitempty"`
 	// Transaction manager configuration
 	TxMgrCfg txmgr.Config `toml:"txmgr,omitempty"`
 }
@@ -152,6 +233,22 @@ func (c DisseminatorConfig) GetClefEndpoint() string                 { return c.
 func (c DisseminatorConfig) GetDisseminationInterval() time.Duration { return c.DisseminationInterval }
 func (c DisseminatorConfig) GetSubSafetyMargin() uint64              { return c.SubSafetyMargin }
 func (c DisseminatorConfig) GetTargetBatchSize() uint64              { return c.TargetBatchSize }
+func (c DisseminatorConfig) GetTargetBatchGas() uint64               { return c.TargetBatchGas }
+func (c DisseminatorConfig) GetMaxBatchAge() time.Duration           { return c.MaxBatchAge }
+func (c DisseminatorConfig) GetMaxBatchBlocks() uint64               { return c.MaxBatchBlocks }
+func (c DisseminatorConfig) GetDAMode() string                       { return c.DAMode }
+func (c DisseminatorConfig) GetMaxFrameSize() uint64                 { return c.MaxFrameSize }
+func (c DisseminatorConfig) GetBatchVersion() uint8                  { return c.BatchVersion }
+func (c DisseminatorConfig) GetDataDir() string                      { return c.DataDir }
+func (c DisseminatorConfig) GetDBEngine() string                     { return c.DBEngine }
+func (c DisseminatorConfig) GetMaxInFlight() uint64                  { return c.MaxInFlight }
+func (c DisseminatorConfig) GetMaxL1BaseFeeGwei() uint64             { return c.MaxL1BaseFeeGwei }
+func (c DisseminatorConfig) GetDailyFeeBudgetGwei() uint64           { return c.DailyFeeBudgetGwei }
+func (c DisseminatorConfig) GetUrgencyMargin() uint64                { return c.UrgencyMargin }
+func (c DisseminatorConfig) GetDAProvider() string                   { return c.DAProvider }
+func (c DisseminatorConfig) GetDAServer() string                     { return c.DAServer }
+func (c DisseminatorConfig) GetDryRun() bool                         { return c.DryRun }
+func (c DisseminatorConfig) GetArchivePath() string                  { return c.ArchivePath }
 func (c DisseminatorConfig) GetTxMgrCfg() txmgr.Config               { return c.TxMgrCfg }
 
 // Validates the configuration.
@@ -159,9 +256,38 @@ func (c DisseminatorConfig) validate() error {
 	if !c.IsEnabled {
 		return nil
 	}
-	if c.PrivateKey == nil && c.ClefEndpoint == "" {
+	// Dry runs send no txs, so need no key.
+	if !c.DryRun && c.PrivateKey == nil && c.ClefEndpoint == "" {
 		return fmt.Errorf("missing both private key and clef endpoint (require at least one)")
 	}
+	switch c.DAMode {
+	case disseminator.CalldataDAMode, disseminator.BlobDAMode, disseminator.AutoDAMode:
+	default:
+		return fmt.Errorf("invalid DA mode: %s", c.DAMode)
+	}
+	if c.TargetBatchGas != 0 && c.TargetBatchGas <= derivation.MinTargetBatchGas() {
+		return fmt.Errorf("target batch gas must exceed the fixed gas of a batch tx (%d)", derivation.MinTargetBatchGas())
+	}
+	if c.MaxFrameSize != 0 && c.MaxFrameSize <= derivation.FrameHeaderSize {
+		return fmt.Errorf("max frame size must exceed frame header size (%d)", derivation.FrameHeaderSize)
+	}
+	if !derivation.IsKnownVersion(c.BatchVersion) {
+		return fmt.Errorf("unknown batch version: %d", c.BatchVersion)
+	}
+	switch c.DAProvider {
+	case "", derivation.InboxDAProvider:
+	case derivation.OffchainDAProvider:
+		if c.DAServer == "" {
+			return fmt.Errorf("missing DA server for off-chain DA provider")
+		}
+	default:
+		return fmt.Errorf("invalid DA provider: %s", c.DAProvider)
+	}
+	switch c.DBEngine {
+	case "", "leveldb", "pebble":
+	default:
+		return fmt.Errorf("invalid db engine: %s", c.DBEngine)
+	}
 	return nil
 }
 
@@ -177,6 +303,22 @@ func newDisseminatorConfigFromCLI(
 		DisseminationInterval: time.Duration(cliCtx.Uint(disseminatorIntervalFlag.Name)) * time.Second,
 		SubSafetyMargin:       cliCtx.Uint64(disseminatorSubSafetyMarginFlag.Name),
 		TargetBatchSize:       cliCtx.Uint64(disseminatorTargetBatchSizeFlag.Name),
+		TargetBatchGas:        cliCtx.Uint64(disseminatorTargetBatchGasFlag.Name),
+		MaxBatchAge:           time.Duration(cliCtx.Uint(disseminatorMaxBatchAgeFlag.Name)) * time.Second,
+		MaxBatchBlocks:        cliCtx.Uint64(disseminatorMaxBatchBlocksFlag.Name),
+		DAMode:                cliCtx.String(disseminatorDAModeFlag.Name),
+		MaxFrameSize:          cliCtx.Uint64(disseminatorMaxFrameSizeFlag.Name),
+		BatchVersion:          uint8(cliCtx.Uint(disseminatorBatchVersionFlag.Name)),
+		DataDir:               cliCtx.String(disseminatorDataDirFlag.Name),
+		DBEngine:              cliCtx.String(disseminatorDBEngineFlag.Name),
+		MaxL1BaseFeeGwei:      cliCtx.Uint64(disseminatorMaxL1BaseFeeFlag.Name),
+		DailyFeeBudgetGwei:    cliCtx.Uint64(disseminatorDailyFeeBudgetFlag.Name),
+		UrgencyMargin:         cliCtx.Uint64(disseminatorUrgencyMarginFlag.Name),
+		DAProvider:            cliCtx.String(disseminatorDAProviderFlag.Name),
+		DAServer:              cliCtx.String(disseminatorDAServerFlag.Name),
+		DryRun:                cliCtx.Bool(disseminatorDryRunFlag.Name),
+		ArchivePath:           cliCtx.String(disseminatorArchivePathFlag.Name),
+		MaxInFlight:           cliCtx.Uint64(disseminatorMaxInFlightFlag.Name),
 		TxMgrCfg:              txMgrCfg,
 	}
 }
@@ -192,6 +334,20 @@ type ValidatorConfig struct {
 	ClefEndpoint string `toml:"clef_endpoint,omitempty"`
 	// Time between validation steps
 	ValidationInterval time.Duration `toml:"validation_interval,omitempty"`
+	// One of "watch" (no staking; check and alert only), "defensive" (stake and challenge) or "active"
+	Mode string `toml:"mode,omitempty"`
+	// L2 head to assert: "safe" or "finalized"
+	AssertionHead string `toml:"assertion_head,omitempty"`
+	// Min # of new L2 blocks per assertion, unless the last assertion is stale
+	MinAssertionL2Blocks uint64 `toml:"min_assertion_l2_blocks,omitempty"`
+	// Min # of L1 blocks between assertions (raised to the on-chain minimumAssertionPeriod)
+	MinAssertionL1Interval uint64 `toml:"min_assertion_l1_interval,omitempty"`
+	// # of L1 blocks after the last assertion at which any new L2 blocks are asserted. If 0, assertions never go stale.
+	MaxAssertionStaleness uint64 `toml:"max_assertion_staleness,omitempty"`
+	// Max L1 basefee (gwei) at which non-stale assertions are created. If 0, L1 fees are ignored.
+	MaxAssertionL1BaseFeeGwei uint64 `toml:"max_assertion_l1_basefee_gwei,omitempty"`
+	// Whether to re-derive L2 blocks from the sequencer inbox and check the local L2 chain against them
+	CheckDerivation bool `toml:"check_derivation,omitempty"`
 	// Transaction manager configuration
 	TxMgrCfg txmgr.Config `toml:"txmgr,omitempty"`
 }
@@ -201,6 +357,13 @@ func (c ValidatorConfig) GetAccountAddr() common.Address       { return c.Accoun
 func (c ValidatorConfig) GetPrivateKey() *ecdsa.PrivateKey     { return c.PrivateKey }
 func (c ValidatorConfig) GetClefEndpoint() string              { return c.ClefEndpoint }
 func (c ValidatorConfig) GetValidationInterval() time.Duration { return c.ValidationInterval }
+func (c ValidatorConfig) GetMode() string                      { return c.Mode }
+func (c ValidatorConfig) GetAssertionHead() string             { return c.AssertionHead }
+func (c ValidatorConfig) GetMinAssertionL2Blocks() uint64      { return c.MinAssertionL2Blocks }
+func (c ValidatorConfig) GetMinAssertionL1Interval() uint64    { return c.MinAssertionL1Interval }
+func (c ValidatorConfig) GetMaxAssertionStaleness() uint64     { return c.MaxAssertionStaleness }
+func (c ValidatorConfig) GetMaxAssertionL1BaseFeeGwei() uint64 { return c.MaxAssertionL1BaseFeeGwei }
+func (c ValidatorConfig) GetCheckDerivation() bool             { return c.CheckDerivation }
 func (c ValidatorConfig) GetTxMgrCfg() txmgr.Config            { return c.TxMgrCfg }
 
 // Validates the configuration.
@@ -208,7 +371,23 @@ func (c ValidatorConfig) validate() error {
 	if !c.IsEnabled {
 		return nil
 	}
-	if c.PrivateKey == nil && c.ClefEndpoint == "" {
+	switch c.Mode {
+	case validator.WatchMode:
+	case validator.DefensiveMode, validator.ActiveMode:
+		// Challenges can't be completed without proving tx inclusion, which requires the derived batches.
+		if !c.CheckDerivation {
+			return fmt.Errorf("mode %s requires derivation checking (to prove tx inclusion in challenges)", c.Mode)
+		}
+	default:
+		return fmt.Errorf("invalid mode: %s", c.Mode)
+	}
+	switch eth.BlockTag(c.AssertionHead) {
+	case "", eth.Safe, eth.Finalized:
+	default:
+		return fmt.Errorf("invalid assertion head: %s", c.AssertionHead)
+	}
+	// Watch-only validators send no txs.
+	if c.Mode != validator.WatchMode && c.PrivateKey == nil && c.ClefEndpoint == "" {
 		return fmt.Errorf("missing both private key and clef endpoint (require at least one)")
 	}
 	return nil
@@ -219,15 +398,56 @@ func newValidatorConfigFromCLI(
 	txMgrCfg txmgr.Config,
 ) ValidatorConfig {
 	return ValidatorConfig{
-		IsEnabled:          cliCtx.Bool(validatorEnableFlag.Name),
-		AccountAddr:        txMgrCfg.From,
-		PrivateKey:         toPrivateKey(cliCtx.String(validatorPrivateKeyFlag.Name)),
-		ClefEndpoint:       cliCtx.String(validatorClefEndpointFlag.Name),
-		ValidationInterval: time.Duration(cliCtx.Uint(validatorValidationIntervalFlag.Name)) * time.Second,
-		TxMgrCfg:           txMgrCfg,
+		IsEnabled:                 cliCtx.Bool(validatorEnableFlag.Name),
+		AccountAddr:               txMgrCfg.From,
+		PrivateKey:                toPrivateKey(cliCtx.String(validatorPrivateKeyFlag.Name)),
+		ClefEndpoint:              cliCtx.String(validatorClefEndpointFlag.Name),
+		ValidationInterval:        time.Duration(cliCtx.Uint(validatorValidationIntervalFlag.Name)) * time.Second,
+		Mode:                      cliCtx.String(validatorModeFlag.Name),
+		AssertionHead:             cliCtx.String(validatorAssertionHeadFlag.Name),
+		MinAssertionL2Blocks:      cliCtx.Uint64(validatorMinAssertionL2BlocksFlag.Name),
+		MinAssertionL1Interval:    cliCtx.Uint64(validatorMinAssertionL1IntervalFlag.Name),
+		MaxAssertionStaleness:     cliCtx.Uint64(validatorMaxAssertionStalenessFlag.Name),
+		MaxAssertionL1BaseFeeGwei: cliCtx.Uint64(validatorMaxAssertionL1BaseFeeFlag.Name),
+		CheckDerivation:           cliCtx.Bool(validatorCheckDerivationFlag.Name),
+		TxMgrCfg:                  txMgrCfg,
+	}
+}
+
+// Admin RPC server configuration
+type AdminConfig struct {
+	// Address (host:port) on which the admin JSON-RPC API is served. If empty, the API is disabled.
+	RPCAddr string `toml:"rpc_addr,omitempty"`
+	// Path to a file holding a hex-encoded 32-byte JWT secret, used to authenticate requests.
+	// Required unless the API is served on a loopback address.
+	JWTSecretPath string `toml:"jwt_secret,omitempty"`
+}
+
+func newAdminConfigFromCLI(cliCtx *cli.Context) AdminConfig {
+	return AdminConfig{
+		RPCAddr:       cliCtx.String(adminRPCAddrFlag.Name),
+		JWTSecretPath: cliCtx.String(adminJWTSecretFlag.Name),
 	}
 }
 
+func (c AdminConfig) GetRPCAddr() string       { return c.RPCAddr }
+func (c AdminConfig) GetJWTSecretPath() string { return c.JWTSecretPath }
+
+func (c AdminConfig) validate() error {
+	if c.RPCAddr == "" || c.JWTSecretPath != "" {
+		return nil
+	}
+	// The API can pause and flush services, so it mustn't be exposed unauthenticated.
+	host, _, err := net.SplitHostPort(c.RPCAddr)
+	if err != nil {
+		return fmt.Errorf("invalid RPC address %s: %w", c.RPCAddr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("RPC address %s is not a loopback address, so a JWT secret is required", c.RPCAddr)
+	}
+	return nil
+}
+
 func toPrivateKey(keyStr string) *ecdsa.PrivateKey {
 	if keyStr == "" {
 		return nil
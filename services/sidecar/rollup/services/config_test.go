@@ -0,0 +1,142 @@
+package services
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/disseminator"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/validator"
+	"github.com/urfave/cli/v2"
+)
+
+func TestDisseminatorConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *DisseminatorConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*DisseminatorConfig) {}},
+		{name: "target gas unset", modify: func(c *DisseminatorConfig) { c.TargetBatchGas = 0 }},
+		{
+			name:    "target gas not exceeding fixed gas",
+			modify:  func(c *DisseminatorConfig) { c.TargetBatchGas = derivation.MinTargetBatchGas() },
+			wantErr: "target batch gas",
+		},
+		{
+			name:    "target gas below intrinsic gas",
+			modify:  func(c *DisseminatorConfig) { c.TargetBatchGas = 21_000 },
+			wantErr: "target batch gas",
+		},
+		{name: "disabled", modify: func(c *DisseminatorConfig) { c.IsEnabled, c.TargetBatchGas = false, 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DisseminatorConfig{
+				IsEnabled:      true,
+				ClefEndpoint:   "http://localhost:8550",
+				TargetBatchGas: 100_000,
+				DAMode:         disseminator.CalldataDAMode,
+			}
+			tt.modify(&cfg)
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAdminConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AdminConfig
+		wantErr bool
+	}{
+		{name: "disabled", cfg: AdminConfig{}},
+		{name: "loopback IPv4", cfg: AdminConfig{RPCAddr: "127.0.0.1:8560"}},
+		{name: "loopback IPv6", cfg: AdminConfig{RPCAddr: "[::1]:8560"}},
+		{name: "localhost", cfg: AdminConfig{RPCAddr: "localhost:8560"}},
+		{name: "all interfaces without JWT", cfg: AdminConfig{RPCAddr: ":8560"}, wantErr: true},
+		{name: "public address without JWT", cfg: AdminConfig{RPCAddr: "10.0.0.1:8560"}, wantErr: true},
+		{name: "public address with JWT", cfg: AdminConfig{RPCAddr: "0.0.0.0:8560", JWTSecretPath: "jwt.hex"}},
+		{name: "invalid address", cfg: AdminConfig{RPCAddr: "localhost"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ValidatorConfig
+		wantErr bool
+	}{
+		{name: "disabled", cfg: ValidatorConfig{Mode: "invalid"}},
+		{name: "watch without key", cfg: ValidatorConfig{IsEnabled: true, Mode: validator.WatchMode}},
+		// Watch mode must still be checked against the rest of the config.
+		{
+			name:    "watch with invalid assertion head",
+			cfg:     ValidatorConfig{IsEnabled: true, Mode: validator.WatchMode, AssertionHead: "latest"},
+			wantErr: true,
+		},
+		{
+			name:    "active without key",
+			cfg:     ValidatorConfig{IsEnabled: true, Mode: validator.ActiveMode, CheckDerivation: true},
+			wantErr: true,
+		},
+		{
+			name: "active with clef",
+			cfg:  ValidatorConfig{IsEnabled: true, Mode: validator.ActiveMode, CheckDerivation: true, ClefEndpoint: "http://clef"},
+		},
+		{
+			name:    "defensive without derivation checking",
+			cfg:     ValidatorConfig{IsEnabled: true, Mode: validator.DefensiveMode, ClefEndpoint: "http://clef"},
+			wantErr: true,
+		},
+		{name: "invalid mode", cfg: ValidatorConfig{IsEnabled: true, Mode: "passive"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Validators enabled with the default flags must be able to start (and challenge).
+func TestValidatorConfigDefaultFlags(t *testing.T) {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range validatorCLIFlags {
+		if err := f.Apply(set); err != nil {
+			t.Fatalf("failed to apply flag: %v", err)
+		}
+	}
+	if err := set.Parse([]string{"--validator", "--validator.clef-endpoint", "http://clef"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	cfg := newValidatorConfigFromCLI(cli.NewContext(cli.NewApp(), set, nil), txmgr.Config{})
+	if cfg.Mode != validator.ActiveMode {
+		t.Errorf("got mode %s, want %s", cfg.Mode, validator.ActiveMode)
+	}
+	if !cfg.CheckDerivation {
+		t.Error("derivation checking disabled by default")
+	}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package disseminator
+
+import (
+	"context"
+
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Snapshot of the disseminator's state, for monitoring.
+type Status struct {
+	Paused       bool          `json:"paused"`
+	LastEnqueued types.BlockID `json:"last_enqueued"`
+	NumPending   uint64        `json:"num_pending"` // # of enqueued blocks not yet handed off for sending
+	Timeout      uint64        `json:"timeout"`     // L1 block # at which the current batch is force-built (0 if unset)
+	NumInFlight  int           `json:"num_in_flight"`
+	NumPosted    int           `json:"num_posted"` // # of confirmed batch txs not yet finalized
+}
+
+// Pauses dissemination; blocks are neither appended nor sent until resumed.
+func (d *BatchDisseminator) Pause() {
+	log.Info("Pausing disseminator")
+	d.paused.Store(true)
+}
+
+func (d *BatchDisseminator) Resume() {
+	log.Info("Resuming disseminator")
+	d.paused.Store(false)
+}
+
+// Forces the current batch to be built and sent regardless of its size and L1 fees (even while paused).
+// Blocks until it's sent and, unless pipelining, confirmed.
+// If the disseminator is found in an unexpected state, returns the error and aborts (as on any other step).
+func (d *BatchDisseminator) Flush(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	select {
+	case d.flushReqs <- errCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Returns the last status snapshot (taken after each step).
+func (d *BatchDisseminator) Status() Status {
+	var status Status
+	if snapshot := d.status.Load(); snapshot != nil {
+		status = *snapshot
+	}
+	status.Paused = d.paused.Load()
+	return status
+}
+
+func (d *BatchDisseminator) flush(ctx context.Context) error {
+	log.Info("Flushing current batch")
+	d.batchBuilder.Flush()
+	d.forceSend = true
+	defer func() { d.forceSend = false }()
+	if err := d.step(ctx, true); err != nil {
+		return fmt.Errorf("failed to flush batch: %w", err)
+	}
+	return nil
+}
+
+func (d *BatchDisseminator) updateStatus() {
+	var (
+		lastEnqueued = d.batchBuilder.LastEnqueued()
+		numPending   uint64
+	)
+	if next := lastEnqueued.GetNumber() + 1; next > d.batchBuilder.FirstPending() {
+		numPending = next - d.batchBuilder.FirstPending()
+	}
+	d.status.Store(&Status{
+		LastEnqueued: lastEnqueued,
+		NumPending:   numPending,
+		Timeout:      d.batchBuilder.Timeout(),
+		NumInFlight:  len(d.inFlight),
+		NumPosted:    len(d.posted),
+	})
+}
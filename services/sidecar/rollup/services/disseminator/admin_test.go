@@ -0,0 +1,57 @@
+package disseminator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+)
+
+// Serves a safe head that may be moved while the disseminator runs.
+type testMovingSafeL2Client struct {
+	*testL2Client
+	safe atomic.Uint64
+}
+
+func (c *testMovingSafeL2Client) HeaderByTag(ctx context.Context, tag eth.BlockTag) (*ethTypes.Header, error) {
+	num := c.safe.Load()
+	if num < uint64(len(c.blocks)) {
+		return c.blocks[num].Header(), nil
+	}
+	return &ethTypes.Header{Number: new(big.Int).SetUint64(num)}, nil
+}
+
+func TestFlushUnexpectedSystemState(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		l2Client    = &testMovingSafeL2Client{testL2Client: newTestL2Client(2)}
+		d           = NewBatchDisseminator(
+			testConfig{}, &testBuilder{}, &testTxMgr{}, eth.NewEthState(), &testL1Client{}, l2Client, nil,
+			testInboxScanner{}, testDAProvider{}, rawdb.NewMemoryDatabase(),
+		)
+		done = make(chan error, 1)
+	)
+	defer cancel()
+	l2Client.safe.Store(1)
+	// Flushes even while paused; steps are otherwise skipped.
+	d.Pause()
+	go func() { done <- d.start(ctx) }()
+	if err := d.Flush(ctx); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+	// The safe head exceeds the last enqueued block.
+	l2Client.safe.Store(2)
+	// Previously, the error was reported to the caller but the disseminator kept running.
+	if err := d.Flush(ctx); !errors.As(err, &unexpectedSystemStateError{}) {
+		t.Fatalf("got error %v, want unexpected system state error", err)
+	}
+	if err := <-done; !errors.As(err, &unexpectedSystemStateError{}) {
+		t.Errorf("got error %v, want disseminator to abort with unexpected system state error", err)
+	}
+}
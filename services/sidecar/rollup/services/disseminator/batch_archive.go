@@ -0,0 +1,109 @@
+package disseminator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Name of the index file in an archive directory.
+const archiveIndexFile = "index.jsonl"
+
+// Archives batches (e.g. for offline analysis of batch sizes and L1 costs), as either:
+// - a JSONL file (if the path ends in `.jsonl`), with one record per batch, including its data; or
+// - a directory, with one file per batch and an index of records (excluding data).
+// Records are appended, so an archive can be reused across runs.
+type batchArchive struct {
+	path string
+}
+
+// A batch's size and estimated L1 costs.
+type archiveRecord struct {
+	Time             time.Time     `json:"time"`
+	DryRun           bool          `json:"dry_run"`
+	Hash             string        `json:"hash"`
+	Version          byte          `json:"version"`
+	Size             int           `json:"size"`
+	FirstL2Block     uint64        `json:"first_l2_block"`
+	LastL2Block      uint64        `json:"last_l2_block"`
+	EstimatedGas     uint64        `json:"estimated_gas"`      // as `appendTxBatch` calldata
+	NumBlobs         int           `json:"num_blobs"`          // if posted in blobs
+	EstimatedBlobGas uint64        `json:"estimated_blob_gas"` // if posted in blobs
+	File             string        `json:"file,omitempty"`
+	Data             hexutil.Bytes `json:"data,omitempty"`
+}
+
+// Returns nil if archiving isn't configured.
+func newBatchArchive(cfg Config) *batchArchive {
+	if cfg.GetArchivePath() == "" {
+		return nil
+	}
+	return &batchArchive{path: cfg.GetArchivePath()}
+}
+
+func (a *batchArchive) isJSONL() bool { return strings.HasSuffix(a.path, ".jsonl") }
+
+// Archives a batch, along with a record of its size and estimated L1 costs.
+func (a *batchArchive) add(batch []byte, dryRun bool) error {
+	record := newArchiveRecord(batch, dryRun)
+	indexPath := a.path
+	if a.isJSONL() {
+		record.Data = batch
+		if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+			return fmt.Errorf("failed to create archive directory: %w", err)
+		}
+	} else {
+		if err := os.MkdirAll(a.path, 0o755); err != nil {
+			return fmt.Errorf("failed to create archive directory: %w", err)
+		}
+		record.File = record.Hash + ".batch"
+		if err := os.WriteFile(filepath.Join(a.path, record.File), batch, 0o644); err != nil {
+			return fmt.Errorf("failed to write batch file: %w", err)
+		}
+		indexPath = filepath.Join(a.path, archiveIndexFile)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive record: %w", err)
+	}
+	f, err := os.OpenFile(indexPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive index: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write archive record: %w", err)
+	}
+	return nil
+}
+
+func newArchiveRecord(batch []byte, dryRun bool) archiveRecord {
+	numBlobs := derivation.NumBlobs(len(batch))
+	record := archiveRecord{
+		Time:             time.Now(),
+		DryRun:           dryRun,
+		Hash:             crypto.Keccak256Hash(batch).Hex(),
+		Size:             len(batch),
+		EstimatedGas:     derivation.AppendTxBatchDataGas(batch),
+		NumBlobs:         numBlobs,
+		EstimatedBlobGas: derivation.BlobGas(numBlobs),
+	}
+	if len(batch) > 0 {
+		record.Version = batch[0]
+	}
+	first, last, ok, err := derivation.DecodeL2BlockRange(batch)
+	if err != nil {
+		log.Warn("Failed to decode batch for archiving", "err", err)
+	} else if ok {
+		record.FirstL2Block, record.LastL2Block = first, last
+	}
+	return record
+}
@@ -5,25 +5,89 @@ import (
 	"errors"
 	"io"
 	"math/big"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
 	"github.com/specularL2/specular/services/sidecar/rollup/services/api"
 	"github.com/specularL2/specular/services/sidecar/rollup/types"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 	"github.com/specularL2/specular/services/sidecar/utils/log"
 )
 
+// Data-availability modes (i.e. how batches are posted to L1).
+const (
+	CalldataDAMode = "calldata" // Always post batches as calldata.
+	BlobDAMode     = "blobs"    // Always post batches in blobs (EIP-4844).
+	AutoDAMode     = "auto"     // Post batches using whichever of the above is estimated to be cheaper.
+)
+
 // Disseminates batches of L2 blocks via L1.
 type BatchDisseminator struct {
 	cfg          Config
 	batchBuilder BatchBuilder
-	l1TxMgr      TxManager
+	l1TxMgr      TxManager     // nil in dry runs
 	l1State      *eth.EthState // Expected to generally be kept in sync with L1 chain.
+	l1Client     L1Client
 	l2Client     L2Client
+	l2HeadSub    L2HeadSubscriber // Optional; if nil, L2 blocks are only polled for.
+	inboxScanner InboxScanner
+	da           DAProvider
+	feePolicy    *feePolicy
+	archive      *batchArchive // nil if not archiving
+
+	pendingFrames []derivation.Frame // Frames of the last built batch not yet sent.
+	pendingBatch  []byte             // The batch split into `pendingFrames`.
+	requeued      []*queuedTx        // Batch txs to re-send (in order) before any new ones.
+	posted        []*postedTx        // Confirmed batch txs not yet finalized, in nonce order.
+	nonce         *uint64            // Nonce of the next batch tx to send (nil until queried).
+
+	// Pipelined mode only.
+	inFlight []*inFlightTx // Sent batch txs awaiting confirmation, in nonce order.
+	numTaken int           // # of built batches taken into the pipeline, not yet advanced past (i.e. posted).
+
+	// Admin controls (see `admin.go`).
+	paused    atomic.Bool
+	flushReqs chan chan error
+	forceSend bool // Whether the current step sends regardless of L1 fees (set while flushing).
+	status    atomic.Pointer[Status]
+}
+
+// A confirmed batch tx, tracked until its L1 block is finalized (in case it's reorged out).
+type postedTx struct {
+	payload []byte
+	txHash  common.Hash
+	nonce   uint64
+	l1Block types.BlockID
 }
 
+// A batch tx (carrying a whole batch or a single frame) to send.
+type queuedTx struct {
+	payload []byte
+	// Whether the tx completes a batch still held by the builder (i.e. carries the whole batch or its last frame),
+	// in which case the builder is advanced once the tx is confirmed.
+	completesBatch bool
+	// The batch carried by the tx (or by the frames it's the last of), archived once the tx is confirmed.
+	// Nil for other frames, and txs re-sent after a reorg (whose batch was already archived).
+	batch []byte
+}
+
+// A batch tx sent but not yet confirmed.
+type inFlightTx struct {
+	*queuedTx
+	nonce    uint64
+	resultCh chan txmgr.SendResult
+	cancel   context.CancelFunc
+}
+
+var errTxReverted = errors.New("batch tx reverted")
+
 type unexpectedSystemStateError struct{ msg string }
 
 func (e unexpectedSystemStateError) Error() string {
@@ -39,9 +103,27 @@ func NewBatchDisseminator(
 	batchBuilder BatchBuilder,
 	l1TxMgr TxManager,
 	l1State *eth.EthState,
+	l1Client L1Client,
 	l2Client L2Client,
+	l2HeadSub L2HeadSubscriber,
+	inboxScanner InboxScanner,
+	da DAProvider,
+	db ethdb.KeyValueStore,
 ) *BatchDisseminator {
-	return &BatchDisseminator{cfg, batchBuilder, l1TxMgr, l1State, l2Client}
+	return &BatchDisseminator{
+		cfg:          cfg,
+		batchBuilder: batchBuilder,
+		l1TxMgr:      l1TxMgr,
+		l1State:      l1State,
+		l1Client:     l1Client,
+		l2Client:     l2Client,
+		l2HeadSub:    l2HeadSub,
+		inboxScanner: inboxScanner,
+		da:           da,
+		feePolicy:    newFeePolicy(cfg, db),
+		archive:      newBatchArchive(cfg),
+		flushReqs:    make(chan chan error),
+	}
 }
 
 func (s *BatchDisseminator) Start(ctx context.Context, eg api.ErrGroup) error {
@@ -55,34 +137,89 @@ func (s *BatchDisseminator) Start(ctx context.Context, eg api.ErrGroup) error {
 }
 
 func (d *BatchDisseminator) start(ctx context.Context) error {
-	// Start with latest safe state.
-	d.rollback()
-	var ticker = time.NewTicker(d.cfg.GetDisseminationInterval())
+	if err := d.recover(ctx); err != nil {
+		log.Error("Failed to recover dissemination state, rolling back to safe state", "err", err)
+		if err := d.rollback(); err != nil {
+			return fmt.Errorf("failed to roll back to safe state: %w", err)
+		}
+	}
+	d.updateStatus()
+	var (
+		ticker  = time.NewTicker(d.cfg.GetDisseminationInterval())
+		headCh  = make(chan *ethTypes.Header, newHeadBufferSize)
+		headSub ethereum.Subscription // Active L2 head subscription (nil if polling).
+	)
 	defer ticker.Stop()
+	defer func() {
+		if headSub != nil {
+			headSub.Unsubscribe()
+		}
+	}()
 	for {
 		select {
 		case <-ticker.C:
-			if err := d.step(ctx); err != nil {
+			if d.paused.Load() {
+				log.Trace("Disseminator paused, skipping step")
+				break
+			}
+			// Poll for blocks unless they're being pushed (e.g. to catch up after (re)subscribing).
+			poll := headSub == nil
+			if poll {
+				headSub = d.subscribeNewHeads(ctx, headCh)
+			}
+			if err := d.step(ctx, poll); err != nil {
 				if errors.As(err, &unexpectedSystemStateError{}) {
 					return fmt.Errorf("aborting: %w", err)
 				}
 				log.Errorf("Failed to step: %w", err)
 			}
+		case head := <-headCh:
+			if d.paused.Load() {
+				break
+			}
+			if err := d.onNewHead(ctx, head); err != nil {
+				log.Errorf("Failed to handle new L2 head: %w", err)
+			}
+		case errCh := <-d.flushReqs:
+			err := d.flush(ctx)
+			errCh <- err
+			if errors.As(err, &unexpectedSystemStateError{}) {
+				return fmt.Errorf("aborting: %w", err)
+			}
+		case err := <-subErr(headSub):
+			log.Warn("L2 head subscription dropped, falling back to polling", "err", err)
+			headSub.Unsubscribe()
+			headSub = nil
 		case <-ctx.Done():
 			log.Info("Aborting.")
 			return nil
 		}
+		d.updateStatus()
 	}
 }
 
 // Attempts to (incrementally) build a batch and disseminate it via L1.
-func (d *BatchDisseminator) step(ctx context.Context) error {
-	if err := d.appendToBuilder(ctx); err != nil {
-		if errors.As(err, &L2ReorgDetectedError{}) {
-			log.Error("Reorg detected, reverting to safe state.", "error", err)
-			d.rollback()
+// If `poll` is set, first appends all new L2 blocks to the batch builder.
+func (d *BatchDisseminator) step(ctx context.Context, poll bool) error {
+	if poll {
+		if err := d.appendToBuilder(ctx); err != nil {
+			return fmt.Errorf("failed to append to batch builder: %w", d.handleAppendError(err))
 		}
-		return fmt.Errorf("failed to append to batch builder: %w", err)
+	}
+	if err := d.checkPosted(ctx); err != nil {
+		return fmt.Errorf("failed to check posted batches: %w", err)
+	}
+	if d.cfg.GetDryRun() {
+		if err := d.dryRunBatches(ctx); err != nil {
+			return fmt.Errorf("failed to dry-run batches: %w", err)
+		}
+		return nil
+	}
+	if d.cfg.GetMaxInFlight() > 1 {
+		if err := d.disseminatePipelined(ctx); err != nil {
+			return fmt.Errorf("failed to sequence batches (pipelined): %w", err)
+		}
+		return nil
 	}
 	if err := d.disseminateBatches(ctx); err != nil {
 		return fmt.Errorf("failed to sequence batches: %w", err)
@@ -90,6 +227,43 @@ func (d *BatchDisseminator) step(ctx context.Context) error {
 	return nil
 }
 
+// Determines where to resume dissemination from after a (re)start: right after the last L2 block
+// already posted by this batcher (per the inbox), or the L2 safe head, whichever is later.
+// Persisted builder state is kept if it continues from there; otherwise the builder is reset.
+func (d *BatchDisseminator) recover(ctx context.Context) error {
+	safe, err := d.l2Client.HeaderByTag(ctx, eth.Safe)
+	if err != nil {
+		return fmt.Errorf("failed to get last safe header: %w", err)
+	}
+	resumeAfter := safe.Number.Uint64()
+	lastPosted, found, err := d.inboxScanner.LastPostedL2BlockNum(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to scan inbox: %w", err)
+	}
+	if found && lastPosted > resumeAfter {
+		log.Info("Inbox is ahead of L2 safe head", "last_posted", lastPosted, "safe", resumeAfter)
+		resumeAfter = lastPosted
+	}
+	var (
+		lastEnqueued = d.batchBuilder.LastEnqueued()
+		firstPending = d.batchBuilder.FirstPending()
+	)
+	// Note: this may re-post some blocks, if they were posted but not yet handed off.
+	if lastEnqueued != types.EmptyBlockID && lastEnqueued.GetNumber() >= resumeAfter && firstPending <= resumeAfter+1 {
+		log.Info("Resuming from persisted builder state", "last_enqueued", lastEnqueued, "first_pending", firstPending)
+		return nil
+	}
+	header, err := d.l2Client.HeaderByNumber(ctx, new(big.Int).SetUint64(resumeAfter))
+	if err != nil {
+		return fmt.Errorf("failed to get header (num=%d): %w", resumeAfter, err)
+	}
+	log.Info("Resuming after last posted block", "l2Block#", resumeAfter)
+	if err := d.resetBuilder(header); err != nil {
+		return fmt.Errorf("failed to reset batch builder: %w", err)
+	}
+	return nil
+}
+
 // Rolls back the disseminator state to the last safe L2 header.
 func (d *BatchDisseminator) rollback() error {
 	head, err := d.l2Client.HeaderByTag(context.Background(), eth.Safe)
@@ -97,10 +271,43 @@ func (d *BatchDisseminator) rollback() error {
 		return fmt.Errorf("failed to get last safe header: %w", err)
 	}
 	log.Info("Rolling back disseminator to checkpoint", "l2Block#", head.Number)
-	d.batchBuilder.Reset(types.NewBlockIDFromHeader(head))
+	if err := d.resetBuilder(head); err != nil {
+		return fmt.Errorf("failed to reset batch builder: %w", err)
+	}
+	return nil
+}
+
+// Resets the batch builder to continue after the given L2 header, discarding any frames not yet sent.
+// Batch txs already in the pipeline are still sent, but no longer advance the builder once confirmed.
+func (d *BatchDisseminator) resetBuilder(header *ethTypes.Header) error {
+	if err := d.batchBuilder.Reset(types.NewBlockIDFromHeader(header)); err != nil {
+		return err
+	}
+	d.pendingFrames, d.pendingBatch = nil, nil
+	d.numTaken = 0
+	for _, tx := range d.inFlight {
+		tx.completesBatch = false
+	}
+	for _, tx := range d.requeued {
+		tx.completesBatch = false
+	}
 	return nil
 }
 
+// Rolls back to the last safe L2 header if appending failed due to a reorg.
+// Returns the append error, or the rollback error if rolling back failed (in which case the reorg is
+// detected again, and the rollback retried, on the next append).
+func (d *BatchDisseminator) handleAppendError(err error) error {
+	if !errors.As(err, &L2ReorgDetectedError{}) {
+		return err
+	}
+	log.Error("Reorg detected, reverting to safe state.", "error", err)
+	if rollbackErr := d.rollback(); rollbackErr != nil {
+		return fmt.Errorf("failed to roll back after reorg (%s): %w", err, rollbackErr)
+	}
+	return err
+}
+
 // Appends L2 blocks to batch builder.
 func (d *BatchDisseminator) appendToBuilder(ctx context.Context) error {
 	start, end, err := d.pendingL2BlockRange(ctx)
@@ -111,6 +318,11 @@ func (d *BatchDisseminator) appendToBuilder(ctx context.Context) error {
 		log.Info("No pending blocks to append", "start", start, "end", end)
 		return nil
 	}
+	return d.appendRange(ctx, start, end)
+}
+
+// Appends L2 blocks `start` through `end` (inclusive) to batch builder.
+func (d *BatchDisseminator) appendRange(ctx context.Context, start, end uint64) error {
 	log.Info("Appending blocks to builder", "start", start, "end", end)
 	for i := start; i <= end; i++ {
 		block, err := d.l2Client.BlockByNumber(ctx, big.NewInt(0).SetUint64(i))
@@ -133,7 +345,7 @@ func (d *BatchDisseminator) appendToBuilder(ctx context.Context) error {
 func (d *BatchDisseminator) pendingL2BlockRange(ctx context.Context) (uint64, uint64, error) {
 	var (
 		lastEnqueued = d.batchBuilder.LastEnqueued()
-		start        = lastEnqueued.GetNumber() + 1 // TODO: fix assumption
+		start        = lastEnqueued.GetNumber() + 1
 	)
 	safe, err := d.l2Client.HeaderByTag(ctx, eth.Safe)
 	if err != nil {
@@ -144,8 +356,10 @@ func (d *BatchDisseminator) pendingL2BlockRange(ctx context.Context) (uint64, ui
 		// First time running; use safe (assumes local chain fork-choice is in sync...)
 		start = safe.Number.Uint64() + 1
 	} else if safe.Number.Uint64() > lastEnqueued.GetNumber() {
-		// This should currently not be possible (single sequencer). TODO: handle restart case?
-		return 0, 0, &unexpectedSystemStateError{msg: "Safe header exceeds last appended header"}
+		// Blocks past the last enqueued one can only have been posted before a restart (single sequencer),
+		// which `recover` accounts for.
+		log.Error("Safe header exceeds last enqueued header", "safe", safe.Number, "last_enqueued", lastEnqueued)
+		return 0, 0, unexpectedSystemStateError{msg: "Safe header exceeds last appended header"}
 	}
 	end, err := d.l2Client.BlockNumber(ctx)
 	if err != nil {
@@ -156,6 +370,9 @@ func (d *BatchDisseminator) pendingL2BlockRange(ctx context.Context) (uint64, ui
 
 // Disseminates batches until batch builder runs out (or signal from `ctx`).
 func (d *BatchDisseminator) disseminateBatches(ctx context.Context) error {
+	if err := d.resendRequeued(ctx); err != nil {
+		return fmt.Errorf("failed to re-send batches: %w", err)
+	}
 	for {
 		// Non-blocking ctx check.
 		select {
@@ -167,6 +384,9 @@ func (d *BatchDisseminator) disseminateBatches(ctx context.Context) error {
 					log.Info("No pending batches to sequence")
 					return nil
 				}
+				if errors.Is(err, errFeeThrottled) {
+					return nil
+				}
 				return fmt.Errorf("failed to sequence batch: %w", err)
 			}
 		}
@@ -182,11 +402,455 @@ func (d *BatchDisseminator) disseminateBatch(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to build batch: %w", err)
 	}
-	receipt, err := d.l1TxMgr.AppendTxBatch(ctx, data)
+	// Don't hold back the rest of a batch whose frames are partially sent.
+	if len(d.pendingFrames) == 0 {
+		if err := d.checkFees(ctx); err != nil {
+			return err
+		}
+	}
+	// Idempotent, so it's fine to repeat for a batch that's being re-sent.
+	payload, err := d.da.Put(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to make batch available: %w", err)
+	}
+	if d.cfg.GetMaxFrameSize() != 0 {
+		if err := d.disseminateFrames(ctx, payload); err != nil {
+			return fmt.Errorf("failed to send batch frames: %w", err)
+		}
+	} else {
+		receipt, err := d.postBatch(ctx, payload)
+		if err != nil {
+			return fmt.Errorf("failed to send batch transaction: %w", err)
+		}
+		log.Info("Sequenced batch to L1", "tx_hash", receipt.TxHash, "l1Block#", receipt.BlockNumber)
+	}
+	d.archiveBatch(data, false)
+	d.batchBuilder.Advance()
+	return nil
+}
+
+// Builds batches until batch builder runs out (or signal from `ctx`), archiving them instead of sending them.
+// No L1 txs are sent, and the builder's state is expected to be kept in memory only (see `openDisseminatorDB`),
+// so advancing it past the built batches has no lasting effect.
+func (d *BatchDisseminator) dryRunBatches(ctx context.Context) error {
+	for {
+		// Non-blocking ctx check.
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		data, err := d.batchBuilder.Build(d.l1State.Head())
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Info("No pending batches to dry-run")
+				return nil
+			}
+			return fmt.Errorf("failed to build batch: %w", err)
+		}
+		log.Info(
+			"Built batch (dry run)",
+			"size", len(data), "estimated_gas", derivation.AppendTxBatchDataGas(data), "num_blobs", derivation.NumBlobs(len(data)),
+		)
+		d.archiveBatch(data, true)
+		d.batchBuilder.Advance()
+	}
+}
+
+// Archives a batch, if configured to. Failing to do so doesn't affect dissemination.
+func (d *BatchDisseminator) archiveBatch(data []byte, dryRun bool) {
+	if d.archive == nil {
+		return
+	}
+	if err := d.archive.add(data, dryRun); err != nil {
+		log.Error("Failed to archive batch", "err", err)
+	}
+}
+
+// Re-sends requeued batch txs in order, awaiting each.
+func (d *BatchDisseminator) resendRequeued(ctx context.Context) error {
+	for len(d.requeued) > 0 {
+		tx := d.requeued[0]
+		receipt, err := d.postBatch(ctx, tx.payload)
+		if err != nil {
+			return fmt.Errorf("failed to send batch transaction: %w", err)
+		}
+		log.Info("Re-sequenced batch tx to L1", "tx_hash", receipt.TxHash, "l1Block#", receipt.BlockNumber)
+		d.requeued = d.requeued[1:]
+		d.onPosted(tx)
+	}
+	return nil
+}
+
+// Splits a batch into frames and sends them in order, one tx per frame.
+// If sending fails, the remaining frames are retried on the next call (with the same batch).
+func (d *BatchDisseminator) disseminateFrames(ctx context.Context, data []byte) error {
+	channelID := derivation.NewChannelID(data)
+	if len(d.pendingFrames) == 0 || d.pendingFrames[0].ChannelID != channelID {
+		frames, err := derivation.SplitIntoFrames(data, d.cfg.GetMaxFrameSize())
+		if err != nil {
+			return fmt.Errorf("failed to split batch into frames: %w", err)
+		}
+		d.pendingFrames = frames
+	}
+	for len(d.pendingFrames) > 0 {
+		frame := d.pendingFrames[0]
+		receipt, err := d.postBatch(ctx, frame.Marshal())
+		if err != nil {
+			return fmt.Errorf("failed to send frame (channel=%s, frame#=%d): %w", channelID, frame.FrameNum, err)
+		}
+		log.Info(
+			"Sequenced frame to L1",
+			"channel", channelID, "frame#", frame.FrameNum, "is_last", frame.IsLast,
+			"tx_hash", receipt.TxHash, "l1Block#", receipt.BlockNumber,
+		)
+		d.pendingFrames = d.pendingFrames[1:]
+	}
+	return nil
+}
+
+// Keeps up to `MaxInFlight` batch txs in flight, and handles the results of those that completed.
+// Txs are sent with sequential nonces; when one fails, all later ones are cancelled and requeued in order,
+// to be re-sent with the same nonces (replacing them, if still pending).
+// Batches are held by the builder until the tx completing them is confirmed, so they're rebuilt after a restart.
+func (d *BatchDisseminator) disseminatePipelined(ctx context.Context) error {
+	if err := d.handleResults(ctx); err != nil {
+		return err
+	}
+	for uint64(len(d.inFlight)) < d.cfg.GetMaxInFlight() {
+		// Non-blocking ctx check.
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		next, err := d.nextPayload(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Info("No pending batches to sequence")
+				return nil
+			}
+			if errors.Is(err, errFeeThrottled) {
+				return nil
+			}
+			return fmt.Errorf("failed to get next batch: %w", err)
+		}
+		nonce, err := d.nextNonce(ctx)
+		if err != nil {
+			d.requeued = append([]*queuedTx{next}, d.requeued...)
+			return err
+		}
+		var (
+			txCtx, cancel = context.WithCancel(ctx)
+			tx            = &inFlightTx{queuedTx: next, nonce: nonce, resultCh: make(chan txmgr.SendResult, 1), cancel: cancel}
+		)
+		if err := d.sendBatchAsync(txCtx, next.payload, nonce, tx.resultCh); err != nil {
+			cancel()
+			d.requeued = append([]*queuedTx{next}, d.requeued...)
+			return fmt.Errorf("failed to send batch transaction: %w", err)
+		}
+		*d.nonce = nonce + 1
+		d.inFlight = append(d.inFlight, tx)
+		log.Info("Sent batch tx", "nonce", nonce, "#in_flight", len(d.inFlight))
+	}
+	return nil
+}
+
+// Handles the results of completed in-flight txs, in order (stopping at the first that's still pending).
+// If a tx failed or reverted, cancels all later in-flight txs and requeues them (in order).
+func (d *BatchDisseminator) handleResults(ctx context.Context) error {
+	for len(d.inFlight) > 0 {
+		var result txmgr.SendResult
+		select {
+		case result = <-d.inFlight[0].resultCh:
+		default:
+			return nil
+		}
+		done := d.inFlight[0]
+		done.cancel()
+		d.inFlight = d.inFlight[1:]
+		var (
+			err       = result.Err
+			nextNonce = done.nonce // Re-sent with the same nonce, unless it was used up.
+		)
+		if err == nil {
+			d.feePolicy.record(result.Receipt)
+			if result.Receipt.Status == ethTypes.ReceiptStatusSuccessful {
+				log.Info("Sequenced batch tx to L1", "tx_hash", result.Receipt.TxHash, "l1Block#", result.Receipt.BlockNumber)
+				d.trackPosted(done.payload, done.nonce, result.Receipt)
+				d.onPosted(done.queuedTx)
+				continue
+			}
+			err = fmt.Errorf("%w (tx=%s)", errTxReverted, result.Receipt.TxHash)
+			nextNonce++
+		}
+		log.Warn("Batch tx failed; requeuing it and all later in-flight txs", "#requeued", len(d.inFlight)+1, "err", err)
+		// Later txs can't be included without the failed one's nonce (nor should they, to keep batches in order).
+		d.requeued = append(append([]*queuedTx{done.queuedTx}, d.cancelInFlight()...), d.requeued...)
+		d.nonce = &nextNonce
+		return fmt.Errorf("batch tx failed: %w", err)
+	}
+	return nil
+}
+
+// Cancels all in-flight txs, returning them (in order) for requeuing.
+// They aren't awaited: the nonces they were sent with are only reused to re-send them, in the same order.
+func (d *BatchDisseminator) cancelInFlight() []*queuedTx {
+	txs := make([]*queuedTx, 0, len(d.inFlight))
+	for _, tx := range d.inFlight {
+		tx.cancel()
+		txs = append(txs, tx.queuedTx)
+	}
+	d.inFlight = nil
+	return txs
+}
+
+// Returns `errFeeThrottled` if the last built batch should be held back due to L1 fees (see `feePolicy`).
+func (d *BatchDisseminator) checkFees(ctx context.Context) error {
+	if d.forceSend || !d.feePolicy.enabled() {
+		return nil
+	}
+	if deadline, ok := d.batchBuilder.Deadline(); d.feePolicy.isUrgent(d.l1State.Head().GetNumber(), deadline, ok) {
+		return nil
+	}
+	_, basefee, _, err := d.l1TxMgr.SuggestGasPriceCaps(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to send batch transaction: %w", err)
+		return fmt.Errorf("failed to get L1 fees: %w", err)
+	}
+	return d.feePolicy.check(basefee)
+}
+
+// Starts tracking a confirmed batch tx until its L1 block is finalized.
+func (d *BatchDisseminator) trackPosted(payload []byte, nonce uint64, receipt *ethTypes.Receipt) {
+	d.posted = append(d.posted, &postedTx{
+		payload: payload,
+		txHash:  receipt.TxHash,
+		nonce:   nonce,
+		l1Block: types.NewBlockID(receipt.BlockNumber.Uint64(), receipt.BlockHash),
+	})
+}
+
+// Archives the batch completed by a confirmed tx, and advances the builder past it if it's still held by the builder.
+func (d *BatchDisseminator) onPosted(tx *queuedTx) {
+	if tx.batch != nil {
+		d.archiveBatch(tx.batch, false)
+	}
+	if !tx.completesBatch {
+		return
 	}
-	log.Info("Sequenced batch to L1", "tx_hash", receipt.TxHash, "l1Block#", receipt.BlockNumber)
 	d.batchBuilder.Advance()
+	if d.numTaken > 0 {
+		d.numTaken--
+	}
+}
+
+// Checks that each tracked batch tx is still included in the canonical L1 chain, and stops tracking those
+// whose L1 block is finalized. Txs that were reorged out are requeued (in order) for re-sending,
+// along with all in-flight txs, which can't be included without them.
+func (d *BatchDisseminator) checkPosted(ctx context.Context) error {
+	var (
+		finalized = d.l1State.Finalized().GetNumber()
+		remaining []*postedTx
+		reorged   []*postedTx
+	)
+	for _, tx := range d.posted {
+		if tx.l1Block.GetNumber() <= finalized {
+			continue
+		}
+		included, err := d.isIncluded(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to check batch tx inclusion (tx=%s): %w", tx.txHash, err)
+		}
+		if !included {
+			reorged = append(reorged, tx)
+			continue
+		}
+		remaining = append(remaining, tx)
+	}
+	d.posted = remaining
+	if len(reorged) == 0 {
+		return nil
+	}
+	log.Warn("Batch txs reorged out of L1; requeuing them", "#reorged", len(reorged), "#in_flight", len(d.inFlight))
+	requeued := make([]*queuedTx, 0, len(reorged))
+	for _, tx := range reorged {
+		// Its batch was already advanced past.
+		requeued = append(requeued, &queuedTx{payload: tx.payload})
+	}
+	d.requeued = append(append(requeued, d.cancelInFlight()...), d.requeued...)
+	// Re-send from the first reorged tx's nonce, replacing the reorged txs (and any later ones) in order,
+	// in case they're back in the mempool.
+	nonce := reorged[0].nonce
+	d.nonce = &nonce
 	return nil
 }
+
+// Returns true if the batch tx is included in the canonical L1 chain,
+// updating its L1 block if it was re-included in a different one.
+func (d *BatchDisseminator) isIncluded(ctx context.Context, tx *postedTx) (bool, error) {
+	header, err := d.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(tx.l1Block.GetNumber()))
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return false, fmt.Errorf("failed to get L1 header (num=%d): %w", tx.l1Block.GetNumber(), err)
+	}
+	if err == nil && header.Hash() == tx.l1Block.GetHash() {
+		return true, nil
+	}
+	// The block was reorged out, but the tx may have been re-included (e.g. from the mempool).
+	receipt, err := d.l1Client.TransactionReceipt(ctx, tx.txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	if receipt.Status != ethTypes.ReceiptStatusSuccessful {
+		return false, nil
+	}
+	tx.l1Block = types.NewBlockID(receipt.BlockNumber.Uint64(), receipt.BlockHash)
+	log.Info("Batch tx re-included after L1 reorg", "tx_hash", tx.txHash, "l1Block#", tx.l1Block.GetNumber())
+	return true, nil
+}
+
+// Returns the next batch tx to send: a requeued one, the next frame of the current batch,
+// or a newly built batch (or its first frame). Built batches are held by the builder until posted.
+// Returns an `io.EOF` error if there's nothing to send, or `errFeeThrottled` if a new batch is held back.
+func (d *BatchDisseminator) nextPayload(ctx context.Context) (*queuedTx, error) {
+	if len(d.requeued) > 0 {
+		tx := d.requeued[0]
+		d.requeued = d.requeued[1:]
+		return tx, nil
+	}
+	if len(d.pendingFrames) == 0 {
+		data, err := d.batchBuilder.BuildAhead(d.l1State.Head(), d.numTaken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build batch: %w", err)
+		}
+		if err := d.checkFees(ctx); err != nil {
+			return nil, err
+		}
+		payload, err := d.da.Put(ctx, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make batch available: %w", err)
+		}
+		d.numTaken++
+		if d.cfg.GetMaxFrameSize() == 0 {
+			return &queuedTx{payload: payload, completesBatch: true, batch: data}, nil
+		}
+		frames, err := derivation.SplitIntoFrames(payload, d.cfg.GetMaxFrameSize())
+		if err != nil {
+			d.numTaken--
+			return nil, fmt.Errorf("failed to split batch into frames: %w", err)
+		}
+		d.pendingFrames, d.pendingBatch = frames, data
+	}
+	frame := d.pendingFrames[0]
+	d.pendingFrames = d.pendingFrames[1:]
+	tx := &queuedTx{payload: frame.Marshal(), completesBatch: frame.IsLast}
+	if frame.IsLast {
+		tx.batch, d.pendingBatch = d.pendingBatch, nil
+	}
+	return tx, nil
+}
+
+// Returns the nonce to send the next batch tx with, querying the L1 account's if it's unknown.
+func (d *BatchDisseminator) nextNonce(ctx context.Context) (uint64, error) {
+	if d.nonce == nil {
+		nonce, err := d.l1TxMgr.LatestNonce(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get nonce: %w", err)
+		}
+		d.nonce = &nonce
+	}
+	return *d.nonce, nil
+}
+
+// Sends a batch to L1 with the next nonce, awaiting its confirmation, and tracks it once confirmed.
+// Returns an error if the tx failed (in which case its nonce is reused) or reverted.
+func (d *BatchDisseminator) postBatch(ctx context.Context, data []byte) (*ethTypes.Receipt, error) {
+	nonce, err := d.nextNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := d.sendBatch(ctx, data, nonce)
+	if err != nil {
+		return nil, err
+	}
+	*d.nonce = nonce + 1
+	d.feePolicy.record(receipt)
+	if receipt.Status != ethTypes.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w (tx=%s)", errTxReverted, receipt.TxHash)
+	}
+	d.trackPosted(data, nonce, receipt)
+	return receipt, nil
+}
+
+// Sends a batch to L1 asynchronously (see `sendBatch`), delivering the outcome on `resultCh`.
+func (d *BatchDisseminator) sendBatchAsync(
+	ctx context.Context,
+	data []byte,
+	nonce uint64,
+	resultCh chan<- txmgr.SendResult,
+) error {
+	useBlobs, err := d.shouldUseBlobs(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to determine DA mode: %w", err)
+	}
+	if !useBlobs {
+		return d.l1TxMgr.AppendTxBatchAsync(ctx, data, nonce, resultCh)
+	}
+	blobs, err := derivation.EncodeBlobs(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode batch into blobs: %w", err)
+	}
+	log.Info("Sending batch in blobs", "num_blobs", len(blobs))
+	return d.l1TxMgr.AppendBlobTxBatchAsync(ctx, derivation.BlobHeader(data), blobs, nonce, resultCh)
+}
+
+// Sends a batch to L1 with the given nonce, either as calldata or in blobs (depending on the DA mode).
+func (d *BatchDisseminator) sendBatch(ctx context.Context, data []byte, nonce uint64) (*ethTypes.Receipt, error) {
+	useBlobs, err := d.shouldUseBlobs(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine DA mode: %w", err)
+	}
+	if !useBlobs {
+		return d.l1TxMgr.AppendTxBatch(ctx, data, nonce)
+	}
+	blobs, err := derivation.EncodeBlobs(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch into blobs: %w", err)
+	}
+	// Only the header (version byte or frame header) is passed as calldata; the full data is carried in blobs.
+	log.Info("Sending batch in blobs", "num_blobs", len(blobs))
+	return d.l1TxMgr.AppendBlobTxBatch(ctx, derivation.BlobHeader(data), blobs, nonce)
+}
+
+// Returns true if the batch should be posted in blobs.
+// In auto mode, compares the estimated L1 cost of posting the batch as calldata vs. in blobs.
+func (d *BatchDisseminator) shouldUseBlobs(ctx context.Context, data []byte) (bool, error) {
+	switch d.cfg.GetDAMode() {
+	case CalldataDAMode:
+		return false, nil
+	case BlobDAMode:
+		return true, nil
+	}
+	numBlobs := derivation.NumBlobs(len(data))
+	if numBlobs > derivation.MaxBlobsPerTx {
+		return false, nil
+	}
+	tip, basefee, blobBaseFee, err := d.l1TxMgr.SuggestGasPriceCaps(ctx)
+	if err != nil {
+		return false, err
+	}
+	if blobBaseFee == nil {
+		log.Trace("L1 is pre-cancun; using calldata")
+		return false, nil
+	}
+	var (
+		gasPrice     = new(big.Int).Add(basefee, tip)
+		calldataCost = new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(derivation.CalldataGas(data)))
+		blobCost     = new(big.Int).Mul(blobBaseFee, new(big.Int).SetUint64(derivation.BlobGas(numBlobs)))
+	)
+	blobCost.Add(blobCost, new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(derivation.CalldataGas(derivation.BlobHeader(data)))))
+	log.Info("Estimated batch DA costs", "calldata_cost", calldataCost, "blob_cost", blobCost)
+	return blobCost.Cmp(calldataCost) < 0, nil
+}
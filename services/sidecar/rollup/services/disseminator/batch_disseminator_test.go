@@ -0,0 +1,649 @@
+package disseminator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+type testConfig struct {
+	maxInFlight  uint64
+	maxFrameSize uint64
+	dryRun       bool
+	archivePath  string
+}
+
+func (c testConfig) GetDisseminationInterval() time.Duration { return time.Millisecond }
+func (c testConfig) GetDAMode() string                       { return CalldataDAMode }
+func (c testConfig) GetMaxFrameSize() uint64                 { return c.maxFrameSize }
+func (c testConfig) GetMaxInFlight() uint64                  { return c.maxInFlight }
+func (c testConfig) GetMaxL1BaseFeeGwei() uint64             { return 0 }
+func (c testConfig) GetDailyFeeBudgetGwei() uint64           { return 0 }
+func (c testConfig) GetUrgencyMargin() uint64                { return 0 }
+func (c testConfig) GetDryRun() bool                         { return c.dryRun }
+func (c testConfig) GetArchivePath() string                  { return c.archivePath }
+
+// Builds the given batches, in order, as if they were ready.
+type testBuilder struct {
+	lastEnqueued types.BlockID
+	enqueued     []uint64 // numbers of enqueued blocks
+	resets       []types.BlockID
+	resetErr     error
+	ready        [][]byte // batches ready to be built
+	built        [][]byte // built batches not yet advanced past
+	advanced     [][]byte // batches advanced past
+}
+
+func (b *testBuilder) Enqueue(block *ethTypes.Block) error {
+	if block.ParentHash() != b.lastEnqueued.GetHash() {
+		return derivation.InvalidBlockError{Msg: "not a child"}
+	}
+	b.lastEnqueued = types.NewBlockID(block.NumberU64(), block.Hash())
+	b.enqueued = append(b.enqueued, block.NumberU64())
+	return nil
+}
+func (b *testBuilder) LastEnqueued() types.BlockID                { return b.lastEnqueued }
+func (b *testBuilder) FirstPending() uint64                       { return 0 }
+func (b *testBuilder) Deadline() (uint64, bool)                   { return 0, false }
+func (b *testBuilder) Timeout() uint64                            { return 0 }
+func (b *testBuilder) Flush()                                     {}
+func (b *testBuilder) Build(l1Head types.BlockID) ([]byte, error) { return b.BuildAhead(l1Head, 0) }
+func (b *testBuilder) BuildAhead(l1Head types.BlockID, n int) ([]byte, error) {
+	if n < len(b.built) {
+		return b.built[n], nil
+	}
+	if n > len(b.built) {
+		return nil, errors.New("cannot build ahead of unbuilt batches")
+	}
+	if len(b.ready) == 0 {
+		return nil, io.EOF
+	}
+	b.built, b.ready = append(b.built, b.ready[0]), b.ready[1:]
+	return b.built[n], nil
+}
+func (b *testBuilder) Advance() {
+	if len(b.built) > 0 {
+		b.advanced, b.built = append(b.advanced, b.built[0]), b.built[1:]
+	}
+}
+func (b *testBuilder) Reset(lastEnqueued types.BlockID) error {
+	if b.resetErr != nil {
+		return b.resetErr
+	}
+	b.resets = append(b.resets, lastEnqueued)
+	b.lastEnqueued = lastEnqueued
+	b.ready, b.built = nil, nil
+	return nil
+}
+
+// A batch tx sent by the test tx manager.
+type testSentTx struct {
+	payload  []byte
+	nonce    uint64
+	resultCh chan<- txmgr.SendResult // nil if sent synchronously
+}
+
+// Records sent batch txs. Async txs complete once the test delivers their results;
+// sync txs complete immediately, with the next of `results` (or successfully, if none are left).
+type testTxMgr struct {
+	latestNonce uint64
+	sent        []*testSentTx
+	results     []txmgr.SendResult
+}
+
+func (m *testTxMgr) AppendTxBatch(ctx context.Context, batch []byte, nonce uint64) (*ethTypes.Receipt, error) {
+	m.sent = append(m.sent, &testSentTx{payload: batch, nonce: nonce})
+	if len(m.results) == 0 {
+		return testReceipt(ethTypes.ReceiptStatusSuccessful, uint64(len(m.sent))), nil
+	}
+	result := m.results[0]
+	m.results = m.results[1:]
+	return result.Receipt, result.Err
+}
+func (m *testTxMgr) AppendBlobTxBatch(
+	ctx context.Context,
+	header []byte,
+	blobs []kzg4844.Blob,
+	nonce uint64,
+) (*ethTypes.Receipt, error) {
+	return nil, errors.New("blobs not supported")
+}
+func (m *testTxMgr) AppendTxBatchAsync(
+	ctx context.Context,
+	batch []byte,
+	nonce uint64,
+	resultCh chan<- txmgr.SendResult,
+) error {
+	m.sent = append(m.sent, &testSentTx{payload: batch, nonce: nonce, resultCh: resultCh})
+	return nil
+}
+func (m *testTxMgr) AppendBlobTxBatchAsync(
+	ctx context.Context,
+	header []byte,
+	blobs []kzg4844.Blob,
+	nonce uint64,
+	resultCh chan<- txmgr.SendResult,
+) error {
+	return errors.New("blobs not supported")
+}
+func (m *testTxMgr) SuggestGasPriceCaps(ctx context.Context) (*big.Int, *big.Int, *big.Int, error) {
+	return big.NewInt(1), big.NewInt(1), nil, nil
+}
+func (m *testTxMgr) LatestNonce(ctx context.Context) (uint64, error) { return m.latestNonce, nil }
+
+// Returns the payloads and nonces of the sent txs, from the `i`-th on.
+func (m *testTxMgr) sentSince(i int) ([]string, []uint64) {
+	var (
+		payloads []string
+		nonces   []uint64
+	)
+	for _, tx := range m.sent[i:] {
+		payloads, nonces = append(payloads, string(tx.payload)), append(nonces, tx.nonce)
+	}
+	return payloads, nonces
+}
+
+func testReceipt(status uint64, l1BlockNum uint64) *ethTypes.Receipt {
+	return &ethTypes.Receipt{
+		Status:      status,
+		TxHash:      common.BytesToHash([]byte{byte(l1BlockNum)}),
+		BlockNumber: new(big.Int).SetUint64(l1BlockNum),
+		BlockHash:   testL1Header(l1BlockNum).Hash(),
+	}
+}
+
+// Returns the canonical L1 header at the given height (unless overridden by a `testL1Client`).
+func testL1Header(num uint64) *ethTypes.Header {
+	return &ethTypes.Header{Number: new(big.Int).SetUint64(num)}
+}
+
+// Makes batches available as is.
+type testDAProvider struct{}
+
+func (testDAProvider) Put(ctx context.Context, batch []byte) ([]byte, error) { return batch, nil }
+
+func newTestDisseminator(cfg testConfig, builder *testBuilder, txMgr *testTxMgr) *BatchDisseminator {
+	return NewBatchDisseminator(
+		cfg, builder, txMgr, eth.NewEthState(), &testL1Client{}, newTestL2Client(1), nil, nil, testDAProvider{},
+		rawdb.NewMemoryDatabase(),
+	)
+}
+
+func testPayloads(batches ...[]byte) []string {
+	var payloads []string
+	for _, b := range batches {
+		payloads = append(payloads, string(b))
+	}
+	return payloads
+}
+
+// An L2 chain of empty blocks.
+type testL2Client struct {
+	blocks  []*ethTypes.Block
+	safe    uint64
+	safeErr error
+}
+
+func newTestL2Client(n int) *testL2Client {
+	blocks := []*ethTypes.Block{ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: big.NewInt(0)})}
+	for i := 1; i < n; i++ {
+		blocks = append(blocks, ethTypes.NewBlockWithHeader(&ethTypes.Header{
+			Number:     big.NewInt(int64(i)),
+			ParentHash: blocks[i-1].Hash(),
+		}))
+	}
+	return &testL2Client{blocks: blocks}
+}
+
+func (c *testL2Client) EnsureDialed(ctx context.Context) error { return nil }
+func (c *testL2Client) BlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(len(c.blocks) - 1), nil
+}
+func (c *testL2Client) BlockByNumber(ctx context.Context, number *big.Int) (*ethTypes.Block, error) {
+	return c.blocks[number.Uint64()], nil
+}
+func (c *testL2Client) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	return c.blocks[number.Uint64()].Header(), nil
+}
+func (c *testL2Client) HeaderByTag(ctx context.Context, tag eth.BlockTag) (*ethTypes.Header, error) {
+	if c.safeErr != nil {
+		return nil, c.safeErr
+	}
+	return c.blocks[c.safe].Header(), nil
+}
+
+func TestHandleAppendError(t *testing.T) {
+	var (
+		errOther    = errors.New("other error")
+		errRollback = errors.New("rollback error")
+		reorgErr    = L2ReorgDetectedError{derivation.InvalidBlockError{Msg: "not a child"}}
+	)
+	tests := []struct {
+		name      string
+		err       error
+		safeErr   error
+		resetErr  error
+		wantErr   error // If nil, the reorg error is expected.
+		wantReset bool
+	}{
+		{name: "non-reorg error", err: errOther, wantErr: errOther},
+		{name: "reorg rolls back", err: reorgErr, wantReset: true},
+		{name: "safe header unavailable", err: reorgErr, safeErr: errRollback, wantErr: errRollback},
+		{name: "reset fails", err: reorgErr, resetErr: errRollback, wantErr: errRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				l2Client = newTestL2Client(3)
+				builder  = &testBuilder{resetErr: tt.resetErr}
+				d        = &BatchDisseminator{batchBuilder: builder, l2Client: l2Client}
+			)
+			l2Client.safe, l2Client.safeErr = 1, tt.safeErr
+			err := d.handleAppendError(tt.err)
+			if tt.wantErr == nil {
+				if !errors.As(err, &L2ReorgDetectedError{}) {
+					t.Errorf("got error %v, want reorg error", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			wantResets := 0
+			if tt.wantReset {
+				wantResets = 1
+			}
+			if len(builder.resets) != wantResets {
+				t.Fatalf("got %d resets, want %d", len(builder.resets), wantResets)
+			}
+			if tt.wantReset && builder.resets[0] != types.NewBlockIDFromHeader(l2Client.blocks[1].Header()) {
+				t.Errorf("reset to %v, want safe block %d", builder.resets[0], 1)
+			}
+		})
+	}
+}
+
+func TestAppendToBuilderRollsBackOnReorg(t *testing.T) {
+	var (
+		l2Client = newTestL2Client(4)
+		// Last enqueued block 2 was reorged out.
+		builder = &testBuilder{lastEnqueued: types.NewBlockID(2, [32]byte{0x1})}
+		d       = &BatchDisseminator{batchBuilder: builder, l2Client: l2Client}
+	)
+	l2Client.safe = 1
+	err := d.step(context.Background(), true)
+	if !errors.As(err, &L2ReorgDetectedError{}) {
+		t.Fatalf("got error %v, want reorg error", err)
+	}
+	if builder.lastEnqueued != types.NewBlockIDFromHeader(l2Client.blocks[1].Header()) {
+		t.Fatalf("builder at %v after rollback, want safe block 1", builder.lastEnqueued)
+	}
+	// Appending resumes from the safe block.
+	if err := d.appendToBuilder(context.Background()); err != nil {
+		t.Fatalf("failed to append after rollback: %v", err)
+	}
+	if builder.lastEnqueued.GetHash() != l2Client.blocks[3].Hash() {
+		t.Errorf("builder at %v, want block 3", builder.lastEnqueued)
+	}
+}
+
+func TestDisseminatePipelined(t *testing.T) {
+	var (
+		errSend = errors.New("send failed")
+		batches = [][]byte{{derivation.V0, 1}, {derivation.V0, 2}, {derivation.V0, 3}}
+	)
+	tests := []struct {
+		name   string
+		result txmgr.SendResult // of the first tx
+		// Batches advanced past once the result is handled.
+		wantAdvanced [][]byte
+		// Txs re-sent once the result is handled.
+		wantResent []string
+		wantNonces []uint64
+	}{
+		{
+			name:         "confirmed",
+			result:       txmgr.SendResult{Receipt: testReceipt(ethTypes.ReceiptStatusSuccessful, 1)},
+			wantAdvanced: batches[:1],
+		},
+		{
+			// The later txs are replaced, with the same nonces.
+			name:       "failed",
+			result:     txmgr.SendResult{Err: errSend},
+			wantResent: testPayloads(batches...),
+			wantNonces: []uint64{5, 6, 7},
+		},
+		{
+			// The reverted tx used up its nonce, so it and the later txs replace the later txs, shifted by one.
+			name:       "reverted",
+			result:     txmgr.SendResult{Receipt: testReceipt(ethTypes.ReceiptStatusFailed, 1)},
+			wantResent: testPayloads(batches...),
+			wantNonces: []uint64{6, 7, 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				builder = &testBuilder{ready: batches}
+				txMgr   = &testTxMgr{latestNonce: 5}
+				d       = newTestDisseminator(testConfig{maxInFlight: 3}, builder, txMgr)
+			)
+			if err := d.step(ctx, false); err != nil {
+				t.Fatalf("failed to step: %v", err)
+			}
+			payloads, nonces := txMgr.sentSince(0)
+			if !reflect.DeepEqual(payloads, testPayloads(batches...)) || !reflect.DeepEqual(nonces, []uint64{5, 6, 7}) {
+				t.Fatalf("sent %x with nonces %v, want all batches with nonces [5 6 7]", payloads, nonces)
+			}
+			// Batches aren't advanced past until posted.
+			if len(builder.advanced) != 0 {
+				t.Fatalf("advanced past %d batches before any was posted", len(builder.advanced))
+			}
+
+			txMgr.sent[0].resultCh <- tt.result
+			// The other txs never complete, so this must not wait for them.
+			err := d.step(ctx, false)
+			if (err != nil) != (tt.wantResent != nil) {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantResent != nil)
+			}
+			if err := d.step(ctx, false); err != nil {
+				t.Fatalf("failed to step: %v", err)
+			}
+			if !reflect.DeepEqual(builder.advanced, tt.wantAdvanced) {
+				t.Errorf("advanced past %x, want %x", builder.advanced, tt.wantAdvanced)
+			}
+			if wantPosted := len(tt.wantAdvanced); len(d.posted) != wantPosted {
+				t.Errorf("tracking %d posted txs, want %d", len(d.posted), wantPosted)
+			}
+			payloads, nonces = txMgr.sentSince(len(batches))
+			if !reflect.DeepEqual(payloads, tt.wantResent) || !reflect.DeepEqual(nonces, tt.wantNonces) {
+				t.Errorf("re-sent %x with nonces %v, want %x with nonces %v", payloads, nonces, tt.wantResent, tt.wantNonces)
+			}
+		})
+	}
+}
+
+func TestDisseminatePipelinedFrames(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		batch   = append([]byte{derivation.V0}, make([]byte, 100)...)
+		builder = &testBuilder{ready: [][]byte{batch}}
+		txMgr   = &testTxMgr{}
+		d       = newTestDisseminator(testConfig{maxInFlight: 10, maxFrameSize: 50}, builder, txMgr)
+	)
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	if len(txMgr.sent) < 2 {
+		t.Fatalf("sent %d frames, want several", len(txMgr.sent))
+	}
+	// The batch is only advanced past once its last frame is posted.
+	for i, tx := range txMgr.sent {
+		if len(builder.advanced) != 0 {
+			t.Fatalf("advanced past batch after posting %d of %d frames", i, len(txMgr.sent))
+		}
+		tx.resultCh <- txmgr.SendResult{Receipt: testReceipt(ethTypes.ReceiptStatusSuccessful, uint64(i+1))}
+		if err := d.step(ctx, false); err != nil {
+			t.Fatalf("failed to step: %v", err)
+		}
+	}
+	if len(builder.advanced) != 1 {
+		t.Errorf("advanced past %d batches, want 1", len(builder.advanced))
+	}
+}
+
+// Returns the records in a JSONL archive (none if it doesn't exist).
+func readArchive(t *testing.T, path string) []archiveRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+	var records []archiveRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var record archiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("failed to decode archive record: %v", err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	return records
+}
+
+func TestDisseminatePipelinedArchivesPosted(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		path    = filepath.Join(t.TempDir(), "batches.jsonl")
+		batches = [][]byte{{derivation.V0, 1}, {derivation.V0, 2}}
+		builder = &testBuilder{ready: batches}
+		txMgr   = &testTxMgr{}
+		d       = newTestDisseminator(testConfig{maxInFlight: 2, archivePath: path}, builder, txMgr)
+	)
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	// Previously, batches were archived as soon as they were built, even if never posted.
+	if records := readArchive(t, path); len(records) != 0 {
+		t.Fatalf("archived %d batches before any was posted", len(records))
+	}
+	txMgr.sent[0].resultCh <- txmgr.SendResult{Receipt: testReceipt(ethTypes.ReceiptStatusSuccessful, 1)}
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	records := readArchive(t, path)
+	if len(records) != 1 || string(records[0].Data) != string(batches[0]) || records[0].DryRun {
+		t.Errorf("archived %+v, want only the posted batch %x", records, batches[0])
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		path    = filepath.Join(t.TempDir(), "batches.jsonl")
+		batches = [][]byte{{derivation.V0, 1}, {derivation.V0, 2}}
+		builder = &testBuilder{ready: batches}
+		// No tx manager, so sending anything would panic.
+		d = NewBatchDisseminator(
+			testConfig{dryRun: true, archivePath: path},
+			builder, nil, eth.NewEthState(), nil, newTestL2Client(1), nil, nil, testDAProvider{}, rawdb.NewMemoryDatabase(),
+		)
+	)
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	records := readArchive(t, path)
+	if len(records) != len(batches) {
+		t.Fatalf("archived %d batches, want %d", len(records), len(batches))
+	}
+	for i, record := range records {
+		if string(record.Data) != string(batches[i]) || !record.DryRun {
+			t.Errorf("record %d: got %+v, want dry-run record of batch %x", i, record, batches[i])
+		}
+	}
+}
+
+func TestDisseminateBatchReverted(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		batch   = []byte{derivation.V0, 1}
+		builder = &testBuilder{ready: [][]byte{batch}}
+		txMgr   = &testTxMgr{
+			latestNonce: 5,
+			results:     []txmgr.SendResult{{Receipt: testReceipt(ethTypes.ReceiptStatusFailed, 1)}},
+		}
+		d = newTestDisseminator(testConfig{}, builder, txMgr)
+	)
+	if err := d.step(ctx, false); !errors.Is(err, errTxReverted) {
+		t.Fatalf("got error %v, want %v", err, errTxReverted)
+	}
+	if len(builder.advanced) != 0 || len(d.posted) != 0 {
+		t.Fatalf("reverted batch was counted as posted")
+	}
+	// Re-sent with the next nonce.
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	payloads, nonces := txMgr.sentSince(0)
+	if !reflect.DeepEqual(payloads, testPayloads(batch, batch)) || !reflect.DeepEqual(nonces, []uint64{5, 6}) {
+		t.Errorf("sent %x with nonces %v, want batch twice with nonces [5 6]", payloads, nonces)
+	}
+	if len(builder.advanced) != 1 || len(d.posted) != 1 {
+		t.Errorf("advanced past %d batches and tracking %d posted txs, want 1 each", len(builder.advanced), len(d.posted))
+	}
+}
+
+type testInboxScanner struct {
+	lastPosted uint64
+	found      bool
+}
+
+func (s testInboxScanner) LastPostedL2BlockNum(ctx context.Context) (uint64, bool, error) {
+	return s.lastPosted, s.found, nil
+}
+
+func TestPendingL2BlockRangeSafeAhead(t *testing.T) {
+	var (
+		l2Client = newTestL2Client(4)
+		builder  = &testBuilder{lastEnqueued: types.NewBlockIDFromHeader(l2Client.blocks[1].Header())}
+		d        = &BatchDisseminator{batchBuilder: builder, l2Client: l2Client}
+	)
+	l2Client.safe = 2
+	// Aborts the disseminator (see `start`), rather than silently skipping ahead.
+	_, _, err := d.pendingL2BlockRange(context.Background())
+	if !errors.As(err, &unexpectedSystemStateError{}) {
+		t.Fatalf("got error %v, want unexpected system state error", err)
+	}
+	if len(builder.resets) != 0 {
+		t.Errorf("builder was reset to %v", builder.resets)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastEnqueued uint64 // 0 if none
+		safe         uint64
+		inbox        testInboxScanner
+		wantReset    bool
+		wantStart    uint64 // first block to append after recovering
+	}{
+		{name: "fresh start", safe: 2, wantReset: true, wantStart: 3},
+		{name: "resumes persisted state", lastEnqueued: 3, safe: 2, wantStart: 4},
+		{name: "inbox ahead of safe head", lastEnqueued: 3, safe: 1, inbox: testInboxScanner{4, true}, wantReset: true, wantStart: 5},
+		{name: "safe head ahead of persisted state", lastEnqueued: 1, safe: 2, wantReset: true, wantStart: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				l2Client = newTestL2Client(6)
+				builder  = &testBuilder{}
+				d        = &BatchDisseminator{batchBuilder: builder, l2Client: l2Client, inboxScanner: tt.inbox}
+			)
+			if tt.lastEnqueued != 0 {
+				builder.lastEnqueued = types.NewBlockIDFromHeader(l2Client.blocks[tt.lastEnqueued].Header())
+			}
+			l2Client.safe = tt.safe
+			if err := d.recover(context.Background()); err != nil {
+				t.Fatalf("failed to recover: %v", err)
+			}
+			if (len(builder.resets) != 0) != tt.wantReset {
+				t.Errorf("got resets %v, want reset: %v", builder.resets, tt.wantReset)
+			}
+			start, end, err := d.pendingL2BlockRange(context.Background())
+			if err != nil {
+				t.Fatalf("failed to get pending block range: %v", err)
+			}
+			if start != tt.wantStart || end != 5 {
+				t.Errorf("got pending range [%d, %d], want [%d, 5]", start, end, tt.wantStart)
+			}
+		})
+	}
+}
+
+// An L1 chain of the given headers, which includes no txs.
+// An L1 chain of canonical test headers (see `testL1Header`), except where overridden by `headers`.
+type testL1Client struct {
+	headers map[uint64]*ethTypes.Header
+}
+
+func (c *testL1Client) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	if header, ok := c.headers[number.Uint64()]; ok {
+		return header, nil
+	}
+	return testL1Header(number.Uint64()), nil
+}
+func (c *testL1Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethTypes.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+func TestCheckPostedReorg(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		batches  = [][]byte{{derivation.V0, 1}, {derivation.V0, 2}, {derivation.V0, 3}}
+		l1Client = &testL1Client{headers: make(map[uint64]*ethTypes.Header)}
+		builder  = &testBuilder{ready: batches[2:]}
+		txMgr    = &testTxMgr{latestNonce: 7}
+		d        = newTestDisseminator(testConfig{maxInFlight: 3}, builder, txMgr)
+	)
+	d.l1Client = l1Client
+	// The first two batches were posted with nonces 5 and 6.
+	for i, batch := range batches[:2] {
+		d.posted = append(d.posted, &postedTx{
+			payload: batch,
+			txHash:  common.Hash{byte(i)},
+			nonce:   uint64(5 + i),
+			l1Block: types.NewBlockIDFromHeader(testL1Header(uint64(i + 1))),
+		})
+	}
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	if len(d.posted) != 2 || len(d.inFlight) != 1 || txMgr.sent[0].nonce != 7 {
+		t.Fatalf("got %d posted and %d in-flight txs, want 2 and 1 (with nonce 7)", len(d.posted), len(d.inFlight))
+	}
+
+	// The second batch is reorged out; it and the in-flight tx are re-sent as replacements, with the same nonces.
+	l1Client.headers[2] = &ethTypes.Header{Number: big.NewInt(2), Extra: []byte("reorg")}
+	if err := d.step(ctx, false); err != nil {
+		t.Fatalf("failed to step: %v", err)
+	}
+	payloads, nonces := txMgr.sentSince(1)
+	if !reflect.DeepEqual(payloads, testPayloads(batches[1:]...)) || !reflect.DeepEqual(nonces, []uint64{6, 7}) {
+		t.Errorf("re-sent %x with nonces %v, want batches 2 and 3 with nonces [6 7]", payloads, nonces)
+	}
+	if len(d.posted) != 1 {
+		t.Errorf("tracking %d posted txs, want 1", len(d.posted))
+	}
+	// Only the third batch is still held by the builder.
+	for i, tx := range d.inFlight {
+		if want := i == 1; tx.completesBatch != want {
+			t.Errorf("in-flight tx %d completes batch: %v, want %v", i, tx.completesBatch, want)
+		}
+	}
+}
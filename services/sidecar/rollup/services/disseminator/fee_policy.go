@@ -0,0 +1,144 @@
+package disseminator
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Period over which the fee budget applies.
+const feeBudgetPeriod = 24 * time.Hour
+
+// Spends are keyed by prefix || time (unix nanoseconds, uint64 big endian) || tx hash, so they're iterated in order.
+var feeSpendKeyPrefix = []byte("DisseminatorFeeSpend")
+
+// Returned when a batch is held back due to L1 fees.
+var errFeeThrottled = errors.New("batch held back due to L1 fees")
+
+// Decides whether to post a batch given current L1 fees and what's been spent recently.
+// Non-urgent batches are held while the L1 basefee exceeds a threshold, or while the fee budget
+// for the current day is spent. Batches are urgent once their deadline is within the urgency margin.
+// Spending is persisted, so that the budget holds across restarts.
+type feePolicy struct {
+	cfg    Config
+	db     ethdb.KeyValueStore
+	spends []feeSpend // Fees paid for confirmed batch txs over the last budget period, oldest first.
+}
+
+type feeSpend struct {
+	key  []byte
+	time time.Time
+	fee  *big.Int
+}
+
+// Creates a fee policy, restoring the spends persisted in `db`.
+func newFeePolicy(cfg Config, db ethdb.KeyValueStore) *feePolicy {
+	p := &feePolicy{cfg: cfg, db: db}
+	// Failing to restore spends only results in the budget being reset.
+	if err := p.load(); err != nil {
+		log.Error("Failed to load L1 fee spends", "err", err)
+	}
+	return p
+}
+
+// Returns true if the policy may hold batches back at all.
+func (p *feePolicy) enabled() bool {
+	return p.cfg.GetMaxL1BaseFeeGwei() != 0 || p.cfg.GetDailyFeeBudgetGwei() != 0
+}
+
+// Returns true if a batch due by L1 block `deadline` must be posted at `l1Head`, regardless of fees.
+// A batch without a known deadline (`hasDeadline` unset) isn't urgent.
+func (p *feePolicy) isUrgent(l1Head, deadline uint64, hasDeadline bool) bool {
+	return hasDeadline && l1Head+p.cfg.GetUrgencyMargin() >= deadline
+}
+
+// Returns `errFeeThrottled` if a non-urgent batch should be held back at the given L1 basefee.
+func (p *feePolicy) check(basefee *big.Int) error {
+	if maxBaseFee := gweiToWei(p.cfg.GetMaxL1BaseFeeGwei()); maxBaseFee.Sign() != 0 && basefee.Cmp(maxBaseFee) > 0 {
+		log.Info("L1 basefee exceeds threshold, holding batch", "basefee", basefee, "max", maxBaseFee)
+		return errFeeThrottled
+	}
+	if budget := gweiToWei(p.cfg.GetDailyFeeBudgetGwei()); budget.Sign() != 0 {
+		if spent := p.spent(); spent.Cmp(budget) >= 0 {
+			log.Info("Daily L1 fee budget spent, holding batch", "spent", spent, "budget", budget)
+			return errFeeThrottled
+		}
+	}
+	return nil
+}
+
+// Records the fee paid for a mined batch tx.
+func (p *feePolicy) record(receipt *ethTypes.Receipt) {
+	fee := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	if receipt.BlobGasPrice != nil {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+	}
+	p.add(time.Now(), receipt.TxHash, fee)
+}
+
+// Adds a spend, persisting it.
+func (p *feePolicy) add(t time.Time, txHash common.Hash, fee *big.Int) {
+	spend := feeSpend{key: feeSpendKey(t, txHash), time: t, fee: fee}
+	p.spends = append(p.spends, spend)
+	// Failing to persist this only results in the spend not counting towards the budget after a restart.
+	if err := p.db.Put(spend.key, fee.Bytes()); err != nil {
+		log.Error("Failed to persist L1 fee spend", "err", err)
+	}
+}
+
+// Returns the total fees paid over the last budget period, pruning older spends.
+func (p *feePolicy) spent() *big.Int {
+	cutoff := time.Now().Add(-feeBudgetPeriod)
+	for len(p.spends) > 0 && p.spends[0].time.Before(cutoff) {
+		if err := p.db.Delete(p.spends[0].key); err != nil {
+			log.Error("Failed to delete persisted L1 fee spend", "err", err)
+		}
+		p.spends = p.spends[1:]
+	}
+	total := new(big.Int)
+	for _, s := range p.spends {
+		total.Add(total, s.fee)
+	}
+	return total
+}
+
+// Loads the persisted spends (pruned on the next call to `spent`).
+func (p *feePolicy) load() error {
+	it := p.db.NewIterator(feeSpendKeyPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		key := common.CopyBytes(it.Key())
+		if len(key) != len(feeSpendKeyPrefix)+8+common.HashLength {
+			return fmt.Errorf("invalid fee spend key: %x", key)
+		}
+		nanos := binary.BigEndian.Uint64(key[len(feeSpendKeyPrefix):])
+		p.spends = append(p.spends, feeSpend{
+			key:  key,
+			time: time.Unix(0, int64(nanos)),
+			fee:  new(big.Int).SetBytes(it.Value()),
+		})
+	}
+	return it.Error()
+}
+
+func feeSpendKey(t time.Time, txHash common.Hash) []byte {
+	key := make([]byte, len(feeSpendKeyPrefix)+8, len(feeSpendKeyPrefix)+8+common.HashLength)
+	copy(key, feeSpendKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(feeSpendKeyPrefix):], uint64(t.UnixNano()))
+	return append(key, txHash.Bytes()...)
+}
+
+func gweiToWei(gwei uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gwei), big.NewInt(params.GWei))
+}
@@ -0,0 +1,59 @@
+package disseminator
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+type testFeeConfig struct {
+	testConfig
+	urgencyMargin uint64
+}
+
+func (c testFeeConfig) GetUrgencyMargin() uint64 { return c.urgencyMargin }
+
+func TestFeePolicyIsUrgent(t *testing.T) {
+	p := newFeePolicy(testFeeConfig{urgencyMargin: 5}, rawdb.NewMemoryDatabase())
+	tests := []struct {
+		name        string
+		l1Head      uint64
+		deadline    uint64
+		hasDeadline bool
+		want        bool
+	}{
+		// Previously, a batch with an unknown deadline was always posted regardless of fees.
+		{"unknown deadline", 100, 0, false, false},
+		{"outside margin", 100, 106, true, false},
+		{"within margin", 101, 106, true, true},
+		{"past deadline", 110, 106, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.isUrgent(tt.l1Head, tt.deadline, tt.hasDeadline); got != tt.want {
+				t.Errorf("got urgent %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeePolicyPersistsSpends(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	p := newFeePolicy(testConfig{}, db)
+	p.record(&ethTypes.Receipt{TxHash: common.Hash{1}, GasUsed: 21_000, EffectiveGasPrice: big.NewInt(10)})
+	p.add(time.Now().Add(-feeBudgetPeriod-time.Minute), common.Hash{2}, big.NewInt(1_000_000))
+
+	// Spends must survive a restart, and ones older than the budget period must be pruned.
+	p = newFeePolicy(testConfig{}, db)
+	if got, want := p.spent(), big.NewInt(210_000); got.Cmp(want) != 0 {
+		t.Fatalf("got spent %s, want %s", got, want)
+	}
+	p = newFeePolicy(testConfig{}, db)
+	if len(p.spends) != 1 {
+		t.Errorf("got %d persisted spends after pruning, want 1", len(p.spends))
+	}
+}
@@ -5,13 +5,30 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
 	"github.com/specularL2/specular/services/sidecar/rollup/types"
 )
 
-type Config interface{ GetDisseminationInterval() time.Duration }
+type Config interface {
+	GetDisseminationInterval() time.Duration
+	GetDAMode() string
+	GetMaxFrameSize() uint64
+	// Max # of batch txs in flight at once. At most 1 disables pipelining (i.e. each tx is awaited).
+	GetMaxInFlight() uint64
+	GetMaxL1BaseFeeGwei() uint64
+	GetDailyFeeBudgetGwei() uint64
+	GetUrgencyMargin() uint64
+	// If set, batches are built but not sent (see `GetArchivePath`).
+	GetDryRun() bool
+	// Path of a JSONL file or directory in which to archive built batches (disabled if empty).
+	GetArchivePath() string
+}
 
 type ForkChoiceState = engine.ForkchoiceStateV1
 type ForkChoiceResponse = engine.ForkChoiceResponse
@@ -20,18 +37,53 @@ type BuildPayloadResponse = engine.ForkChoiceResponse
 type BatchBuilder interface {
 	Enqueue(block *ethTypes.Block) error
 	LastEnqueued() types.BlockID
+	FirstPending() uint64
+	Deadline() (uint64, bool)
+	Timeout() uint64
+	Flush()
 	Build(l1Head types.BlockID) ([]byte, error)
+	BuildAhead(l1Head types.BlockID, n int) ([]byte, error)
 	Advance()
-	Reset(lastEnqueued types.BlockID)
+	Reset(lastEnqueued types.BlockID) error
 }
 
 type TxManager interface {
-	AppendTxBatch(ctx context.Context, batch []byte) (*ethTypes.Receipt, error)
+	AppendTxBatch(ctx context.Context, batch []byte, nonce uint64) (*ethTypes.Receipt, error)
+	AppendBlobTxBatch(ctx context.Context, header []byte, blobs []kzg4844.Blob, nonce uint64) (*ethTypes.Receipt, error)
+	AppendTxBatchAsync(ctx context.Context, batch []byte, nonce uint64, resultCh chan<- txmgr.SendResult) error
+	AppendBlobTxBatchAsync(
+		ctx context.Context,
+		header []byte,
+		blobs []kzg4844.Blob,
+		nonce uint64,
+		resultCh chan<- txmgr.SendResult,
+	) error
+	SuggestGasPriceCaps(ctx context.Context) (*big.Int, *big.Int, *big.Int, error)
+	LatestNonce(ctx context.Context) (uint64, error)
+}
+
+type InboxScanner interface {
+	LastPostedL2BlockNum(ctx context.Context) (uint64, bool, error)
+}
+
+type DAProvider interface {
+	Put(ctx context.Context, batch []byte) ([]byte, error)
+}
+
+type L1Client interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethTypes.Receipt, error)
+}
+
+type L2HeadSubscriber interface {
+	EnsureDialed(ctx context.Context) error
+	SubscribeNewHead(ctx context.Context, ch chan<- *ethTypes.Header) (ethereum.Subscription, error)
 }
 
 type L2Client interface {
 	EnsureDialed(ctx context.Context) error
 	BlockNumber(ctx context.Context) (uint64, error)
 	BlockByNumber(ctx context.Context, number *big.Int) (*ethTypes.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
 	HeaderByTag(ctx context.Context, tag eth.BlockTag) (*ethTypes.Header, error)
 }
@@ -0,0 +1,58 @@
+package disseminator
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Max # of new L2 heads buffered while the disseminator is busy (e.g. awaiting a batch tx).
+const newHeadBufferSize = 256
+
+// Subscribes to new L2 heads, so that blocks are appended to the batch builder as soon as they're produced.
+// Returns nil if no subscriber is configured or subscribing fails, in which case blocks are polled for.
+func (d *BatchDisseminator) subscribeNewHeads(ctx context.Context, headCh chan<- *ethTypes.Header) ethereum.Subscription {
+	if d.l2HeadSub == nil {
+		return nil
+	}
+	if err := d.l2HeadSub.EnsureDialed(ctx); err != nil {
+		log.Warn("Failed to dial L2 websocket endpoint, polling for blocks", "err", err)
+		return nil
+	}
+	sub, err := d.l2HeadSub.SubscribeNewHead(ctx, headCh)
+	if err != nil {
+		log.Warn("Failed to subscribe to new L2 heads, polling for blocks", "err", err)
+		return nil
+	}
+	log.Info("Subscribed to new L2 heads")
+	return sub
+}
+
+// Appends the blocks up to a new L2 head to the batch builder,
+// including any produced since the last enqueued block that weren't pushed (e.g. while resubscribing).
+func (d *BatchDisseminator) onNewHead(ctx context.Context, head *ethTypes.Header) error {
+	lastEnqueued := d.batchBuilder.LastEnqueued()
+	// Not yet initialized; left to polling, which determines where to start from.
+	if lastEnqueued == types.EmptyBlockID {
+		return nil
+	}
+	// Already appended (e.g. by polling).
+	if head.Number.Uint64() <= lastEnqueued.GetNumber() {
+		return nil
+	}
+	if err := d.appendRange(ctx, lastEnqueued.GetNumber()+1, head.Number.Uint64()); err != nil {
+		return d.handleAppendError(err)
+	}
+	return nil
+}
+
+// Returns the error channel of the subscription (nil if there's none, which blocks forever).
+func subErr(sub ethereum.Subscription) <-chan error {
+	if sub == nil {
+		return nil
+	}
+	return sub.Err()
+}
@@ -0,0 +1,150 @@
+package disseminator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+type testSubscription struct {
+	errCh        chan error
+	unsubscribed atomic.Bool
+}
+
+func (s *testSubscription) Err() <-chan error { return s.errCh }
+func (s *testSubscription) Unsubscribe()      { s.unsubscribed.Store(true) }
+
+// Hands each subscription to the test (blocking until received), after failing the first `failures` attempts.
+type testHeadSubscriber struct {
+	failures int
+	subs     chan *testSubscription
+}
+
+func (s *testHeadSubscriber) EnsureDialed(ctx context.Context) error { return nil }
+func (s *testHeadSubscriber) SubscribeNewHead(
+	ctx context.Context,
+	ch chan<- *ethTypes.Header,
+) (ethereum.Subscription, error) {
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("subscription failed")
+	}
+	sub := &testSubscription{errCh: make(chan error, 1)}
+	select {
+	case s.subs <- sub:
+	case <-ctx.Done():
+	}
+	return sub, nil
+}
+
+// Signals each poll for new blocks (blocking until received).
+type testPolledL2Client struct {
+	*testL2Client
+	polls chan struct{}
+}
+
+func (c *testPolledL2Client) BlockNumber(ctx context.Context) (uint64, error) {
+	select {
+	case c.polls <- struct{}{}:
+	case <-ctx.Done():
+	}
+	return c.testL2Client.BlockNumber(ctx)
+}
+
+func TestL2HeadSubscriptionFallback(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		headSub     = &testHeadSubscriber{failures: 1, subs: make(chan *testSubscription)}
+		l2Client    = &testPolledL2Client{newTestL2Client(3), make(chan struct{})}
+		d           = NewBatchDisseminator(
+			testConfig{}, &testBuilder{}, &testTxMgr{}, eth.NewEthState(), &testL1Client{}, l2Client, headSub,
+			testInboxScanner{}, testDAProvider{}, rawdb.NewMemoryDatabase(),
+		)
+		done = make(chan error)
+	)
+	defer cancel()
+	go func() { done <- d.start(ctx) }()
+
+	expectSub := func() *testSubscription {
+		t.Helper()
+		select {
+		case sub := <-headSub.subs:
+			return sub
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for subscription")
+			return nil
+		}
+	}
+	expectPoll := func() {
+		t.Helper()
+		select {
+		case <-l2Client.polls:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for poll")
+		}
+	}
+	// Polls while subscribing fails, and subscribes on a later step.
+	expectPoll()
+	sub := expectSub()
+	expectPoll()
+	// Not polled for while blocks are pushed.
+	select {
+	case <-l2Client.polls:
+		t.Fatalf("polled while subscribed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// Falls back to polling once the subscription drops, and resubscribes.
+	sub.errCh <- errors.New("subscription dropped")
+	resub := expectSub()
+	expectPoll()
+	if !sub.unsubscribed.Load() {
+		t.Errorf("dropped subscription not unsubscribed")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resub.unsubscribed.Load() {
+		t.Errorf("subscription not unsubscribed on exit")
+	}
+}
+
+func TestOnNewHead(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		l2Client = newTestL2Client(6)
+		builder  = &testBuilder{}
+		d        = &BatchDisseminator{batchBuilder: builder, l2Client: l2Client}
+	)
+	// Left to polling until the builder is initialized.
+	if err := d.onNewHead(ctx, l2Client.blocks[1].Header()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(builder.enqueued) != 0 {
+		t.Fatalf("enqueued %v before initialization", builder.enqueued)
+	}
+	builder.lastEnqueued = types.NewBlockIDFromHeader(l2Client.blocks[1].Header())
+	// Includes blocks missed since the last enqueued one; repeated and stale heads are ignored.
+	for _, num := range []uint64{3, 3, 2, 4} {
+		if err := d.onNewHead(ctx, l2Client.blocks[num].Header()); err != nil {
+			t.Fatalf("head %d: unexpected error: %v", num, err)
+		}
+	}
+	// Polling only appends blocks not yet pushed.
+	if err := d.appendToBuilder(ctx); err != nil {
+		t.Fatalf("failed to append: %v", err)
+	}
+	if want := []uint64{2, 3, 4, 5}; !reflect.DeepEqual(builder.enqueued, want) {
+		t.Errorf("enqueued %v, want %v", builder.enqueued, want)
+	}
+}
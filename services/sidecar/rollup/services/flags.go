@@ -2,7 +2,11 @@ package services
 
 import (
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth/txmgr"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/disseminator"
+	"github.com/specularL2/specular/services/sidecar/rollup/services/validator"
 	"github.com/urfave/cli/v2"
 )
 
@@ -15,6 +19,7 @@ func CLIFlags() []cli.Flag {
 		txmgr.CLIFlags(disseminatorTxMgrNamespace),
 		validatorCLIFlags,
 		txmgr.CLIFlags(validatorTxMgrNamespace),
+		adminCLIFlags,
 	)
 }
 
@@ -48,12 +53,20 @@ var (
 		Usage:    "The L1 API endpoint",
 		Required: true,
 	}
+	l1BeaconEndpointFlag = &cli.StringFlag{
+		Name:  "l1.beacon-endpoint",
+		Usage: "The L1 beacon API endpoint, used to retrieve batches posted in blobs",
+	}
 	// L2 config flags
 	l2EndpointFlag = &cli.StringFlag{
 		Name:     "l2.endpoint",
 		Usage:    "The L2 API endpoint",
 		Required: true,
 	}
+	l2WSEndpointFlag = &cli.StringFlag{
+		Name:  "l2.ws-endpoint",
+		Usage: "The L2 websocket endpoint, used to subscribe to new heads (polls the L2 API endpoint if unset)",
+	}
 	// Chain config protocol flags.
 	protocolRollupCfgPathFlag = &cli.StringFlag{
 		Name:     "protocol.rollup-cfg-path",
@@ -96,6 +109,82 @@ var (
 		Name:  "disseminator.target-batch-size",
 		Usage: "The target size of a batch tx submitted to L1 (bytes)",
 	}
+	disseminatorTargetBatchGasFlag = &cli.Uint64Flag{
+		Name:  "disseminator.target-batch-gas",
+		Usage: "The target L1 gas of a batch tx, estimated from its calldata (overrides the target batch size if set)",
+	}
+	disseminatorMaxBatchAgeFlag = &cli.UintFlag{
+		Name:  "disseminator.max-batch-age",
+		Usage: "Max age of the oldest L2 block in a batch before it's flushed to L1 regardless of size (seconds). 0 disables",
+	}
+	disseminatorMaxBatchBlocksFlag = &cli.Uint64Flag{
+		Name:  "disseminator.max-batch-blocks",
+		Usage: "Max number of L2 blocks in a batch before it's flushed to L1 regardless of size. 0 disables",
+	}
+	disseminatorDAModeFlag = &cli.StringFlag{
+		Name:  "disseminator.da-mode",
+		Usage: "How batches are posted to L1: calldata, blobs (EIP-4844) or auto (whichever is cheaper)",
+		Value: disseminator.CalldataDAMode,
+	}
+	disseminatorMaxFrameSizeFlag = &cli.Uint64Flag{
+		Name:  "disseminator.max-frame-size",
+		Usage: "The max size of a frame, i.e. a chunk of a batch sent in a single L1 tx (bytes). 0 disables framing",
+	}
+	disseminatorBatchVersionFlag = &cli.UintFlag{
+		Name:  "disseminator.batch-version",
+		Usage: "The max batch format version to encode with (the latest version active on L1, up to this, is used)",
+	}
+	disseminatorMaxInFlightFlag = &cli.Uint64Flag{
+		Name:  "disseminator.max-in-flight",
+		Usage: "Max number of batch txs in flight at once, sent with sequential nonces (1 disables pipelining)",
+		Value: 1,
+	}
+	disseminatorDataDirFlag = &cli.StringFlag{
+		Name:  "disseminator.datadir",
+		Usage: "Directory in which batch builder state is persisted across restarts (in-memory only if empty)",
+	}
+	disseminatorDBEngineFlag = &cli.StringFlag{
+		Name:  "disseminator.db-engine",
+		Usage: "Database engine for persisted state: leveldb or pebble (defaults to the existing database's engine)",
+	}
+	disseminatorMaxL1BaseFeeFlag = &cli.Uint64Flag{
+		Name:  "disseminator.max-l1-basefee-gwei",
+		Usage: "Max L1 basefee (gwei) at which non-urgent batches are posted (0 disables)",
+	}
+	disseminatorDailyFeeBudgetFlag = &cli.Uint64Flag{
+		Name:  "disseminator.daily-fee-budget-gwei",
+		Usage: "Max total L1 fees (gwei) spent per day before non-urgent batches are held (0 disables)",
+	}
+	disseminatorUrgencyMarginFlag = &cli.Uint64Flag{
+		Name:  "disseminator.urgency-margin",
+		Usage: "Number of L1 blocks before a batch's deadline (per the sub-safety margin) at which it's posted regardless of L1 fees",
+	}
+	disseminatorDAProviderFlag = &cli.StringFlag{
+		Name:  "disseminator.da-provider",
+		Usage: "Where batches are made available: inbox (in full, in the sequencer inbox) or offchain (on a DA server, posting only commitments)",
+		Value: derivation.InboxDAProvider,
+	}
+	disseminatorDAServerFlag = &cli.StringFlag{
+		Name:  "disseminator.da-server",
+		Usage: "The off-chain DA server endpoint: an HTTP(S) URL, or a local directory (as a stand-in)",
+	}
+	disseminatorDryRunFlag = &cli.BoolFlag{
+		Name:  "disseminator.dry-run",
+		Usage: "Build batches without sending them to L1 (archiving them, if an archive path is set)",
+	}
+	disseminatorArchivePathFlag = &cli.StringFlag{
+		Name:  "disseminator.archive-path",
+		Usage: "Path of a JSONL file (*.jsonl) or directory in which to archive built batches, with their sizes and estimated L1 gas",
+	}
+	// Admin config flags
+	adminRPCAddrFlag = &cli.StringFlag{
+		Name:  "admin.rpc-addr",
+		Usage: "Address (host:port) on which to serve the admin JSON-RPC API (disabled if empty)",
+	}
+	adminJWTSecretFlag = &cli.StringFlag{
+		Name:  "admin.jwt-secret",
+		Usage: "Path to a hex-encoded 32-byte JWT secret used to authenticate admin RPC requests (required for non-loopback addresses)",
+	}
 	// Validator config flags
 	validatorEnableFlag = &cli.BoolFlag{
 		Name:  "validator",
@@ -118,10 +207,42 @@ var (
 		Usage: "Time between batch validation steps (seconds)",
 		Value: 10,
 	}
+	validatorModeFlag = &cli.StringFlag{
+		Name:  "validator.mode",
+		Usage: "Validator mode: watch (check and alert only, no staking), defensive (stake and challenge) or active",
+		Value: validator.ActiveMode,
+	}
+	validatorAssertionHeadFlag = &cli.StringFlag{
+		Name:  "validator.assertion-head",
+		Usage: "L2 head to assert: safe or finalized",
+		Value: string(eth.Safe),
+	}
+	validatorMinAssertionL2BlocksFlag = &cli.Uint64Flag{
+		Name:  "validator.min-assertion-l2-blocks",
+		Usage: "Min number of new L2 blocks per assertion (unless the last assertion is stale)",
+		Value: 1,
+	}
+	validatorMinAssertionL1IntervalFlag = &cli.Uint64Flag{
+		Name:  "validator.min-assertion-l1-interval",
+		Usage: "Min number of L1 blocks between assertions (at least the on-chain minimumAssertionPeriod)",
+	}
+	validatorMaxAssertionStalenessFlag = &cli.Uint64Flag{
+		Name:  "validator.max-assertion-staleness",
+		Usage: "Number of L1 blocks after the last assertion at which any new L2 blocks are asserted (0 disables)",
+	}
+	validatorMaxAssertionL1BaseFeeFlag = &cli.Uint64Flag{
+		Name:  "validator.max-assertion-l1-basefee-gwei",
+		Usage: "Max L1 basefee (gwei) at which non-stale assertions are created (0 disables)",
+	}
+	validatorCheckDerivationFlag = &cli.BoolFlag{
+		Name:  "validator.check-derivation",
+		Usage: "Whether to re-derive L2 blocks from the sequencer inbox and check the local L2 chain against them (required to challenge)",
+		Value: true,
+	}
 )
 
 var (
-	generalFlags  = []cli.Flag{VerbosityFlag, l1EndpointFlag, l2EndpointFlag}
+	generalFlags  = []cli.Flag{VerbosityFlag, l1EndpointFlag, l1BeaconEndpointFlag, l2EndpointFlag, l2WSEndpointFlag}
 	protocolFlags = []cli.Flag{
 		protocolRollupCfgPathFlag,
 		protocolRollupAddrFlag,
@@ -134,6 +255,22 @@ var (
 		disseminatorIntervalFlag,
 		disseminatorSubSafetyMarginFlag,
 		disseminatorTargetBatchSizeFlag,
+		disseminatorTargetBatchGasFlag,
+		disseminatorMaxBatchAgeFlag,
+		disseminatorMaxBatchBlocksFlag,
+		disseminatorDAModeFlag,
+		disseminatorMaxFrameSizeFlag,
+		disseminatorBatchVersionFlag,
+		disseminatorMaxInFlightFlag,
+		disseminatorDataDirFlag,
+		disseminatorDBEngineFlag,
+		disseminatorMaxL1BaseFeeFlag,
+		disseminatorDailyFeeBudgetFlag,
+		disseminatorUrgencyMarginFlag,
+		disseminatorDAProviderFlag,
+		disseminatorDAServerFlag,
+		disseminatorDryRunFlag,
+		disseminatorArchivePathFlag,
 	}
 	validatorCLIFlags = []cli.Flag{
 		validatorEnableFlag,
@@ -141,5 +278,13 @@ var (
 		validatorPrivateKeyFlag,
 		validatorClefEndpointFlag,
 		validatorValidationIntervalFlag,
+		validatorModeFlag,
+		validatorAssertionHeadFlag,
+		validatorMinAssertionL2BlocksFlag,
+		validatorMinAssertionL1IntervalFlag,
+		validatorMaxAssertionStalenessFlag,
+		validatorMaxAssertionL1BaseFeeFlag,
+		validatorCheckDerivationFlag,
 	}
+	adminCLIFlags = []cli.Flag{adminRPCAddrFlag, adminJWTSecretFlag}
 )
@@ -80,6 +80,12 @@ type RollupConfig struct {
 	// and required to be the same network-wide to stay in consensus.
 	// L1 address that batches are sent to.
 	BatchInboxAddress common.Address `json:"batch_inbox_address"`
+	// L1 block numbers at which batch format versions activate, keyed by version (V0 is active from genesis).
+	BatchVersionActivations map[uint8]uint64 `json:"batch_version_activations,omitempty"`
+	// L2 block numbers from which VM hash versions are asserted, keyed by version (legacy is asserted from genesis).
+	VMHashVersionActivations map[uint8]uint64 `json:"vm_hash_version_activations,omitempty"`
+	// L2 address of the L2Portal contract, whose storage root VM hashes commit to (the predeploy if unset).
+	L2PortalAddress common.Address `json:"l2_portal_address,omitempty"`
 }
 
 type Genesis struct {
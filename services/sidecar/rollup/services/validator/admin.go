@@ -0,0 +1,33 @@
+package validator
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Pauses validation; no assertions are created or resolved until resumed.
+func (v *Validator) Pause() {
+	log.Info("Pausing validator")
+	v.paused.Store(true)
+}
+
+func (v *Validator) Resume() {
+	log.Info("Resuming validator")
+	v.paused.Store(false)
+}
+
+func (v *Validator) IsPaused() bool { return v.paused.Load() }
+
+// Returns the L2 block number and VM hash of the last assertion created by this validator
+// (or, before any, of the assertion it's staked on).
+func (v *Validator) LastCreatedAssertion() (uint64, common.Hash) {
+	v.attrsLock.RLock()
+	defer v.attrsLock.RUnlock()
+	return v.lastCreatedAssertionAttrs.l2BlockNum, v.lastCreatedAssertionAttrs.l2VMHash
+}
+
+func (v *Validator) setLastCreatedAssertionAttrs(attrs assertionAttributes) {
+	v.attrsLock.Lock()
+	defer v.attrsLock.Unlock()
+	v.lastCreatedAssertionAttrs = attrs
+}
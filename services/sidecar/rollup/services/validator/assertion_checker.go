@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Max # of L1 blocks to query assertion events for at once.
+const maxAssertionQueryRange = 1000
+
+// An assertion created on L1, not yet checked against the local L2 chain.
+type uncheckedAssertion struct {
+	id       *big.Int
+	asserter common.Address
+}
+
+// Checks created assertions (see `onRollupEvent` and `scanCreatedAssertions`) against the local L2 chain,
+// up to its safe head.
+// Assertions whose VM hash doesn't match the locally-computed one are recorded as invalid,
+// which prevents this validator from confirming any assertion while they remain unresolved.
+func (v *Validator) checkAssertions(ctx context.Context) error {
+	// Without a rollup event subscriber, created assertions aren't published to us.
+	if v.rollupEventSub == nil {
+		if err := v.scanCreatedAssertions(ctx); err != nil {
+			return fmt.Errorf("failed to scan created assertions: %w", err)
+		}
+	}
+	if len(v.uncheckedAssertions) == 0 {
+		return nil
+	}
+	safe, err := v.l2Client.HeaderByTag(ctx, eth.Safe)
+	if err != nil {
+		return fmt.Errorf("failed to get L2 safe header: %w", err)
+	}
+	for len(v.uncheckedAssertions) > 0 {
+		unchecked := v.uncheckedAssertions[0]
+		assertion, err := v.l1BridgeClient.GetAssertion(ctx, unchecked.id)
+		if err != nil {
+			return fmt.Errorf("failed to get assertion (id=%s): %w", unchecked.id, err)
+		}
+		// Rejected assertions are deleted.
+		if assertion.ProposalTime.Sign() == 0 {
+			log.Info("Skipping check of resolved assertion", "id", unchecked.id)
+			v.uncheckedAssertions = v.uncheckedAssertions[1:]
+			continue
+		}
+		// Wait for the local L2 chain to catch up.
+		if assertion.BlockNum.Cmp(safe.Number) > 0 {
+			log.Trace("Local L2 chain behind assertion", "id", unchecked.id, "l2Block#", assertion.BlockNum, "safe", safe.Number)
+			return nil
+		}
+		header, err := v.l2Client.HeaderByNumber(ctx, assertion.BlockNum)
+		if err != nil {
+			return fmt.Errorf("failed to get L2 header (num=%s): %w", assertion.BlockNum, err)
+		}
+		localVMHash, err := v.computeVMHash(ctx, header)
+		if err != nil {
+			return fmt.Errorf("failed to compute local VM hash: %w", err)
+		}
+		vmHash := common.BytesToHash(assertion.StateHash[:])
+		if vmHash != localVMHash {
+			log.Error(
+				"Invalid assertion detected",
+				"id", unchecked.id, "asserter", unchecked.asserter, "l2Block#", assertion.BlockNum,
+				"vm_hash", vmHash, "local_vm_hash", localVMHash,
+			)
+			v.invalidAssertions[unchecked.id.Uint64()] = unchecked.asserter
+		} else {
+			log.Info("Checked assertion", "id", unchecked.id, "asserter", unchecked.asserter, "l2Block#", assertion.BlockNum)
+		}
+		v.uncheckedAssertions = v.uncheckedAssertions[1:]
+	}
+	return nil
+}
+
+// Queues assertions created in L1 blocks after the last scanned one, up to the L1 head, for checking.
+func (v *Validator) scanCreatedAssertions(ctx context.Context) error {
+	l1Head := v.l1State.Head().GetNumber()
+	for v.nextL1BlockToScan <= l1Head {
+		end := v.nextL1BlockToScan + maxAssertionQueryRange - 1
+		if end > l1Head {
+			end = l1Head
+		}
+		events, err := v.l1BridgeClient.GetAssertionCreatedEvents(ctx, v.nextL1BlockToScan, end)
+		if err != nil {
+			return fmt.Errorf("failed to get events (from=%d, to=%d): %w", v.nextL1BlockToScan, end, err)
+		}
+		for _, event := range events {
+			log.Info("Found created assertion", "id", event.AssertionID, "asserter", event.AsserterAddr)
+			v.uncheckedAssertions = append(v.uncheckedAssertions, uncheckedAssertion{event.AssertionID, event.AsserterAddr})
+		}
+		v.nextL1BlockToScan = end + 1
+	}
+	return nil
+}
+
+// Returns true if an assertion recorded as invalid may still be confirmed (i.e. it's unresolved).
+// Stops tracking those that were resolved.
+func (v *Validator) hasUnresolvedInvalidAssertion(ctx context.Context) (bool, error) {
+	if len(v.invalidAssertions) == 0 {
+		return false, nil
+	}
+	lastConfirmed, err := v.l1BridgeClient.GetLastConfirmedAssertionID(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get last confirmed assertion ID: %w", err)
+	}
+	for id, asserter := range v.invalidAssertions {
+		if id <= lastConfirmed.Uint64() {
+			log.Error("Invalid assertion was confirmed", "id", id, "asserter", asserter)
+			delete(v.invalidAssertions, id)
+			continue
+		}
+		assertion, err := v.l1BridgeClient.GetAssertion(ctx, new(big.Int).SetUint64(id))
+		if err != nil {
+			return false, fmt.Errorf("failed to get assertion (id=%d): %w", id, err)
+		}
+		// Rejected assertions are deleted.
+		if assertion.ProposalTime.Sign() == 0 {
+			log.Info("Invalid assertion was rejected", "id", id, "asserter", asserter)
+			delete(v.invalidAssertions, id)
+		}
+	}
+	return len(v.invalidAssertions) > 0, nil
+}
@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+)
+
+// Decides when to create an assertion, so as to avoid assertions that revert (too soon after
+// their parent, given `minimumAssertionPeriod`) or that cover little L2 work.
+// Once the last assertion is stale, any new L2 work is asserted regardless of its size and L1 fees.
+type assertionPolicy struct {
+	cfg Config
+}
+
+// The state an assertion decision is based on.
+type assertionCandidate struct {
+	l2BlockNum       uint64   // L2 block to assert.
+	parentL2BlockNum uint64   // L2 block asserted by the parent (i.e. our staked assertion).
+	parentProposal   uint64   // L1 block at which the parent was proposed.
+	l1Head           uint64   // Current L1 head.
+	minPeriod        uint64   // On-chain `minimumAssertionPeriod`.
+	l1BaseFee        *big.Int // Current L1 basefee (nil if unknown).
+}
+
+func newAssertionPolicy(cfg Config) *assertionPolicy { return &assertionPolicy{cfg: cfg} }
+
+// Returns the L2 head to assert.
+func (p *assertionPolicy) head() eth.BlockTag {
+	if p.cfg.GetAssertionHead() == string(eth.Finalized) {
+		return eth.Finalized
+	}
+	return eth.Safe
+}
+
+// Returns true if the policy holds back assertions based on L1 fees.
+func (p *assertionPolicy) checksL1BaseFee() bool { return p.cfg.GetMaxAssertionL1BaseFeeGwei() != 0 }
+
+// Returns the reason to hold back the candidate assertion, or "" if it should be created.
+func (p *assertionPolicy) holdReason(c assertionCandidate) string {
+	if c.l2BlockNum <= c.parentL2BlockNum {
+		return "no new L2 blocks"
+	}
+	var elapsed uint64
+	if c.l1Head > c.parentProposal {
+		elapsed = c.l1Head - c.parentProposal
+	}
+	minInterval := p.cfg.GetMinAssertionL1Interval()
+	if c.minPeriod > minInterval {
+		minInterval = c.minPeriod
+	}
+	if elapsed < minInterval {
+		return "minimum L1 interval not passed"
+	}
+	if maxStaleness := p.cfg.GetMaxAssertionStaleness(); maxStaleness != 0 && elapsed >= maxStaleness {
+		return ""
+	}
+	if c.l2BlockNum-c.parentL2BlockNum < p.cfg.GetMinAssertionL2Blocks() {
+		return "too few new L2 blocks"
+	}
+	if p.checksL1BaseFee() && c.l1BaseFee != nil {
+		maxBaseFee := new(big.Int).Mul(new(big.Int).SetUint64(p.cfg.GetMaxAssertionL1BaseFeeGwei()), big.NewInt(params.GWei))
+		if c.l1BaseFee.Cmp(maxBaseFee) > 0 {
+			return "L1 basefee exceeds threshold"
+		}
+	}
+	return ""
+}
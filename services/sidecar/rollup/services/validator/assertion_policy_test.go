@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Serves the assertion policy settings. Other methods panic.
+type testPolicyConfig struct {
+	Config
+	minL2Blocks    uint64
+	minL1Interval  uint64
+	maxStaleness   uint64
+	maxBaseFeeGwei uint64
+}
+
+func (c testPolicyConfig) GetMinAssertionL2Blocks() uint64      { return c.minL2Blocks }
+func (c testPolicyConfig) GetMinAssertionL1Interval() uint64    { return c.minL1Interval }
+func (c testPolicyConfig) GetMaxAssertionStaleness() uint64     { return c.maxStaleness }
+func (c testPolicyConfig) GetMaxAssertionL1BaseFeeGwei() uint64 { return c.maxBaseFeeGwei }
+
+func TestAssertionPolicyHoldReason(t *testing.T) {
+	var (
+		cfg = testPolicyConfig{minL2Blocks: 10, minL1Interval: 5, maxStaleness: 100, maxBaseFeeGwei: 50}
+		// Passes every check under `cfg`.
+		base = assertionCandidate{
+			l2BlockNum:       110,
+			parentL2BlockNum: 100,
+			parentProposal:   1000,
+			l1Head:           1010,
+			minPeriod:        0,
+			l1BaseFee:        big.NewInt(50 * params.GWei),
+		}
+		highBaseFee = big.NewInt(50*params.GWei + 1)
+	)
+	tests := []struct {
+		name   string
+		cfg    testPolicyConfig
+		modify func(c *assertionCandidate)
+		want   string
+	}{
+		{"assert", cfg, func(c *assertionCandidate) {}, ""},
+		{"no new L2 blocks", cfg, func(c *assertionCandidate) { c.l2BlockNum = c.parentL2BlockNum }, "no new L2 blocks"},
+		{"min L1 interval not passed", cfg, func(c *assertionCandidate) { c.l1Head = 1004 }, "minimum L1 interval not passed"},
+		{"min L1 interval passed", cfg, func(c *assertionCandidate) { c.l1Head = 1005 }, ""},
+		// The on-chain period wins over a smaller configured interval, otherwise the assertion would revert.
+		{"on-chain min period wins", cfg, func(c *assertionCandidate) { c.minPeriod = 20 }, "minimum L1 interval not passed"},
+		{"on-chain min period passed", cfg, func(c *assertionCandidate) { c.minPeriod = 10 }, ""},
+		// Stale L2 work still waits for the min L1 interval.
+		{
+			"min L1 interval overrides staleness",
+			testPolicyConfig{minL1Interval: 5, maxStaleness: 1},
+			func(c *assertionCandidate) { c.l1Head = 1004 },
+			"minimum L1 interval not passed",
+		},
+		{"too few new L2 blocks", cfg, func(c *assertionCandidate) { c.l2BlockNum = 109 }, "too few new L2 blocks"},
+		{"L1 basefee exceeds threshold", cfg, func(c *assertionCandidate) { c.l1BaseFee = highBaseFee }, "L1 basefee exceeds threshold"},
+		{"unknown L1 basefee", cfg, func(c *assertionCandidate) { c.l1BaseFee = nil }, ""},
+		{
+			"L1 basefee not checked",
+			testPolicyConfig{minL2Blocks: 10, minL1Interval: 5, maxStaleness: 100},
+			func(c *assertionCandidate) { c.l1BaseFee = highBaseFee },
+			"",
+		},
+		{
+			"too few new L2 blocks before L1 basefee",
+			cfg,
+			func(c *assertionCandidate) { c.l2BlockNum, c.l1BaseFee = 109, highBaseFee },
+			"too few new L2 blocks",
+		},
+		// Once stale, any new L2 work is asserted regardless of its size and L1 fees.
+		{
+			"staleness overrides min L2 blocks and L1 basefee",
+			cfg,
+			func(c *assertionCandidate) { c.l1Head, c.l2BlockNum, c.l1BaseFee = 1100, 101, highBaseFee },
+			"",
+		},
+		{
+			"not yet stale",
+			cfg,
+			func(c *assertionCandidate) { c.l1Head, c.l2BlockNum, c.l1BaseFee = 1099, 101, highBaseFee },
+			"too few new L2 blocks",
+		},
+		{
+			"staleness disabled",
+			testPolicyConfig{minL2Blocks: 10, minL1Interval: 5, maxBaseFeeGwei: 50},
+			func(c *assertionCandidate) { c.l1Head, c.l1BaseFee = 10_000, highBaseFee },
+			"L1 basefee exceeds threshold",
+		},
+		{"stale without new L2 blocks", cfg, func(c *assertionCandidate) { c.l1Head, c.l2BlockNum = 1100, 100 }, "no new L2 blocks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base
+			tt.modify(&c)
+			if got := newAssertionPolicy(tt.cfg).holdReason(c); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Must match `MAX_BISECTION_DEGREE` in SymChallenge.sol.
+const maxBisectionDegree = 2
+
+// A bisection of the challenged segment [start, start+length] (in steps).
+type bisection struct {
+	hashes []common.Hash // State hashes at each cut; the first is agreed upon.
+	start  uint64
+	length uint64
+}
+
+// Returns the initial bisection, as set by `initializeChallengeLength`.
+func initialBisection(startStateHash, endStateHash common.Hash, numSteps uint64) bisection {
+	return bisection{[]common.Hash{startStateHash, endStateHash}, 0, numSteps}
+}
+
+// Returns the step at which each cut is made (see `ChallengeLib.firstSegmentLength`/`otherSegmentLength`).
+func cutPositions(start, length uint64, degree uint64) []uint64 {
+	var (
+		firstLen  = length/degree + length%degree
+		otherLen  = length / degree
+		positions = make([]uint64, degree+1)
+	)
+	positions[0] = start
+	for i := uint64(1); i <= degree; i++ {
+		positions[i] = start + firstLen + otherLen*(i-1)
+	}
+	return positions
+}
+
+// Returns the step at which each of the bisection's cuts is made.
+func (b bisection) positions() []uint64 {
+	return cutPositions(b.start, b.length, uint64(len(b.hashes)-1))
+}
+
+// Returns `ChallengeLib.computeBisectionHash`, i.e. H(bisection || segmentStart || segmentLength).
+func (b bisection) hash() common.Hash {
+	var data []byte
+	for _, h := range b.hashes {
+		data = append(data, h.Bytes()...)
+	}
+	data = append(data, math.U256Bytes(new(big.Int).SetUint64(b.start))...)
+	data = append(data, math.U256Bytes(new(big.Int).SetUint64(b.length))...)
+	return crypto.Keccak256Hash(data)
+}
+
+// The response to a counterparty's bisection: either a further bisection of the
+// first segment we disagree with or, once that segment is a single step, a one-step proof.
+type bisectionResponse struct {
+	index     uint64    // Index into the counterparty's bisection of the end of the challenged segment.
+	bisection bisection // Our bisection of the challenged segment (unset if it's a single step).
+}
+
+func (r bisectionResponse) isOneStep() bool { return len(r.bisection.hashes) == 0 }
+
+// Computes our response to the counterparty's bisection `prev`, given our own execution states.
+func respondToBisection(prev bisection, states []common.Hash) (bisectionResponse, error) {
+	positions := prev.positions()
+	for i := 1; i < len(prev.hashes); i++ {
+		if positions[i] >= uint64(len(states)) {
+			return bisectionResponse{}, fmt.Errorf("bisection cut %d at step %d beyond local states", i, positions[i])
+		}
+		if prev.hashes[i] == states[positions[i]] {
+			continue
+		}
+		var (
+			start  = positions[i-1]
+			length = positions[i] - positions[i-1]
+		)
+		log.Info("Found disagreement", "index", i, "start", start, "length", length)
+		if length <= 1 {
+			return bisectionResponse{index: uint64(i)}, nil
+		}
+		degree := uint64(maxBisectionDegree)
+		if length < degree {
+			degree = length
+		}
+		cuts := cutPositions(start, length, degree)
+		hashes := make([]common.Hash, len(cuts))
+		for j, pos := range cuts {
+			hashes[j] = states[pos]
+		}
+		return bisectionResponse{uint64(i), bisection{hashes, start, length}}, nil
+	}
+	return bisectionResponse{}, fmt.Errorf("no disagreement with counterparty's bisection")
+}
@@ -0,0 +1,67 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Returns `numSteps`+1 state hashes, which diverge from the honest ones at step `divergeAt` (if non-zero) on.
+func testStates(numSteps, divergeAt uint64) []common.Hash {
+	states := make([]common.Hash, numSteps+1)
+	for i := range states {
+		states[i] = common.BigToHash(common.Big1)
+		states[i][0] = byte(i)
+		if divergeAt != 0 && uint64(i) >= divergeAt {
+			states[i][1] = 0xff
+		}
+	}
+	return states
+}
+
+func TestBisectionRounds(t *testing.T) {
+	for _, numSteps := range []uint64{1, 2, 3, 5, 16, 33} {
+		for divergeAt := uint64(1); divergeAt <= numSteps; divergeAt++ {
+			var (
+				parties = [2][]common.Hash{testStates(numSteps, 0), testStates(numSteps, divergeAt)}
+				// The defender's end state is committed to first; the challenger responds.
+				prev   = initialBisection(parties[0][0], parties[0][numSteps], numSteps)
+				turn   = 1
+				rounds int
+			)
+			for {
+				if rounds++; rounds > 2*64 {
+					t.Fatalf("steps=%d, diverge=%d: bisection did not converge", numSteps, divergeAt)
+				}
+				resp, err := respondToBisection(prev, parties[turn])
+				if err != nil {
+					t.Fatalf("steps=%d, diverge=%d, round %d: failed to respond: %v", numSteps, divergeAt, rounds, err)
+				}
+				if resp.isOneStep() {
+					// The one-step proof is of the transition from the last agreed-upon state.
+					if step := prev.positions()[resp.index-1]; step != divergeAt-1 {
+						t.Errorf("steps=%d, diverge=%d: proving step %d, want %d", numSteps, divergeAt, step, divergeAt-1)
+					}
+					break
+				}
+				b := resp.bisection
+				// The responder agrees with the start of the segment it bisects, and disagrees with its end.
+				if b.hashes[0] != parties[1-turn][b.start] || b.hashes[len(b.hashes)-1] == parties[1-turn][b.start+b.length] {
+					t.Fatalf("steps=%d, diverge=%d, round %d: bisected segment [%d, %d] not the disputed one",
+						numSteps, divergeAt, rounds, b.start, b.start+b.length)
+				}
+				prev, turn = b, 1-turn
+			}
+		}
+	}
+}
+
+func TestRespondToBisectionErrors(t *testing.T) {
+	states := testStates(4, 0)
+	if _, err := respondToBisection(initialBisection(states[0], states[4], 4), states); err == nil {
+		t.Error("responded to a bisection without any disagreement")
+	}
+	if _, err := respondToBisection(initialBisection(states[0], states[4], 8), states); err == nil {
+		t.Error("responded to a bisection beyond the local states")
+	}
+}
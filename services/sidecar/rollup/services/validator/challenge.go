@@ -0,0 +1,366 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/bindings"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// State of a challenge this validator is engaged in, as defender or challenger.
+type challengeState struct {
+	addr           common.Address
+	createdL1Block uint64 // L1 block in which the challenge was created.
+	isDefender     bool
+	startVMHash    common.Hash    // VM hash of the (agreed-upon) parent assertion.
+	endVMHashes    [2]common.Hash // VM hashes of the defender's and challenger's assertions.
+	startL2Block   uint64         // First L2 block after the parent assertion.
+	endL2Block     uint64         // L2 block of our assertion.
+	states         []common.Hash  // Our execution state hashes, from the parent assertion to ours.
+	turnStart      uint64         // L1 block by which the counterparty's turn started (0 if unknown).
+}
+
+// Challenges the earliest invalid assertion competing with (i.e. a sibling of) the one this validator is staked on.
+// If the invalid assertion is instead a child of ours, first creates a competing assertion.
+func (v *Validator) challengeInvalidAssertion(ctx context.Context) error {
+	if v.challenge != nil || len(v.invalidAssertions) == 0 {
+		return nil
+	}
+	staker, err := v.l1BridgeClient.GetStaker(ctx, v.cfg.GetAccountAddr())
+	if err != nil {
+		return fmt.Errorf("failed to get staker: %w", err)
+	}
+	if staker.CurrentChallenge != (common.Address{}) {
+		return nil
+	}
+	ours, err := v.l1BridgeClient.GetAssertion(ctx, staker.AssertionID)
+	if err != nil {
+		return fmt.Errorf("failed to get assertion (id=%s): %w", staker.AssertionID, err)
+	}
+	ids := make([]uint64, 0, len(v.invalidAssertions))
+	for id := range v.invalidAssertions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		invalid, err := v.l1BridgeClient.GetAssertion(ctx, new(big.Int).SetUint64(id))
+		if err != nil {
+			return fmt.Errorf("failed to get assertion (id=%d): %w", id, err)
+		}
+		// Assertions are created as children of the creator's staked assertion.
+		if invalid.Parent.Cmp(staker.AssertionID) == 0 {
+			return v.createCompetingAssertion(ctx, id, invalid)
+		}
+		if invalid.Parent.Cmp(ours.Parent) != 0 || id == staker.AssertionID.Uint64() {
+			continue
+		}
+		var (
+			players      = [2]common.Address{v.invalidAssertions[id], v.cfg.GetAccountAddr()}
+			assertionIDs = [2]*big.Int{new(big.Int).SetUint64(id), staker.AssertionID}
+		)
+		// The earlier-created assertion is the one defended.
+		if staker.AssertionID.Uint64() < id {
+			players[0], players[1] = players[1], players[0]
+			assertionIDs[0], assertionIDs[1] = assertionIDs[1], assertionIDs[0]
+		}
+		log.Info("Challenging assertion", "id", id, "asserter", v.invalidAssertions[id], "ours", staker.AssertionID)
+		return v.sendChallengeTx(ctx, "challenge assertion", func(ctx context.Context) (*types.Receipt, error) {
+			return v.l1TxMgr.ChallengeAssertion(ctx, players, assertionIDs)
+		})
+	}
+	log.Trace("No competing assertion to challenge invalid assertions with", "staked_on", staker.AssertionID)
+	return nil
+}
+
+// Creates an assertion for the same L2 block as the invalid assertion `id`, to challenge it with.
+func (v *Validator) createCompetingAssertion(ctx context.Context, id uint64, invalid bindings.IRollupAssertion) error {
+	header, err := v.l2Client.HeaderByNumber(ctx, invalid.BlockNum)
+	if err != nil {
+		return fmt.Errorf("failed to get L2 header (num=%s): %w", invalid.BlockNum, err)
+	}
+	vmHash, err := v.computeVMHash(ctx, header)
+	if err != nil {
+		return fmt.Errorf("failed to compute VM hash: %w", err)
+	}
+	attrs := assertionAttributes{header.Number.Uint64(), vmHash}
+	log.Info("Creating competing assertion", "invalid_id", id, "l2Block#", attrs.l2BlockNum)
+	cCtx, cancel := context.WithTimeout(ctx, transactTimeout)
+	defer cancel()
+	receipt, err := v.l1TxMgr.CreateAssertion(cCtx, attrs.l2VMHash, header.Number)
+	if err != nil {
+		return fmt.Errorf("failed to create competing assertion: %w", err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		log.Error("Tx successfully published but reverted", "tx_hash", receipt.TxHash)
+		return nil
+	}
+	log.Info("Created competing assertion", "invalid_id", id, "tx_hash", receipt.TxHash)
+	v.setLastCreatedAssertionAttrs(attrs)
+	return nil
+}
+
+// Takes this validator's turn in its ongoing challenge, if any.
+func (v *Validator) advanceChallenge(ctx context.Context) error {
+	staker, err := v.l1BridgeClient.GetStaker(ctx, v.cfg.GetAccountAddr())
+	if err != nil {
+		return fmt.Errorf("failed to get staker: %w", err)
+	}
+	if staker.CurrentChallenge == (common.Address{}) {
+		if v.challenge != nil {
+			// Losers are removed as stakers.
+			if staker.IsStaked {
+				log.Info("Won challenge", "challenge", v.challenge.addr)
+			} else {
+				log.Error("Lost challenge", "challenge", v.challenge.addr)
+			}
+			v.challenge = nil
+		}
+		return nil
+	}
+	if v.challenge == nil || v.challenge.addr != staker.CurrentChallenge {
+		challenge, err := v.loadChallenge(ctx, staker.CurrentChallenge)
+		if err != nil {
+			return fmt.Errorf("failed to load challenge (addr=%s): %w", staker.CurrentChallenge, err)
+		}
+		v.challenge = challenge
+	}
+	c := v.challenge
+	responder, err := v.l1BridgeClient.GetCurrentResponder(ctx, c.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get current responder: %w", err)
+	}
+	events, err := v.l1BridgeClient.GetBisectedEvents(ctx, c.addr, c.createdL1Block)
+	if err != nil {
+		return fmt.Errorf("failed to get bisection events: %w", err)
+	}
+	if responder != v.cfg.GetAccountAddr() {
+		return v.awaitCounterparty(ctx, events)
+	}
+	c.turnStart = 0
+	// Each party first commits to its number of steps.
+	if len(events) == 0 {
+		numSteps := new(big.Int).SetUint64(uint64(len(c.states) - 1))
+		return v.sendChallengeTx(ctx, "initialize challenge length", func(ctx context.Context) (*types.Receipt, error) {
+			return v.l1TxMgr.InitializeChallengeLength(ctx, c.addr, numSteps)
+		})
+	}
+	prev, err := v.getBisection(ctx, events[len(events)-1])
+	if err != nil {
+		return fmt.Errorf("failed to get current bisection: %w", err)
+	}
+	resp, err := respondToBisection(prev, c.states)
+	if err != nil {
+		return fmt.Errorf("failed to respond to bisection: %w", err)
+	}
+	var (
+		index     = new(big.Int).SetUint64(resp.index)
+		prevStart = new(big.Int).SetUint64(prev.start)
+		prevLen   = new(big.Int).SetUint64(prev.length)
+	)
+	if !resp.isOneStep() {
+		return v.sendChallengeTx(ctx, "bisect execution", func(ctx context.Context) (*types.Receipt, error) {
+			return v.l1TxMgr.BisectExecution(ctx, c.addr, resp.bisection.hashes, index, prev.hashes, prevStart, prevLen)
+		})
+	}
+	step := prev.positions()[resp.index-1]
+	osp, err := v.l2Client.GenerateOneStepProof(ctx, c.startL2Block, c.endL2Block+1, step, v.proverConfig(c.endL2Block))
+	if err != nil {
+		return fmt.Errorf("failed to generate one-step proof (step=%d): %w", step, err)
+	}
+	verificationCtx := bindings.VerificationContextLibRawContext{
+		EncodedTx:        osp.EncodedTx,
+		L2BlockCoinbase:  osp.Coinbase,
+		L2BlockNumber:    new(big.Int).SetUint64(uint64(osp.BlockNumber)),
+		L2BlockTimestamp: new(big.Int).SetUint64(uint64(osp.BlockTimestamp)),
+	}
+	inclusionProof, err := v.txInclusionProof(uint64(osp.BlockNumber), osp.EncodedTx)
+	if err != nil {
+		return fmt.Errorf("failed to prove tx inclusion (step=%d): %w", step, err)
+	}
+	return v.sendChallengeTx(ctx, "verify one-step proof", func(ctx context.Context) (*types.Receipt, error) {
+		return v.l1TxMgr.VerifyOneStepProof(
+			ctx, c.addr, osp.Proof, inclusionProof, verificationCtx, index, prev.hashes, prevStart, prevLen,
+		)
+	})
+}
+
+// Times out the counterparty once they've run out of time to respond.
+func (v *Validator) awaitCounterparty(ctx context.Context, events []*bindings.ISymChallengeBisected) error {
+	var (
+		c      = v.challenge
+		l1Head = v.l1State.Head().GetNumber()
+	)
+	if c.turnStart == 0 {
+		c.turnStart = l1Head
+	}
+	// The counterparty may have moved more than once in a row.
+	if len(events) > 0 && events[len(events)-1].Raw.BlockNumber > c.turnStart {
+		c.turnStart = events[len(events)-1].Raw.BlockNumber
+	}
+	timeLeft, err := v.l1BridgeClient.GetCurrentResponderTimeLeft(ctx, c.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get counterparty's time left: %w", err)
+	}
+	if new(big.Int).SetUint64(l1Head-c.turnStart).Cmp(timeLeft) <= 0 {
+		log.Trace("Awaiting counterparty's response", "challenge", c.addr, "turn_start", c.turnStart, "time_left", timeLeft)
+		return nil
+	}
+	log.Info("Counterparty timed out", "challenge", c.addr)
+	return v.sendChallengeTx(ctx, "time out challenge", func(ctx context.Context) (*types.Receipt, error) {
+		return v.l1TxMgr.TimeoutChallenge(ctx, c.addr)
+	})
+}
+
+// Loads the challenge at `addr` and generates our execution states over the challenged block range.
+func (v *Validator) loadChallenge(ctx context.Context, addr common.Address) (*challengeState, error) {
+	// The rollup watcher may lag behind the staker's state, or not be subscribed to.
+	event, ok := v.challengeEvents[addr]
+	if !ok {
+		var err error
+		if event, err = v.findAssertionChallenged(ctx, addr); err != nil {
+			return nil, err
+		}
+	}
+	tx, _, err := v.l1Client.TransactionByHash(ctx, event.Raw.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge tx: %w", err)
+	}
+	players, assertionIDs, err := bridge.UnpackChallengeAssertionInput(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack challenge tx: %w", err)
+	}
+	var ourIdx int
+	switch v.cfg.GetAccountAddr() {
+	case players[0]:
+		ourIdx = 0
+	case players[1]:
+		ourIdx = 1
+	default:
+		return nil, fmt.Errorf("not a player in challenge (players=%v)", players)
+	}
+	var assertions [2]bindings.IRollupAssertion
+	for i, id := range assertionIDs {
+		if assertions[i], err = v.l1BridgeClient.GetAssertion(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to get assertion (id=%s): %w", id, err)
+		}
+	}
+	parent, err := v.l1BridgeClient.GetAssertion(ctx, assertions[0].Parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent assertion (id=%s): %w", assertions[0].Parent, err)
+	}
+	c := &challengeState{
+		addr:           addr,
+		createdL1Block: event.Raw.BlockNumber,
+		isDefender:     ourIdx == 0,
+		startVMHash:    parent.StateHash,
+		endVMHashes:    [2]common.Hash{assertions[0].StateHash, assertions[1].StateHash},
+		startL2Block:   parent.BlockNum.Uint64() + 1,
+		endL2Block:     assertions[ourIdx].BlockNum.Uint64(),
+	}
+	c.states, err = v.l2Client.GenerateStateHashes(ctx, c.startL2Block, c.endL2Block+1, v.proverConfig(c.endL2Block))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate states (blocks %d-%d): %w", c.startL2Block, c.endL2Block, err)
+	}
+	if len(c.states) < 2 {
+		return nil, fmt.Errorf("no execution steps in blocks %d-%d", c.startL2Block, c.endL2Block)
+	}
+	// The start is committed to as the parent's VM hash rather than an execution state hash.
+	// (The end is the VM hash of our last block, as generated by the prover.)
+	c.states[0] = c.startVMHash
+	log.Info(
+		"Loaded challenge",
+		"challenge", addr, "defender", c.isDefender, "assertions", assertionIDs, "num_steps", len(c.states)-1,
+	)
+	return c, nil
+}
+
+// Finds the event emitted on creation of the challenge at `addr`.
+func (v *Validator) findAssertionChallenged(
+	ctx context.Context,
+	addr common.Address,
+) (*bindings.IRollupAssertionChallenged, error) {
+	l1Head := v.l1State.Head().GetNumber()
+	for start := v.scanStartL1Block; start <= l1Head; start += maxAssertionQueryRange {
+		end := start + maxAssertionQueryRange - 1
+		if end > l1Head {
+			end = l1Head
+		}
+		events, err := v.l1BridgeClient.GetAssertionChallengedEvents(ctx, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events (from=%d, to=%d): %w", start, end, err)
+		}
+		for _, event := range events {
+			if event.ChallengeAddr == addr {
+				return event, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no creation event found for challenge %s", addr)
+}
+
+// Reconstructs the bisection committed to by a Bisected event from the tx that emitted it.
+func (v *Validator) getBisection(ctx context.Context, event *bindings.ISymChallengeBisected) (bisection, error) {
+	tx, _, err := v.l1Client.TransactionByHash(ctx, event.Raw.TxHash)
+	if err != nil {
+		return bisection{}, fmt.Errorf("failed to get bisection tx: %w", err)
+	}
+	var (
+		c      = v.challenge
+		start  = event.ChallengedSegmentStart.Uint64()
+		length = event.ChallengedSegmentLength.Uint64()
+		b      bisection
+	)
+	if bridge.IsBisectExecutionTx(tx) {
+		hashes, err := bridge.UnpackBisection(tx)
+		if err != nil {
+			return bisection{}, fmt.Errorf("failed to unpack bisection: %w", err)
+		}
+		b = bisection{hashes, start, length}
+	} else {
+		// Emitted by `initializeChallengeLength`, which picks the end state of the party with fewer steps.
+		b = initialBisection(c.startVMHash, c.endVMHashes[0], length)
+		if b.hash() != event.ChallengeState {
+			b = initialBisection(c.startVMHash, c.endVMHashes[1], length)
+		}
+	}
+	if b.hash() != event.ChallengeState {
+		return bisection{}, fmt.Errorf("bisection inconsistent with challenge state (tx=%s)", tx.Hash())
+	}
+	return b, nil
+}
+
+// Sends a challenge-related tx using `send`, logging whether it reverted.
+func (v *Validator) sendChallengeTx(
+	ctx context.Context,
+	action string,
+	send func(ctx context.Context) (*types.Receipt, error),
+) error {
+	cCtx, cancel := context.WithTimeout(ctx, transactTimeout)
+	defer cancel()
+	receipt, err := send(cCtx)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		log.Error("Tx successfully published but reverted", "action", action, "tx_hash", receipt.TxHash)
+	} else {
+		log.Info("Tx successfully published", "action", action, "tx_hash", receipt.TxHash)
+	}
+	return nil
+}
+
+// Returns the proof of a tx's inclusion in the sequencer inbox (see `SequencerInbox.verifyTxInclusion`),
+// built from the batches accumulated by the derivation checker.
+func (v *Validator) txInclusionProof(l2BlockNum uint64, encodedTx []byte) ([]byte, error) {
+	if v.derivationChecker == nil {
+		return nil, errors.New("tx inclusion proofs require derivation checking")
+	}
+	return v.derivationChecker.txInclusionProof(l2BlockNum, encodedTx)
+}
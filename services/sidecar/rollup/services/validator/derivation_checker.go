@@ -0,0 +1,152 @@
+package validator
+
+import (
+	"context"
+	"math/big"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Re-derives L2 blocks from the batches appended to the sequencer inbox (up to the L1 safe head),
+// and checks the local L2 chain against them, so that it isn't trusted blindly.
+// Checked blocks are accumulated as the inbox does, to prove tx inclusion in challenges.
+type DerivationChecker struct {
+	deriver     Deriver
+	nextL1Block uint64                         // Next L1 block to derive from.
+	pending     []derivation.L2BlockAttributes // Derived blocks not yet checked (local chain behind).
+	acc         derivation.TxAccumulator       // Accumulator after the checked blocks.
+	batches     []*derivation.AccumulatedBatch // Batches of checked blocks, not yet pruned (see `prune`).
+}
+
+// Creates a checker deriving from L1 blocks after `genesisL1Block`.
+func NewDerivationChecker(deriver Deriver, genesisL1Block uint64) *DerivationChecker {
+	return &DerivationChecker{deriver: deriver, nextL1Block: genesisL1Block + 1}
+}
+
+// Derives L2 blocks from L1 blocks up to the L1 safe head, and checks them against the local L2 chain
+// (up to its safe head). Mismatching blocks are reported.
+func (v *Validator) checkDerivation(ctx context.Context) error {
+	c := v.derivationChecker
+	if c == nil {
+		return nil
+	}
+	// Txs of confirmed blocks can no longer be challenged.
+	if v.lastConfirmedID != nil {
+		assertion, err := v.l1BridgeClient.GetAssertion(ctx, v.lastConfirmedID)
+		if err != nil {
+			return fmt.Errorf("failed to get confirmed assertion (id=%s): %w", v.lastConfirmedID, err)
+		}
+		c.prune(assertion.BlockNum.Uint64())
+		v.lastConfirmedID = nil
+	}
+	for l1Safe := v.l1State.Safe().GetNumber(); c.nextL1Block <= l1Safe; c.nextL1Block++ {
+		attrs, err := c.deriver.Derive(ctx, c.nextL1Block)
+		if err != nil {
+			return fmt.Errorf("failed to derive from L1 block (num=%d): %w", c.nextL1Block, err)
+		}
+		c.pending = append(c.pending, attrs...)
+	}
+	if len(c.pending) == 0 {
+		return nil
+	}
+	safe, err := v.l2Client.HeaderByTag(ctx, eth.Safe)
+	if err != nil {
+		return fmt.Errorf("failed to get L2 safe header: %w", err)
+	}
+	for len(c.pending) > 0 {
+		attrs := c.pending[0]
+		// Wait for the local L2 chain to catch up.
+		if attrs.Number > safe.Number.Uint64() {
+			log.Trace("Local L2 chain behind derived block", "l2Block#", attrs.Number, "safe", safe.Number)
+			return nil
+		}
+		block, err := v.l2Client.BlockByNumber(ctx, new(big.Int).SetUint64(attrs.Number))
+		if err != nil {
+			return fmt.Errorf("failed to get L2 block (num=%d): %w", attrs.Number, err)
+		}
+		if reason := mismatchReason(attrs, block); reason != "" {
+			log.Error(
+				"Local L2 block does not match derived block",
+				"l2Block#", attrs.Number, "l1Block", attrs.L1Block, "reason", reason,
+			)
+		} else {
+			log.Trace("Checked derived block", "l2Block#", attrs.Number)
+		}
+		if err := c.accumulate(attrs, block.Header()); err != nil {
+			return fmt.Errorf("failed to accumulate derived block (num=%d): %w", attrs.Number, err)
+		}
+		c.pending = c.pending[1:]
+	}
+	return nil
+}
+
+// Returns why the local block doesn't match the derived one, or "" if it does.
+func mismatchReason(attrs derivation.L2BlockAttributes, block *ethTypes.Block) string {
+	// Timestamps are only derivable from V2 batches onwards.
+	if attrs.Timestamp != 0 && attrs.Timestamp != block.Time() {
+		return fmt.Sprintf("timestamp %d, derived %d", block.Time(), attrs.Timestamp)
+	}
+	txs := block.Transactions()
+	if len(txs) != len(attrs.Txs) {
+		return fmt.Sprintf("%d txs, derived %d", len(txs), len(attrs.Txs))
+	}
+	for i, tx := range txs {
+		if tx.Hash() != attrs.Txs[i].Hash() {
+			return fmt.Sprintf("tx %d is %s, derived %s", i, tx.Hash(), attrs.Txs[i].Hash())
+		}
+	}
+	return ""
+}
+
+// Adds the txs of a derived block to the accumulator, with the context of the local L2 block.
+// The txs are those derived (i.e. the inbox's), even if the local block doesn't match.
+func (c *DerivationChecker) accumulate(attrs derivation.L2BlockAttributes, header *ethTypes.Header) error {
+	if len(c.batches) == 0 || c.batches[len(c.batches)-1].Num != attrs.BatchNum {
+		c.batches = append(c.batches, &derivation.AccumulatedBatch{Num: attrs.BatchNum, Before: c.acc})
+	}
+	batch := c.batches[len(c.batches)-1]
+	for _, tx := range attrs.Txs {
+		encodedTx, err := tx.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to encode tx (hash=%s): %w", tx.Hash(), err)
+		}
+		accTx := derivation.NewAccumulatedTx(header.Coinbase, attrs.Number, header.Time, encodedTx)
+		batch.Txs = append(batch.Txs, accTx)
+		c.acc = c.acc.Add(accTx)
+	}
+	return nil
+}
+
+// Returns the proof of inclusion of a tx in the inbox (see `derivation.AccumulatedBatch.InclusionProof`).
+// Fails if the tx's batch hasn't been fully checked yet; retry later.
+func (c *DerivationChecker) txInclusionProof(l2BlockNum uint64, encodedTx []byte) ([]byte, error) {
+	for i, batch := range c.batches {
+		index, ok := batch.IndexOf(l2BlockNum, encodedTx)
+		if !ok {
+			continue
+		}
+		// Blocks are derived a batch at a time, so it's complete unless some of its blocks are still pending.
+		isLast := i == len(c.batches)-1
+		if isLast && len(c.pending) > 0 && c.pending[0].BatchNum == batch.Num {
+			return nil, fmt.Errorf("batch %d not fully checked yet", batch.Num)
+		}
+		return batch.InclusionProof(index)
+	}
+	return nil, fmt.Errorf("tx not found in checked batches (l2Block#=%d)", l2BlockNum)
+}
+
+// Stops tracking batches whose txs are all at or before the given L2 block (e.g. confirmed ones),
+// since they needn't be proven anymore. The last batch is kept, as blocks may still be added to it.
+func (c *DerivationChecker) prune(l2BlockNum uint64) {
+	for len(c.batches) > 1 {
+		txs := c.batches[0].Txs
+		if len(txs) > 0 && txs[len(txs)-1].L2BlockNum > l2BlockNum {
+			return
+		}
+		c.batches = c.batches[1:]
+	}
+}
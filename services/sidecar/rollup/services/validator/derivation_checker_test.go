@@ -0,0 +1,146 @@
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+)
+
+var testCoinbase = common.HexToAddress("0x5000000000000000000000000000000000000005")
+
+func testBlockAttrs(num, batchNum uint64, numTxs int) derivation.L2BlockAttributes {
+	attrs := derivation.L2BlockAttributes{Number: num, BatchNum: batchNum}
+	for i := 0; i < numTxs; i++ {
+		attrs.Txs = append(attrs.Txs, ethTypes.NewTx(&ethTypes.LegacyTx{Nonce: num*100 + uint64(i), Gas: 21_000}))
+	}
+	return attrs
+}
+
+func testBlockHeader(num uint64) *ethTypes.Header {
+	return &ethTypes.Header{Number: new(big.Int).SetUint64(num), Coinbase: testCoinbase, Time: 1000 + num}
+}
+
+func TestDerivationCheckerTxInclusionProof(t *testing.T) {
+	var (
+		c     DerivationChecker
+		attrs = []derivation.L2BlockAttributes{
+			testBlockAttrs(1, 0, 2),
+			testBlockAttrs(2, 0, 1),
+			testBlockAttrs(3, 1, 0),
+			testBlockAttrs(4, 2, 1),
+			testBlockAttrs(5, 2, 2),
+		}
+	)
+	// Block 5 not checked yet, so batch 2 is incomplete.
+	for _, a := range attrs[:4] {
+		if err := c.accumulate(a, testBlockHeader(a.Number)); err != nil {
+			t.Fatalf("failed to accumulate block %d: %v", a.Number, err)
+		}
+	}
+	c.pending = attrs[4:]
+	encode := func(tx *ethTypes.Transaction) []byte {
+		encodedTx, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode tx: %v", err)
+		}
+		return encodedTx
+	}
+
+	tests := []struct {
+		name      string
+		l2Block   uint64
+		encodedTx []byte
+		wantErr   bool
+		wantBatch uint64
+	}{
+		{"first tx", 1, encode(attrs[0].Txs[0]), false, 0},
+		{"last tx of batch", 2, encode(attrs[1].Txs[0]), false, 0},
+		{"incomplete batch", 4, encode(attrs[3].Txs[0]), true, 0},
+		{"wrong block", 2, encode(attrs[0].Txs[0]), true, 0},
+		{"unknown tx", 1, []byte{0x01}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := c.txInclusionProof(tt.l2Block, tt.encodedTx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to prove tx: %v", err)
+			}
+			if got := new(big.Int).SetBytes(proof[common.HashLength : 2*common.HashLength]); got.Uint64() != tt.wantBatch {
+				t.Errorf("got batch %d, want %d", got, tt.wantBatch)
+			}
+		})
+	}
+
+	// Once the batch is complete, its txs are provable.
+	if err := c.accumulate(attrs[4], testBlockHeader(5)); err != nil {
+		t.Fatalf("failed to accumulate block 5: %v", err)
+	}
+	c.pending = nil
+	if _, err := c.txInclusionProof(4, encode(attrs[3].Txs[0])); err != nil {
+		t.Errorf("failed to prove tx of completed batch: %v", err)
+	}
+	if c.acc.NumTxs != 6 {
+		t.Errorf("accumulated %d txs, want 6", c.acc.NumTxs)
+	}
+}
+
+func TestDerivationCheckerPrune(t *testing.T) {
+	var c DerivationChecker
+	for _, a := range []derivation.L2BlockAttributes{
+		testBlockAttrs(1, 0, 1),
+		testBlockAttrs(2, 1, 0),
+		testBlockAttrs(3, 2, 1),
+		testBlockAttrs(4, 2, 1),
+		testBlockAttrs(5, 3, 1),
+	} {
+		if err := c.accumulate(a, testBlockHeader(a.Number)); err != nil {
+			t.Fatalf("failed to accumulate block %d: %v", a.Number, err)
+		}
+	}
+	batchNums := func() []uint64 {
+		var nums []uint64
+		for _, b := range c.batches {
+			nums = append(nums, b.Num)
+		}
+		return nums
+	}
+	tests := []struct {
+		l2Block uint64
+		want    []uint64
+	}{
+		{0, []uint64{0, 1, 2, 3}},
+		// Empty batches are pruned along with the ones before them.
+		{2, []uint64{2, 3}},
+		// Batches partially at or before the block are kept.
+		{3, []uint64{2, 3}},
+		// The last batch is always kept.
+		{10, []uint64{3}},
+	}
+	for _, tt := range tests {
+		c.prune(tt.l2Block)
+		if got := batchNums(); !equalUint64s(got, tt.want) {
+			t.Errorf("pruned to L2 block %d: got batches %v, want %v", tt.l2Block, got, tt.want)
+		}
+	}
+}
+
+func equalUint64s(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
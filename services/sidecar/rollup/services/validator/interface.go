@@ -7,7 +7,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/lib/el_golang_lib/vmhash"
 	"github.com/specularL2/specular/services/sidecar/bindings"
+	"github.com/specularL2/specular/services/sidecar/proof"
+	"github.com/specularL2/specular/services/sidecar/rollup/derivation"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
 	"github.com/specularL2/specular/services/sidecar/rollup/types"
 )
@@ -15,21 +19,86 @@ import (
 type Config interface {
 	GetAccountAddr() common.Address
 	GetValidationInterval() time.Duration
+	// One of `WatchMode`, `DefensiveMode` or `ActiveMode`.
+	GetMode() string
+	// L2 head to assert: "safe" or "finalized".
+	GetAssertionHead() string
+	GetMinAssertionL2Blocks() uint64
+	// Min # of L1 blocks between assertions (raised to the on-chain `minimumAssertionPeriod`).
+	GetMinAssertionL1Interval() uint64
+	// # of L1 blocks after which any new L2 work is asserted, regardless of other limits (0 disables).
+	GetMaxAssertionStaleness() uint64
+	GetMaxAssertionL1BaseFeeGwei() uint64
+}
+
+type ProtocolConfig interface {
+	GetVMHashActivations() vmhash.Activations
+	GetL2PortalAddr() common.Address
 }
 
 type TxManager interface {
 	Stake(ctx context.Context, stakeAmount *big.Int) (*ethTypes.Receipt, error)
+	RemoveStake(ctx context.Context, stakerAddress common.Address) (*ethTypes.Receipt, error)
 	AdvanceStake(ctx context.Context, assertionID *big.Int) (*ethTypes.Receipt, error)
+	Withdraw(ctx context.Context) (*ethTypes.Receipt, error)
 	CreateAssertion(ctx context.Context, vmHash common.Hash, inboxSize *big.Int) (*ethTypes.Receipt, error)
 	ConfirmFirstUnresolvedAssertion(ctx context.Context) (*ethTypes.Receipt, error)
+	RejectFirstUnresolvedAssertion(ctx context.Context, stakerAddress common.Address) (*ethTypes.Receipt, error)
+	ChallengeAssertion(ctx context.Context, players [2]common.Address, assertionIDs [2]*big.Int) (*ethTypes.Receipt, error)
+	InitializeChallengeLength(ctx context.Context, challenge common.Address, numSteps *big.Int) (*ethTypes.Receipt, error)
+	BisectExecution(
+		ctx context.Context,
+		challenge common.Address,
+		bisection []common.Hash,
+		challengedSegmentIndex *big.Int,
+		prevBisection []common.Hash,
+		prevChallengedSegmentStart *big.Int,
+		prevChallengedSegmentLength *big.Int,
+	) (*ethTypes.Receipt, error)
+	VerifyOneStepProof(
+		ctx context.Context,
+		challenge common.Address,
+		oneStepProof []byte,
+		txInclusionProof []byte,
+		verificationCtx bindings.VerificationContextLibRawContext,
+		challengedStepIndex *big.Int,
+		prevBisection []common.Hash,
+		prevChallengedSegmentStart *big.Int,
+		prevChallengedSegmentLength *big.Int,
+	) (*ethTypes.Receipt, error)
+	TimeoutChallenge(ctx context.Context, challenge common.Address) (*ethTypes.Receipt, error)
 }
 
 type BridgeClient interface {
 	GetRequiredStakeAmount(ctx context.Context) (*big.Int, error)
 	GetStaker(ctx context.Context, addr common.Address) (bindings.IRollupStaker, error)
+	GetWithdrawableFunds(ctx context.Context, addr common.Address) (*big.Int, error)
 	GetAssertion(ctx context.Context, assertionID *big.Int) (bindings.IRollupAssertion, error)
+	GetAssertionCreatedEvents(ctx context.Context, start, end uint64) ([]*bindings.IRollupAssertionCreated, error)
 	GetLastConfirmedAssertionID(ctx context.Context) (*big.Int, error)
+	GetMinimumAssertionPeriod(ctx context.Context) (*big.Int, error)
 	RequireFirstUnresolvedAssertionIsConfirmable(ctx context.Context) error
+	RequireFirstUnresolvedAssertionIsRejectable(ctx context.Context, stakerAddress common.Address) error
+	GetAssertionChallengedEvents(ctx context.Context, start, end uint64) ([]*bindings.IRollupAssertionChallenged, error)
+	GetStakerStakedEvents(ctx context.Context, start, end uint64) ([]*bindings.IRollupStakerStaked, error)
+	GetCurrentResponder(ctx context.Context, challenge common.Address) (common.Address, error)
+	GetCurrentResponderTimeLeft(ctx context.Context, challenge common.Address) (*big.Int, error)
+	GetBisectedEvents(ctx context.Context, challenge common.Address, start uint64) ([]*bindings.ISymChallengeBisected, error)
+}
+
+// Derives L2 blocks from the batches appended to the sequencer inbox in an L1 block (see `derivation.DerivationPipeline`).
+type Deriver interface {
+	Derive(ctx context.Context, l1BlockNum uint64) ([]derivation.L2BlockAttributes, error)
+}
+
+// Optional; without it, the validator scans for the events it needs itself.
+type RollupEventSubscriber interface {
+	Subscribe() chan bridge.RollupEvent
+}
+
+type L1Client interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
+	TransactionByHash(ctx context.Context, txHash common.Hash) (*ethTypes.Transaction, bool, error)
 }
 
 type EthState interface {
@@ -42,5 +111,13 @@ type L2Client interface {
 	EnsureDialed(ctx context.Context) error
 	BlockNumber(ctx context.Context) (uint64, error)
 	BlockByNumber(ctx context.Context, number *big.Int) (*ethTypes.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error)
 	HeaderByTag(ctx context.Context, tag eth.BlockTag) (*ethTypes.Header, error)
+	GenerateStateHashes(ctx context.Context, startNum, endNum uint64, config *proof.ProverConfig) ([]common.Hash, error)
+	GenerateOneStepProof(
+		ctx context.Context,
+		startNum, endNum, step uint64,
+		config *proof.ProverConfig,
+	) (*proof.OneStepProofResult, error)
+	StorageRootAt(ctx context.Context, account common.Address, blockNum *big.Int) (common.Hash, error)
 }
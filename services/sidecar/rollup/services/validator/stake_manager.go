@@ -0,0 +1,172 @@
+package validator
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/utils/fmt"
+	"github.com/specularL2/specular/services/sidecar/utils/log"
+)
+
+// Manages a validator's stake over its lifecycle: staking, keeping the stake at the
+// required amount and on the latest confirmed assertion, and exiting.
+type StakeManager struct {
+	account        common.Address
+	l1TxMgr        TxManager
+	l1BridgeClient BridgeClient
+}
+
+func NewStakeManager(account common.Address, l1TxMgr TxManager, l1BridgeClient BridgeClient) *StakeManager {
+	return &StakeManager{account: account, l1TxMgr: l1TxMgr, l1BridgeClient: l1BridgeClient}
+}
+
+// Stakes the required amount if not yet staked, or tops up the stake if the required amount rose.
+func (m *StakeManager) EnsureStaked(ctx context.Context) error {
+	staker, err := m.l1BridgeClient.GetStaker(ctx, m.account)
+	if err != nil {
+		return fmt.Errorf("failed to get staker: %w", err)
+	}
+	required, err := m.l1BridgeClient.GetRequiredStakeAmount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stake amount: %w", err)
+	}
+	if !staker.IsStaked {
+		if err := m.sendTx(ctx, "stake", func(ctx context.Context) (*types.Receipt, error) {
+			return m.l1TxMgr.Stake(ctx, required)
+		}); err != nil {
+			return err
+		}
+		log.Info("Staked successfully.", "amount", required)
+		return nil
+	}
+	if staker.AmountStaked.Cmp(required) >= 0 {
+		log.Trace("Already staked.", "amount", staker.AmountStaked, "required", required)
+		return nil
+	}
+	topUp := new(big.Int).Sub(required, staker.AmountStaked)
+	if err := m.sendTx(ctx, "top up stake", func(ctx context.Context) (*types.Receipt, error) {
+		return m.l1TxMgr.Stake(ctx, topUp)
+	}); err != nil {
+		return err
+	}
+	log.Info("Topped up stake.", "amount", topUp, "required", required)
+	return nil
+}
+
+// Advances our stake along the confirmed chain up to the latest confirmed assertion, one child at a time
+// (as required by `advanceStake`). Returns true if the stake was advanced.
+func (m *StakeManager) AdvanceStake(ctx context.Context) (bool, error) {
+	staker, err := m.l1BridgeClient.GetStaker(ctx, m.account)
+	if err != nil {
+		return false, fmt.Errorf("failed to get staker: %w", err)
+	}
+	if !staker.IsStaked {
+		return false, nil
+	}
+	lastConfirmedID, err := m.l1BridgeClient.GetLastConfirmedAssertionID(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get last confirmed assertion ID: %w", err)
+	}
+	if staker.AssertionID.Cmp(lastConfirmedID) >= 0 {
+		return false, nil
+	}
+	path, err := m.confirmedPath(ctx, staker.AssertionID, lastConfirmedID)
+	if err != nil {
+		return false, err
+	}
+	log.Info("Advancing stake", "from", staker.AssertionID, "to", lastConfirmedID, "steps", len(path))
+	for _, id := range path {
+		id := id
+		if err := m.sendTx(ctx, "advance stake", func(ctx context.Context) (*types.Receipt, error) {
+			return m.l1TxMgr.AdvanceStake(ctx, id)
+		}); err != nil {
+			return true, err
+		}
+		log.Info("Advanced stake", "id", id)
+	}
+	return true, nil
+}
+
+// Exits cleanly: advances our stake to the latest confirmed assertion, removes all of it
+// (`unstake` can't go below the required stake) and withdraws any funds credited from challenges, if there are any.
+// Fails if our stake is on an unconfirmed assertion or in a challenge; retry once resolved.
+func (m *StakeManager) Exit(ctx context.Context) error {
+	if _, err := m.AdvanceStake(ctx); err != nil {
+		return fmt.Errorf("failed to advance stake: %w", err)
+	}
+	staker, err := m.l1BridgeClient.GetStaker(ctx, m.account)
+	if err != nil {
+		return fmt.Errorf("failed to get staker: %w", err)
+	}
+	if staker.IsStaked {
+		if staker.CurrentChallenge != (common.Address{}) {
+			return fmt.Errorf("staker in challenge %s", staker.CurrentChallenge)
+		}
+		lastConfirmedID, err := m.l1BridgeClient.GetLastConfirmedAssertionID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get last confirmed assertion ID: %w", err)
+		}
+		if staker.AssertionID.Cmp(lastConfirmedID) > 0 {
+			return fmt.Errorf(
+				"staked on unconfirmed assertion (id=%s, last_confirmed=%s)", staker.AssertionID, lastConfirmedID,
+			)
+		}
+		if err := m.sendTx(ctx, "remove stake", func(ctx context.Context) (*types.Receipt, error) {
+			return m.l1TxMgr.RemoveStake(ctx, m.account)
+		}); err != nil {
+			return err
+		}
+		log.Info("Removed stake.", "amount", staker.AmountStaked)
+	}
+	funds, err := m.l1BridgeClient.GetWithdrawableFunds(ctx, m.account)
+	if err != nil {
+		return fmt.Errorf("failed to get withdrawable funds: %w", err)
+	}
+	if funds.Sign() == 0 {
+		log.Info("No funds to withdraw.")
+		return nil
+	}
+	if err := m.sendTx(ctx, "withdraw", m.l1TxMgr.Withdraw); err != nil {
+		return err
+	}
+	log.Info("Withdrew funds.", "amount", funds)
+	return nil
+}
+
+// Returns the IDs of the confirmed assertions after `fromID` up to `toID`, oldest first.
+func (m *StakeManager) confirmedPath(ctx context.Context, fromID, toID *big.Int) ([]*big.Int, error) {
+	var path []*big.Int
+	for id := toID; id.Cmp(fromID) != 0; {
+		if id.Cmp(fromID) < 0 {
+			return nil, fmt.Errorf("staked assertion %s not on the confirmed chain", fromID)
+		}
+		path = append([]*big.Int{id}, path...)
+		assertion, err := m.l1BridgeClient.GetAssertion(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get assertion (id=%s): %w", id, err)
+		}
+		id = assertion.Parent
+	}
+	return path, nil
+}
+
+// Sends a stake tx, treating a reverted tx as an error.
+func (m *StakeManager) sendTx(
+	ctx context.Context,
+	action string,
+	send func(ctx context.Context) (*types.Receipt, error),
+) error {
+	cCtx, cancel := context.WithTimeout(ctx, transactTimeout)
+	defer cancel()
+	receipt, err := send(cCtx)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return fmt.Errorf("failed to %s: tx reverted (tx_hash=%s)", action, receipt.TxHash)
+	}
+	log.Info("Tx successfully published", "action", action, "tx_hash", receipt.TxHash)
+	return nil
+}
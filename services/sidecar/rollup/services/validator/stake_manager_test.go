@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/services/sidecar/bindings"
+)
+
+// Serves an unstaked staker and its withdrawable funds. Other methods panic.
+type testStakeBridgeClient struct {
+	BridgeClient
+	withdrawableFunds *big.Int
+}
+
+func (c *testStakeBridgeClient) GetStaker(ctx context.Context, addr common.Address) (bindings.IRollupStaker, error) {
+	return bindings.IRollupStaker{}, nil
+}
+
+func (c *testStakeBridgeClient) GetWithdrawableFunds(ctx context.Context, addr common.Address) (*big.Int, error) {
+	return c.withdrawableFunds, nil
+}
+
+// Counts withdrawals. Other methods panic.
+type testStakeTxManager struct {
+	TxManager
+	numWithdrawals int
+}
+
+func (m *testStakeTxManager) Withdraw(ctx context.Context) (*ethTypes.Receipt, error) {
+	m.numWithdrawals++
+	return &ethTypes.Receipt{Status: ethTypes.ReceiptStatusSuccessful}, nil
+}
+
+func TestStakeManagerExitWithdrawal(t *testing.T) {
+	tests := []struct {
+		name              string
+		withdrawableFunds *big.Int
+		wantWithdrawals   int
+	}{
+		{"nothing to withdraw", common.Big0, 0},
+		{"funds to withdraw", big.NewInt(10), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				l1TxMgr        = &testStakeTxManager{}
+				l1BridgeClient = &testStakeBridgeClient{withdrawableFunds: tt.withdrawableFunds}
+				m              = NewStakeManager(common.Address{1}, l1TxMgr, l1BridgeClient)
+			)
+			if err := m.Exit(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if l1TxMgr.numWithdrawals != tt.wantWithdrawals {
+				t.Errorf("got %d withdrawals, want %d", l1TxMgr.numWithdrawals, tt.wantWithdrawals)
+			}
+		})
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/lib/el_golang_lib/vmhash"
+	"github.com/specularL2/specular/services/sidecar/bindings"
+	"github.com/specularL2/specular/services/sidecar/proof"
 	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
-	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
 	"github.com/specularL2/specular/services/sidecar/rollup/services/api"
 	"github.com/specularL2/specular/services/sidecar/utils/fmt"
 	"github.com/specularL2/specular/services/sidecar/utils/log"
@@ -17,6 +21,15 @@ import (
 
 var transactTimeout = 10 * time.Minute
 
+const (
+	// Checks assertions and alerts on invalid ones, without staking.
+	WatchMode = "watch"
+	// Stakes and challenges invalid assertions, but creates no assertions otherwise.
+	DefensiveMode = "defensive"
+	// Stakes, creates, challenges and confirms assertions.
+	ActiveMode = "active"
+)
+
 type unexpectedSystemStateError struct{ msg string }
 
 func (e unexpectedSystemStateError) Error() string {
@@ -25,12 +38,32 @@ func (e unexpectedSystemStateError) Error() string {
 
 type Validator struct {
 	cfg            Config
+	protocolCfg    ProtocolConfig
 	l1TxMgr        TxManager
 	l1BridgeClient BridgeClient
 	l1State        EthState
+	l1Client       L1Client
 	l2Client       L2Client
+	rollupEventSub RollupEventSubscriber
+	rollupEvents   chan bridge.RollupEvent
+	stakeMgr       *StakeManager
+	policy         *assertionPolicy
+
+	derivationChecker *DerivationChecker // Nil if derivation isn't checked.
+	lastConfirmedID   *big.Int           // Assertion confirmed since the derivation checker was last pruned (nil if none).
 
 	lastCreatedAssertionAttrs assertionAttributes
+	attrsLock                 sync.RWMutex // Guards writes to `lastCreatedAssertionAttrs`, which is read by admin calls.
+
+	paused atomic.Bool
+
+	nextL1BlockToScan   uint64                    // Next L1 block to scan for created assertions (if not subscribed to events).
+	uncheckedAssertions []uncheckedAssertion      // Created assertions not yet checked against the local L2 chain.
+	invalidAssertions   map[uint64]common.Address // Unresolved assertions that failed the check, by ID.
+	scanStartL1Block    uint64                    // L1 block from which to scan for events on unresolved assertions.
+
+	challenge       *challengeState                                         // Challenge this validator is engaged in (nil if none).
+	challengeEvents map[common.Address]*bindings.IRollupAssertionChallenged // Events on challenge creation, by challenge.
 }
 
 type assertionAttributes struct {
@@ -40,12 +73,30 @@ type assertionAttributes struct {
 
 func NewValidator(
 	cfg Config,
+	protocolCfg ProtocolConfig,
 	l1TxMgr TxManager,
 	l1BridgeClient BridgeClient,
 	l1State EthState,
+	l1Client L1Client,
 	l2Client L2Client,
+	rollupEventSub RollupEventSubscriber,
+	derivationChecker *DerivationChecker,
 ) *Validator {
-	return &Validator{cfg: cfg, l1TxMgr: l1TxMgr, l1BridgeClient: l1BridgeClient, l1State: l1State, l2Client: l2Client}
+	return &Validator{
+		cfg:               cfg,
+		protocolCfg:       protocolCfg,
+		l1TxMgr:           l1TxMgr,
+		l1BridgeClient:    l1BridgeClient,
+		l1State:           l1State,
+		l1Client:          l1Client,
+		l2Client:          l2Client,
+		rollupEventSub:    rollupEventSub,
+		stakeMgr:          NewStakeManager(cfg.GetAccountAddr(), l1TxMgr, l1BridgeClient),
+		policy:            newAssertionPolicy(cfg),
+		derivationChecker: derivationChecker,
+		invalidAssertions: make(map[uint64]common.Address),
+		challengeEvents:   make(map[common.Address]*bindings.IRollupAssertionChallenged),
+	}
 }
 
 func (v *Validator) Start(ctx context.Context, eg api.ErrGroup) error {
@@ -53,8 +104,12 @@ func (v *Validator) Start(ctx context.Context, eg api.ErrGroup) error {
 	if err := v.l2Client.EnsureDialed(ctx); err != nil {
 		return fmt.Errorf("failed to create L2 client: %w", err)
 	}
+	// Subscribe before the rollup watcher starts publishing, so no events are missed.
+	if v.rollupEventSub != nil {
+		v.rollupEvents = v.rollupEventSub.Subscribe()
+	}
 	eg.Go(func() error { return v.start(ctx) })
-	log.Info("Validator started")
+	log.Info("Validator started", "mode", v.cfg.GetMode())
 	return nil
 }
 
@@ -66,15 +121,23 @@ func (v *Validator) start(ctx context.Context) error {
 	if err := v.validateGenesis(ctx); err != nil {
 		return fmt.Errorf("failed to validate genesis: %w", err)
 	}
-	if err := v.ensureStaked(ctx); err != nil {
-		return fmt.Errorf("failed to ensure validator is staked: %w", err)
+	if v.cfg.GetMode() != WatchMode {
+		if err := v.stakeMgr.EnsureStaked(ctx); err != nil {
+			return fmt.Errorf("failed to ensure validator is staked: %w", err)
+		}
 	}
 	if err := v.rollback(ctx); err != nil {
 		return fmt.Errorf("failed to initialize state: %w", err)
 	}
 	for {
 		select {
+		case ev := <-v.rollupEvents: // Never ready if not subscribed.
+			v.onRollupEvent(ev)
 		case <-ticker.C:
+			if v.paused.Load() {
+				log.Trace("Validator paused, skipping step")
+				break
+			}
 			if err := v.step(ctx); err != nil {
 				log.Errorf("Failed to advance: %w", err)
 				if errors.As(err, &unexpectedSystemStateError{}) {
@@ -88,14 +151,41 @@ func (v *Validator) start(ctx context.Context) error {
 	}
 }
 
-// Attempts to create a new assertion and confirm an existing assertion.
+// Depending on the mode, maintains the stake, attempts to create a new assertion, check created assertions,
+// take part in a challenge and confirm an existing assertion.
 func (v *Validator) step(ctx context.Context) error {
+	if v.cfg.GetMode() != WatchMode {
+		if err := v.maintainStake(ctx); err != nil {
+			return fmt.Errorf("failed to maintain stake: %w", err)
+		}
+	}
 	// Try to create a new assertion.
-	// TODO: do this only if configured to be an active validator.
-	if err := v.createAssertion(ctx); err != nil {
-		return fmt.Errorf("failed to create assertion: %w", err)
+	if v.cfg.GetMode() == ActiveMode {
+		if err := v.createAssertion(ctx); err != nil {
+			return fmt.Errorf("failed to create assertion: %w", err)
+		}
+	}
+	// Check created assertions against the local L2 chain.
+	if err := v.checkAssertions(ctx); err != nil {
+		return fmt.Errorf("failed to check assertions: %w", err)
+	}
+	// Check the local L2 chain against the one derived from L1.
+	if err := v.checkDerivation(ctx); err != nil {
+		return fmt.Errorf("failed to check derivation: %w", err)
+	}
+	if v.cfg.GetMode() == WatchMode {
+		return nil
+	}
+	// Challenge an invalid assertion, or take our turn in an ongoing challenge.
+	if err := v.challengeInvalidAssertion(ctx); err != nil {
+		return fmt.Errorf("failed to challenge assertion: %w", err)
+	}
+	if err := v.advanceChallenge(ctx); err != nil {
+		return fmt.Errorf("failed to advance challenge: %w", err)
+	}
+	if v.cfg.GetMode() != ActiveMode {
+		return nil
 	}
-	// TODO: validate assertions locally.
 	// Resolve the first unresolved assertion.
 	if err := v.resolveFirstUnresolvedAssertion(ctx); err != nil {
 		return fmt.Errorf("failed to resolve assertion: %w", err)
@@ -103,18 +193,92 @@ func (v *Validator) step(ctx context.Context) error {
 	return nil
 }
 
-// If enough time has passed and txs have been sequenced to L1, create a new assertion.
+// Tracks assertion lifecycle events published by the rollup watcher.
+func (v *Validator) onRollupEvent(ev bridge.RollupEvent) {
+	switch ev.Kind {
+	case bridge.AssertionCreated:
+		e := ev.AssertionCreated
+		if ev.Removed {
+			log.Warn("Created assertion reorged out", "id", e.AssertionID)
+			v.forgetAssertion(e.AssertionID.Uint64())
+			return
+		}
+		log.Info("Found created assertion", "id", e.AssertionID, "asserter", e.AsserterAddr)
+		v.uncheckedAssertions = append(v.uncheckedAssertions, uncheckedAssertion{e.AssertionID, e.AsserterAddr})
+	case bridge.AssertionChallenged:
+		e := ev.AssertionChallenged
+		if ev.Removed {
+			delete(v.challengeEvents, e.ChallengeAddr)
+			return
+		}
+		log.Info("Assertion challenged", "id", e.AssertionID, "challenge", e.ChallengeAddr)
+		v.challengeEvents[e.ChallengeAddr] = e
+	case bridge.AssertionConfirmed:
+		e := ev.AssertionConfirmed
+		if asserter, ok := v.invalidAssertions[e.AssertionID.Uint64()]; ok && !ev.Removed {
+			log.Error("Invalid assertion confirmed", "id", e.AssertionID, "asserter", asserter)
+		}
+		if !ev.Removed {
+			v.lastConfirmedID = e.AssertionID
+		}
+	}
+}
+
+// Stops tracking an assertion that no longer exists.
+func (v *Validator) forgetAssertion(id uint64) {
+	delete(v.invalidAssertions, id)
+	for i, unchecked := range v.uncheckedAssertions {
+		if unchecked.id.Uint64() == id {
+			v.uncheckedAssertions = append(v.uncheckedAssertions[:i], v.uncheckedAssertions[i+1:]...)
+			return
+		}
+	}
+}
+
+// Tops up our stake if needed and advances it to the latest confirmed assertion.
+func (v *Validator) maintainStake(ctx context.Context) error {
+	if err := v.stakeMgr.EnsureStaked(ctx); err != nil {
+		return err
+	}
+	advanced, err := v.stakeMgr.AdvanceStake(ctx)
+	if err != nil {
+		return err
+	}
+	// New assertions build on the one we're staked on.
+	if advanced {
+		return v.rollback(ctx)
+	}
+	return nil
+}
+
+// If the assertion policy allows it (see `assertionPolicy`), create a new assertion.
 // Add it to the queue for confirmation.
 func (v *Validator) createAssertion(ctx context.Context) error {
-	assertionAttrs, err := v.getNextAssertionAttrs(ctx)
+	header, err := v.l2Client.HeaderByTag(ctx, v.policy.head())
 	if err != nil {
-		return fmt.Errorf("failed to get next assertion attrs: %w", err)
+		return fmt.Errorf("failed to get L2 head (tag=%s): %w", v.policy.head(), err)
 	}
 	// TODO fix assumptions: not reorg-resistant. Other validators may have inserted new assertions.
-	if assertionAttrs.l2BlockNum <= v.lastCreatedAssertionAttrs.l2BlockNum {
+	if header.Number.Uint64() <= v.lastCreatedAssertionAttrs.l2BlockNum {
 		log.Info("No new blocks to create assertion for yet.")
 		return nil
 	}
+	candidate, err := v.getAssertionCandidate(ctx, header.Number.Uint64())
+	if err != nil {
+		return fmt.Errorf("failed to get assertion candidate: %w", err)
+	}
+	if reason := v.policy.holdReason(candidate); reason != "" {
+		log.Info(
+			"Holding back assertion",
+			"reason", reason, "l2Block#", candidate.l2BlockNum, "parent_l2Block#", candidate.parentL2BlockNum,
+		)
+		return nil
+	}
+	vmHash, err := v.computeVMHash(ctx, header)
+	if err != nil {
+		return fmt.Errorf("failed to compute VM hash: %w", err)
+	}
+	assertionAttrs := assertionAttributes{header.Number.Uint64(), vmHash}
 	cCtx, cancel := context.WithTimeout(ctx, transactTimeout)
 	defer cancel()
 	// TOOD: GasLimit: 0 ...?
@@ -127,27 +291,45 @@ func (v *Validator) createAssertion(ctx context.Context) error {
 	} else {
 		log.Info("Tx successfully published", "tx_hash", receipt.TxHash)
 		log.Info("Created assertion", "l2Block#", assertionAttrs.l2BlockNum)
-		v.lastCreatedAssertionAttrs = assertionAttrs
+		v.setLastCreatedAssertionAttrs(assertionAttrs)
 	}
 	return nil
 }
 
-// If the first unresolved assertion is eligible for confirmation, trigger its confirmation. Otherwise, wait.
-// TODO: reject or challenge, depending on circumstances.
+// If the first unresolved assertion is eligible for confirmation, trigger its confirmation.
+// If it can't be confirmed because not all stakers are on it (e.g. its staker lost a challenge), try rejecting it.
+// Otherwise, wait.
 func (v *Validator) resolveFirstUnresolvedAssertion(ctx context.Context) error {
+	if v.challenge != nil {
+		log.Trace("Challenge ongoing, not resolving.", "challenge", v.challenge.addr)
+		return nil
+	}
 	// Simulate a confirmation attempt.
 	err := v.l1BridgeClient.RequireFirstUnresolvedAssertionIsConfirmable(ctx)
 	if err != nil {
-		errStr := err.Error()
-		if errStr == bridge.NoUnresolvedAssertionErr {
+		switch {
+		case errors.Is(err, bridge.ErrNoUnresolvedAssertion):
 			log.Trace("No unresolved assertion to resolve.")
-		} else if errStr == bridge.ConfirmationPeriodPendingErr {
+		case errors.Is(err, bridge.ErrNoStaker):
+			log.Trace("No stakers, not resolving.")
+		case errors.Is(err, bridge.ErrConfirmationPeriodPending):
 			log.Trace("Too early to confirm first unresolved assertion.")
-		} else {
+		case errors.Is(err, bridge.ErrNotAllStaked), errors.Is(err, bridge.ErrInvalidParent):
+			return v.rejectFirstUnresolvedAssertion(ctx)
+		default:
 			return &unexpectedSystemStateError{"failed to validate assertion (breaks current assumptions): " + err.Error()}
 		}
 		return nil
 	}
+	// Never help confirm an invalid assertion.
+	hasInvalid, err := v.hasUnresolvedInvalidAssertion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check for invalid assertions: %w", err)
+	}
+	if hasInvalid {
+		log.Warn("Invalid assertion pending, not confirming.", "num_invalid", len(v.invalidAssertions))
+		return nil
+	}
 	cCtx, cancel := context.WithTimeout(ctx, transactTimeout)
 	defer cancel()
 	_, err = v.l1TxMgr.ConfirmFirstUnresolvedAssertion(cCtx)
@@ -158,51 +340,154 @@ func (v *Validator) resolveFirstUnresolvedAssertion(ctx context.Context) error {
 	return nil
 }
 
-// Rolls back local validator state, using the current L1 contract state as a checkpoint.
-func (v *Validator) rollback(ctx context.Context) error {
-	staker, err := v.l1BridgeClient.GetStaker(ctx, v.cfg.GetAccountAddr())
+// Rejects the first unresolved assertion if it's rejectable for any current staker
+// (see `requireFirstUnresolvedAssertionIsRejectable`), i.e. one staked on a sibling.
+func (v *Validator) rejectFirstUnresolvedAssertion(ctx context.Context) error {
+	stakers, err := v.unresolvedStakers(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get staker: %w", err)
+		return fmt.Errorf("failed to get stakers: %w", err)
 	}
-	assertion, err := v.l1BridgeClient.GetAssertion(ctx, staker.AssertionID)
+	for _, staker := range stakers {
+		err := v.l1BridgeClient.RequireFirstUnresolvedAssertionIsRejectable(ctx, staker)
+		if err == nil {
+			return v.sendRejection(ctx, staker)
+		}
+		switch {
+		case errors.Is(err, bridge.ErrNoUnresolvedAssertion),
+			errors.Is(err, bridge.ErrConfirmationPeriodPending),
+			errors.Is(err, bridge.ErrStakersPresent):
+			// Not rejectable for any staker.
+			log.Trace("First unresolved assertion not rejectable.", "reason", err)
+			return nil
+		case errors.Is(err, bridge.ErrNotStaked),
+			errors.Is(err, bridge.ErrAssertionAlreadyResolved),
+			errors.Is(err, bridge.ErrStakerStakedOnTarget):
+		default:
+			return fmt.Errorf("failed to simulate rejection (staker=%s): %w", staker, err)
+		}
+	}
+	log.Trace("First unresolved assertion not rejectable for any staker.")
+	return nil
+}
+
+func (v *Validator) sendRejection(ctx context.Context, staker common.Address) error {
+	cCtx, cancel := context.WithTimeout(ctx, transactTimeout)
+	defer cancel()
+	receipt, err := v.l1TxMgr.RejectFirstUnresolvedAssertion(cCtx, staker)
 	if err != nil {
-		return fmt.Errorf("failed to get assertion: %w", err)
+		return fmt.Errorf("failed to reject assertion: %w", err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		log.Error("Tx successfully published but reverted", "tx_hash", receipt.TxHash)
+		return nil
 	}
-	v.lastCreatedAssertionAttrs = assertionAttributes{assertion.BlockNum.Uint64(), assertion.StateHash}
+	log.Info("Rejected assertion", "staker", staker, "tx_hash", receipt.TxHash)
 	return nil
 }
 
-// Gets the next assertion's attributes.
-// We can relax this to get the next safe assertion's attributes but need to handle reorgs.
-func (v *Validator) getNextAssertionAttrs(ctx context.Context) (assertionAttributes, error) {
-	header, err := v.l2Client.HeaderByTag(ctx, eth.Safe)
+// Returns this validator's address followed by the other stakers currently staked on an unresolved assertion.
+// Stakers on a sibling of the first unresolved assertion staked on it after their common parent, the last confirmed
+// assertion, was proposed. So they're found from the StakerStaked events emitted since, then checked on-chain.
+func (v *Validator) unresolvedStakers(ctx context.Context) ([]common.Address, error) {
+	lastConfirmedID, err := v.l1BridgeClient.GetLastConfirmedAssertionID(ctx)
 	if err != nil {
-		return assertionAttributes{}, fmt.Errorf("failed to get finalized assertion attrs: %w", err)
+		return nil, fmt.Errorf("failed to get last confirmed assertion ID: %w", err)
+	}
+	lastConfirmed, err := v.l1BridgeClient.GetAssertion(ctx, lastConfirmedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last confirmed assertion: %w", err)
+	}
+	var (
+		self    = v.cfg.GetAccountAddr()
+		stakers = []common.Address{self}
+		seen    = map[common.Address]bool{self: true}
+		l1Head  = v.l1State.Head().GetNumber()
+	)
+	for start := lastConfirmed.ProposalTime.Uint64(); start <= l1Head; start += maxAssertionQueryRange {
+		end := start + maxAssertionQueryRange - 1
+		if end > l1Head {
+			end = l1Head
+		}
+		events, err := v.l1BridgeClient.GetStakerStakedEvents(ctx, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events (from=%d, to=%d): %w", start, end, err)
+		}
+		for _, event := range events {
+			if seen[event.StakerAddr] {
+				continue
+			}
+			seen[event.StakerAddr] = true
+			staker, err := v.l1BridgeClient.GetStaker(ctx, event.StakerAddr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get staker %s: %w", event.StakerAddr, err)
+			}
+			if staker.IsStaked && staker.AssertionID.Cmp(lastConfirmedID) > 0 {
+				stakers = append(stakers, event.StakerAddr)
+			}
+		}
 	}
-	return assertionAttributes{header.Number.Uint64(), computeVmHash(header)}, nil
+	return stakers, nil
 }
 
-func (v *Validator) ensureStaked(ctx context.Context) error {
-	staker, err := v.l1BridgeClient.GetStaker(ctx, v.cfg.GetAccountAddr())
+// Rolls back local validator state, using the current L1 contract state as a checkpoint.
+func (v *Validator) rollback(ctx context.Context) error {
+	// Check all assertions created since the last confirmed one.
+	lastConfirmedID, err := v.l1BridgeClient.GetLastConfirmedAssertionID(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get staker: %w", err)
+		return fmt.Errorf("failed to get last confirmed assertion ID: %w", err)
+	}
+	lastConfirmed, err := v.l1BridgeClient.GetAssertion(ctx, lastConfirmedID)
+	if err != nil {
+		return fmt.Errorf("failed to get last confirmed assertion: %w", err)
 	}
-	if staker.IsStaked {
-		log.Info("Already staked.")
+	v.scanStartL1Block = lastConfirmed.ProposalTime.Uint64() + 1
+	v.nextL1BlockToScan = v.scanStartL1Block
+	if v.cfg.GetMode() == WatchMode {
 		return nil
 	}
-	amount, err := v.l1BridgeClient.GetRequiredStakeAmount(ctx)
+	staker, err := v.l1BridgeClient.GetStaker(ctx, v.cfg.GetAccountAddr())
 	if err != nil {
-		return fmt.Errorf("failed to get stake amount: %w", err)
+		return fmt.Errorf("failed to get staker: %w", err)
 	}
-	_, err = v.l1TxMgr.Stake(ctx, amount)
+	assertion, err := v.l1BridgeClient.GetAssertion(ctx, staker.AssertionID)
 	if err != nil {
-		return fmt.Errorf("failed to stake: %w", err)
+		return fmt.Errorf("failed to get assertion: %w", err)
 	}
-	log.Info("Staked successfully.", "amount", amount)
+	v.setLastCreatedAssertionAttrs(assertionAttributes{assertion.BlockNum.Uint64(), assertion.StateHash})
 	return nil
 }
 
+// Gets the state on which the assertion policy decides whether to assert L2 block `l2BlockNum`.
+func (v *Validator) getAssertionCandidate(ctx context.Context, l2BlockNum uint64) (assertionCandidate, error) {
+	staker, err := v.l1BridgeClient.GetStaker(ctx, v.cfg.GetAccountAddr())
+	if err != nil {
+		return assertionCandidate{}, fmt.Errorf("failed to get staker: %w", err)
+	}
+	parent, err := v.l1BridgeClient.GetAssertion(ctx, staker.AssertionID)
+	if err != nil {
+		return assertionCandidate{}, fmt.Errorf("failed to get staked assertion (id=%s): %w", staker.AssertionID, err)
+	}
+	minPeriod, err := v.l1BridgeClient.GetMinimumAssertionPeriod(ctx)
+	if err != nil {
+		return assertionCandidate{}, fmt.Errorf("failed to get minimum assertion period: %w", err)
+	}
+	candidate := assertionCandidate{
+		l2BlockNum:       l2BlockNum,
+		parentL2BlockNum: parent.BlockNum.Uint64(),
+		parentProposal:   parent.ProposalTime.Uint64(),
+		l1Head:           v.l1State.Head().GetNumber(),
+		minPeriod:        minPeriod.Uint64(),
+	}
+	if v.policy.checksL1BaseFee() {
+		l1Header, err := v.l1Client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return assertionCandidate{}, fmt.Errorf("failed to get L1 head: %w", err)
+		}
+		candidate.l1BaseFee = l1Header.BaseFee
+	}
+	return candidate, nil
+}
+
 // TODO: refactor.
 func (v *Validator) validateGenesis(ctx context.Context) error {
 	assertion, err := v.l1BridgeClient.GetAssertion(ctx, common.Big0)
@@ -210,19 +495,48 @@ func (v *Validator) validateGenesis(ctx context.Context) error {
 		return fmt.Errorf("failed to get genesis assertion: %w", err)
 	}
 	// Check that the genesis assertion is correct.
+	// Legacy VM hashes have no version byte, so it's only checked once a versioned scheme is active.
 	vmHash := common.BytesToHash(assertion.StateHash[:])
+	version := v.protocolCfg.GetVMHashActivations().VersionAt(0)
+	if got := vmhash.GetVersion(vmHash); version != vmhash.Legacy && got != version {
+		return fmt.Errorf("genesis VM hash version on L1 is %d, expected %d", got, version)
+	}
 	genesisBlock, err := v.l2Client.BlockByNumber(ctx, common.Big0)
 	if err != nil {
 		return fmt.Errorf("failed to get L2 genesis block: %w", err)
 	}
-	l2VmHash := computeVmHash(genesisBlock.Header())
+	l2VmHash, err := v.computeVMHash(ctx, genesisBlock.Header())
+	if err != nil {
+		return fmt.Errorf("failed to compute L2 genesis VM hash: %w", err)
+	}
 	if vmHash != l2VmHash {
 		return fmt.Errorf("mismatching genesis on L1=%s vs L2=%s", vmHash, l2VmHash)
 	}
 	return nil
 }
 
-// TODO: add versioning
-func computeVmHash(header *types.Header) common.Hash {
-	return header.Hash()
+// Computes the VM hash committing to the L2 state at `header`, under the version active at its block.
+func (v *Validator) computeVMHash(ctx context.Context, header *types.Header) (common.Hash, error) {
+	var (
+		version = v.protocolCfg.GetVMHashActivations().VersionAt(header.Number.Uint64())
+		state   = vmhash.State{StateRoot: header.Root, BlockHash: header.Hash()}
+	)
+	if version != vmhash.Legacy {
+		withdrawalStorageRoot, err := v.l2Client.StorageRootAt(ctx, v.protocolCfg.GetL2PortalAddr(), header.Number)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to get withdrawal storage root (l2Block#=%s): %w", header.Number, err)
+		}
+		state.WithdrawalStorageRoot = withdrawalStorageRoot
+	}
+	return vmhash.Compute(version, state)
+}
+
+// Returns the config for the prover to generate execution states across blocks up to `endL2Block`,
+// so that the final state is its VM hash, as asserted.
+func (v *Validator) proverConfig(endL2Block uint64) *proof.ProverConfig {
+	var (
+		version      = v.protocolCfg.GetVMHashActivations().VersionAt(endL2Block)
+		l2PortalAddr = v.protocolCfg.GetL2PortalAddr()
+	)
+	return &proof.ProverConfig{VMHashVersion: &version, L2PortalAddr: &l2PortalAddr}
 }
@@ -0,0 +1,444 @@
+package validator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/specularL2/specular/lib/el_golang_lib/vmhash"
+	"github.com/specularL2/specular/services/sidecar/bindings"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/bridge"
+	"github.com/specularL2/specular/services/sidecar/rollup/rpc/eth"
+	"github.com/specularL2/specular/services/sidecar/rollup/types"
+)
+
+type testProtocolConfig struct {
+	activations  vmhash.Activations
+	l2PortalAddr common.Address
+}
+
+func (c testProtocolConfig) GetVMHashActivations() vmhash.Activations { return c.activations }
+func (c testProtocolConfig) GetL2PortalAddr() common.Address          { return c.l2PortalAddr }
+
+// Serves the genesis block, and the storage root of a single account. Other methods panic.
+type testL2Client struct {
+	L2Client
+	genesis     *ethTypes.Block
+	account     common.Address
+	storageRoot common.Hash
+}
+
+func (c *testL2Client) BlockByNumber(ctx context.Context, number *big.Int) (*ethTypes.Block, error) {
+	return c.genesis, nil
+}
+
+func (c *testL2Client) StorageRootAt(ctx context.Context, account common.Address, blockNum *big.Int) (common.Hash, error) {
+	if account != c.account {
+		return ethTypes.EmptyRootHash, nil
+	}
+	return c.storageRoot, nil
+}
+
+// Serves the genesis assertion. Other methods panic.
+type testBridgeClient struct {
+	BridgeClient
+	genesisVMHash common.Hash
+}
+
+func (c *testBridgeClient) GetAssertion(ctx context.Context, assertionID *big.Int) (bindings.IRollupAssertion, error) {
+	return bindings.IRollupAssertion{StateHash: c.genesisVMHash}, nil
+}
+
+func TestComputeVMHash(t *testing.T) {
+	var (
+		portal = common.HexToAddress("0x2A00000000000000000000000000000000000099")
+		l2     = &testL2Client{account: portal, storageRoot: common.Hash{3}}
+		header = &ethTypes.Header{Number: big.NewInt(10), Root: common.Hash{1}}
+	)
+	v1Hash, err := vmhash.Compute(vmhash.V1, vmhash.State{StateRoot: header.Root, BlockHash: header.Hash(), WithdrawalStorageRoot: l2.storageRoot})
+	if err != nil {
+		t.Fatalf("failed to compute V1 hash: %v", err)
+	}
+	tests := []struct {
+		name        string
+		activations vmhash.Activations
+		want        common.Hash
+	}{
+		{"legacy by default", nil, header.Hash()},
+		{"legacy before activation", vmhash.Activations{vmhash.V1: 11}, header.Hash()},
+		// The configured L2Portal's storage root must be committed to.
+		{"V1 once active", vmhash.Activations{vmhash.V1: 10}, v1Hash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{protocolCfg: testProtocolConfig{tt.activations, portal}, l2Client: l2}
+			got, err := v.computeVMHash(context.Background(), header)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGenesis(t *testing.T) {
+	var (
+		genesis = ethTypes.NewBlockWithHeader(&ethTypes.Header{Number: common.Big0, Root: common.Hash{1}})
+		l2      = &testL2Client{genesis: genesis, account: vmhash.DefaultL2PortalAddr, storageRoot: common.Hash{3}}
+	)
+	v1Hash, err := vmhash.Compute(vmhash.V1, vmhash.State{StateRoot: genesis.Root(), BlockHash: genesis.Hash(), WithdrawalStorageRoot: l2.storageRoot})
+	if err != nil {
+		t.Fatalf("failed to compute V1 hash: %v", err)
+	}
+	// A legacy hash with the V1 version byte.
+	ambiguousHash := genesis.Hash()
+	ambiguousHash[0] = vmhash.V1
+
+	tests := []struct {
+		name          string
+		activations   vmhash.Activations
+		genesisVMHash common.Hash
+		wantErr       bool
+	}{
+		// Existing chains assert legacy VM hashes from genesis.
+		{"legacy", nil, genesis.Hash(), false},
+		{"legacy with V1 activated later", vmhash.Activations{vmhash.V1: 100}, genesis.Hash(), false},
+		{"V1", vmhash.Activations{vmhash.V1: 0}, v1Hash, false},
+		{"legacy expecting V1", vmhash.Activations{vmhash.V1: 0}, ambiguousHash, true},
+		{"V1 expecting legacy", nil, v1Hash, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{
+				protocolCfg:    testProtocolConfig{tt.activations, vmhash.DefaultL2PortalAddr},
+				l1BridgeClient: &testBridgeClient{genesisVMHash: tt.genesisVMHash},
+				l2Client:       l2,
+			}
+			if err := v.validateGenesis(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Serves the validator's account. Other methods panic.
+type testConfig struct {
+	Config
+	accountAddr common.Address
+}
+
+func (c testConfig) GetAccountAddr() common.Address { return c.accountAddr }
+
+// Serves the L1 head. Other methods panic.
+type testEthState struct {
+	EthState
+	head types.BlockID
+}
+
+func (s testEthState) Head() types.BlockID { return s.head }
+
+// Serves the stakers of a rollup and the events emitted on staking. Other methods panic.
+type testStakersBridgeClient struct {
+	BridgeClient
+	lastConfirmed     bindings.IRollupAssertion
+	lastConfirmedID   *big.Int
+	stakers           map[common.Address]bindings.IRollupStaker
+	events            []*bindings.IRollupStakerStaked
+	confirmationError error
+}
+
+func (c *testStakersBridgeClient) GetLastConfirmedAssertionID(ctx context.Context) (*big.Int, error) {
+	return c.lastConfirmedID, nil
+}
+
+func (c *testStakersBridgeClient) GetAssertion(ctx context.Context, assertionID *big.Int) (bindings.IRollupAssertion, error) {
+	return c.lastConfirmed, nil
+}
+
+func (c *testStakersBridgeClient) GetStaker(ctx context.Context, addr common.Address) (bindings.IRollupStaker, error) {
+	return c.stakers[addr], nil
+}
+
+func (c *testStakersBridgeClient) GetStakerStakedEvents(
+	ctx context.Context,
+	start, end uint64,
+) ([]*bindings.IRollupStakerStaked, error) {
+	var events []*bindings.IRollupStakerStaked
+	for _, ev := range c.events {
+		if ev.Raw.BlockNumber >= start && ev.Raw.BlockNumber <= end {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (c *testStakersBridgeClient) RequireFirstUnresolvedAssertionIsConfirmable(ctx context.Context) error {
+	return c.confirmationError
+}
+
+func TestUnresolvedStakers(t *testing.T) {
+	var (
+		self, a, b, c, d = common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}, common.Address{5}
+		proposalTime     = uint64(10)
+		staked           = func(staker common.Address, l1BlockNum uint64) *bindings.IRollupStakerStaked {
+			return &bindings.IRollupStakerStaked{StakerAddr: staker, Raw: ethTypes.Log{BlockNumber: l1BlockNum}}
+		}
+		l1BridgeClient = &testStakersBridgeClient{
+			lastConfirmed:   bindings.IRollupAssertion{ProposalTime: new(big.Int).SetUint64(proposalTime)},
+			lastConfirmedID: big.NewInt(5),
+			stakers: map[common.Address]bindings.IRollupStaker{
+				self: {IsStaked: true, AssertionID: big.NewInt(5)},
+				a:    {IsStaked: true, AssertionID: big.NewInt(6)},
+				c:    {IsStaked: true, AssertionID: big.NewInt(5)},
+				d:    {IsStaked: true, AssertionID: big.NewInt(7)},
+			},
+			events: []*bindings.IRollupStakerStaked{
+				staked(d, proposalTime-1), // Before the last confirmed assertion; not scanned.
+				staked(a, proposalTime),
+				staked(self, proposalTime+1),
+				staked(b, proposalTime+2),                      // No longer staked.
+				staked(c, proposalTime+maxAssertionQueryRange), // Staked on the last confirmed assertion.
+				staked(a, proposalTime+maxAssertionQueryRange+1),
+				staked(d, proposalTime+maxAssertionQueryRange+2),
+			},
+		}
+		v = &Validator{
+			cfg:            testConfig{accountAddr: self},
+			l1BridgeClient: l1BridgeClient,
+			l1State:        testEthState{head: types.NewBlockID(proposalTime+maxAssertionQueryRange+2, common.Hash{})},
+		}
+	)
+	got, err := v.unresolvedStakers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []common.Address{self, a, d}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestResolveFirstUnresolvedAssertionNoStaker(t *testing.T) {
+	v := &Validator{l1BridgeClient: &testStakersBridgeClient{confirmationError: bridge.ErrNoStaker}}
+	if err := v.resolveFirstUnresolvedAssertion(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Serves the local L2 chain's headers, up to its safe head. Other methods panic.
+type testHeadersL2Client struct {
+	L2Client
+	headers []*ethTypes.Header // By number.
+	safe    uint64
+}
+
+func (c *testHeadersL2Client) HeaderByTag(ctx context.Context, tag eth.BlockTag) (*ethTypes.Header, error) {
+	return c.headers[c.safe], nil
+}
+
+func (c *testHeadersL2Client) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	return c.headers[number.Uint64()], nil
+}
+
+// Serves created assertions and their events. Other methods panic.
+type testAssertionsBridgeClient struct {
+	BridgeClient
+	assertions      map[uint64]bindings.IRollupAssertion
+	events          []*bindings.IRollupAssertionCreated
+	lastConfirmedID *big.Int
+	queries         [][2]uint64 // Queried L1 block ranges.
+}
+
+func (c *testAssertionsBridgeClient) GetAssertion(ctx context.Context, assertionID *big.Int) (bindings.IRollupAssertion, error) {
+	return c.assertions[assertionID.Uint64()], nil
+}
+
+func (c *testAssertionsBridgeClient) GetAssertionCreatedEvents(
+	ctx context.Context,
+	start, end uint64,
+) ([]*bindings.IRollupAssertionCreated, error) {
+	c.queries = append(c.queries, [2]uint64{start, end})
+	var events []*bindings.IRollupAssertionCreated
+	for _, ev := range c.events {
+		if ev.Raw.BlockNumber >= start && ev.Raw.BlockNumber <= end {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (c *testAssertionsBridgeClient) GetLastConfirmedAssertionID(ctx context.Context) (*big.Int, error) {
+	return c.lastConfirmedID, nil
+}
+
+func testL2Headers(n int) []*ethTypes.Header {
+	headers := make([]*ethTypes.Header, n)
+	for i := range headers {
+		headers[i] = &ethTypes.Header{Number: big.NewInt(int64(i)), Root: common.Hash{byte(i)}}
+	}
+	return headers
+}
+
+func TestCheckAssertions(t *testing.T) {
+	var (
+		asserter = common.Address{1}
+		headers  = testL2Headers(5)
+		created  = func(id, l1BlockNum uint64) *bindings.IRollupAssertionCreated {
+			return &bindings.IRollupAssertionCreated{
+				AssertionID:  new(big.Int).SetUint64(id),
+				AsserterAddr: asserter,
+				Raw:          ethTypes.Log{BlockNumber: l1BlockNum},
+			}
+		}
+		// Legacy VM hashes are the L2 block hash.
+		assertionAt = func(l2BlockNum uint64, vmHash common.Hash) bindings.IRollupAssertion {
+			return bindings.IRollupAssertion{
+				StateHash:    vmHash,
+				BlockNum:     new(big.Int).SetUint64(l2BlockNum),
+				ProposalTime: big.NewInt(1),
+			}
+		}
+	)
+	tests := []struct {
+		name          string
+		assertion     bindings.IRollupAssertion
+		wantInvalid   bool
+		wantUnchecked bool
+	}{
+		{"valid", assertionAt(2, headers[2].Hash()), false, false},
+		{"invalid", assertionAt(2, common.Hash{0xff}), true, false},
+		{"rejected", bindings.IRollupAssertion{ProposalTime: common.Big0}, false, false},
+		// Checked once the local L2 chain catches up.
+		{"ahead of the safe head", assertionAt(4, headers[4].Hash()), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{
+				protocolCfg: testProtocolConfig{},
+				l1BridgeClient: &testAssertionsBridgeClient{
+					assertions: map[uint64]bindings.IRollupAssertion{1: tt.assertion},
+					events:     []*bindings.IRollupAssertionCreated{created(1, 10)},
+				},
+				l1State:           testEthState{head: types.NewBlockID(10, common.Hash{})},
+				l2Client:          &testHeadersL2Client{headers: headers, safe: 3},
+				nextL1BlockToScan: 10,
+				invalidAssertions: make(map[uint64]common.Address),
+			}
+			if err := v.checkAssertions(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, ok := v.invalidAssertions[1]; ok != tt.wantInvalid || (ok && got != asserter) {
+				t.Errorf("got invalid assertions %v, want invalid: %t", v.invalidAssertions, tt.wantInvalid)
+			}
+			if got := len(v.uncheckedAssertions) > 0; got != tt.wantUnchecked {
+				t.Errorf("got unchecked assertions %v, want unchecked: %t", v.uncheckedAssertions, tt.wantUnchecked)
+			}
+		})
+	}
+}
+
+func TestScanCreatedAssertions(t *testing.T) {
+	const start = 10
+	var (
+		created = func(id, l1BlockNum uint64) *bindings.IRollupAssertionCreated {
+			return &bindings.IRollupAssertionCreated{AssertionID: new(big.Int).SetUint64(id), Raw: ethTypes.Log{BlockNumber: l1BlockNum}}
+		}
+		l1BridgeClient = &testAssertionsBridgeClient{
+			events: []*bindings.IRollupAssertionCreated{
+				created(1, start),
+				created(2, start+maxAssertionQueryRange),
+				created(3, start+maxAssertionQueryRange+5),
+			},
+		}
+		v = &Validator{l1BridgeClient: l1BridgeClient, nextL1BlockToScan: start}
+	)
+	scan := func(l1Head uint64, wantQueries [][2]uint64, wantIDs []uint64) {
+		t.Helper()
+		l1BridgeClient.queries = nil
+		v.l1State = testEthState{head: types.NewBlockID(l1Head, common.Hash{})}
+		if err := v.scanCreatedAssertions(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(l1BridgeClient.queries) != len(wantQueries) {
+			t.Fatalf("got queries %v, want %v", l1BridgeClient.queries, wantQueries)
+		}
+		for i := range wantQueries {
+			if l1BridgeClient.queries[i] != wantQueries[i] {
+				t.Errorf("got queries %v, want %v", l1BridgeClient.queries, wantQueries)
+				break
+			}
+		}
+		if len(v.uncheckedAssertions) != len(wantIDs) {
+			t.Fatalf("got unchecked assertions %v, want IDs %v", v.uncheckedAssertions, wantIDs)
+		}
+		for i := range wantIDs {
+			if v.uncheckedAssertions[i].id.Uint64() != wantIDs[i] {
+				t.Errorf("got unchecked assertions %v, want IDs %v", v.uncheckedAssertions, wantIDs)
+				break
+			}
+		}
+		if v.nextL1BlockToScan != l1Head+1 {
+			t.Errorf("got next L1 block to scan %d, want %d", v.nextL1BlockToScan, l1Head+1)
+		}
+	}
+	const end = start + maxAssertionQueryRange
+	// Scans in bounded ranges.
+	scan(end, [][2]uint64{{start, end - 1}, {end, end}}, []uint64{1, 2})
+	// Nothing new to scan.
+	scan(end, nil, []uint64{1, 2})
+	// Resumes from the last scanned block, without re-queuing assertions.
+	scan(end+5, [][2]uint64{{end + 1, end + 5}}, []uint64{1, 2, 3})
+}
+
+func TestHasUnresolvedInvalidAssertion(t *testing.T) {
+	var (
+		asserter = common.Address{1}
+		pending  = bindings.IRollupAssertion{ProposalTime: big.NewInt(1)}
+		rejected = bindings.IRollupAssertion{ProposalTime: common.Big0}
+	)
+	tests := []struct {
+		name        string
+		invalid     []uint64
+		assertions  map[uint64]bindings.IRollupAssertion
+		want        bool
+		wantInvalid int
+	}{
+		{"none", nil, nil, false, 0},
+		{"pending", []uint64{6}, map[uint64]bindings.IRollupAssertion{6: pending}, true, 1},
+		{"confirmed", []uint64{5}, nil, false, 0},
+		{"rejected", []uint64{6}, map[uint64]bindings.IRollupAssertion{6: rejected}, false, 0},
+		{"rejected and pending", []uint64{6, 7}, map[uint64]bindings.IRollupAssertion{6: rejected, 7: pending}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{
+				l1BridgeClient:    &testAssertionsBridgeClient{assertions: tt.assertions, lastConfirmedID: big.NewInt(5)},
+				invalidAssertions: make(map[uint64]common.Address),
+			}
+			for _, id := range tt.invalid {
+				v.invalidAssertions[id] = asserter
+			}
+			got, err := v.hasUnresolvedInvalidAssertion(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+			// Resolved assertions are no longer tracked.
+			if len(v.invalidAssertions) != tt.wantInvalid {
+				t.Errorf("got %d invalid assertions, want %d", len(v.invalidAssertions), tt.wantInvalid)
+			}
+		})
+	}
+}